@@ -0,0 +1,416 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+)
+
+type variable struct {
+	typ Type
+	// the type of annotated variables is declared, assignments are checked against it
+	annotated bool
+}
+
+type scope map[string]*variable
+
+// where a declaration is in the source, used to find the types the first pass made for it
+type position struct {
+	file   string
+	line   int
+	column int
+}
+
+func positionOf(tok expressions.Token) position {
+	return position{file: tok.File, line: tok.Line, column: tok.Column}
+}
+
+// static type checker.
+// runs after the resolver over resolved code, type annotations are optional (gradual typing):
+// unannotated parameters and return values are any, unannotated variables take the type of their
+// initializer when they are never assigned again. Values of type any are never reported.
+// implements statements and expressions visitor interface.
+type Checker struct {
+	scopes []scope
+	// names assigned after their declaration, the types inferred for them can't be trusted
+	reassigned map[string]bool
+	// the types of the functions and classes by the position of their names.
+	// made by the first pass so they are known before their declarations are reached
+	declared map[position]Type
+	// the first pass only collects, it reports nothing
+	collecting bool
+	// the function being checked, nil at the top level
+	fn *function
+	// the type of 'this', nil outside of methods
+	this Type
+}
+
+func New() *Checker {
+	return &Checker{
+		reassigned: map[string]bool{},
+		declared:   map[position]Type{},
+	}
+}
+
+// checks the program, errors are reported with the reporting package.
+// the first pass collects the reassigned names and the declared types, the second one reports
+func (c *Checker) Check(stmts []statements.Statement) {
+	c.collecting = true
+	c.run(stmts)
+	c.collecting = false
+	c.run(stmts)
+}
+
+func (c *Checker) run(stmts []statements.Statement) {
+	c.scopes = []scope{builtins(), {}}
+	c.fn = nil
+	c.this = nil
+	c.statements(stmts)
+}
+
+// the signatures of the built-in functions
+func builtins() scope {
+	native := func(name string, ret Type, params ...Type) *variable {
+		return &variable{typ: &function{name: name, params: params, ret: ret, required: len(params)}, annotated: true}
+	}
+	return scope{
+		"clock":      native("clock", floatType),
+		"len":        native("len", intType, anyType),
+		"str":        native("str", stringType, anyType),
+		"num":        native("num", numberType, anyType),
+		"int":        native("int", intType, anyType),
+		"float":      native("float", floatType, anyType),
+		"type":       native("type", stringType, anyType),
+		"input":      native("input", stringType),
+		"push":       native("push", nilType, listType, anyType),
+		"pop":        native("pop", anyType, listType),
+		"keys":       native("keys", listType, mapType),
+		"values":     native("values", listType, mapType),
+		"has":        native("has", boolType, mapType, anyType),
+		"delete":     native("delete", boolType, mapType, anyType),
+		"stackTrace": native("stackTrace", stringType),
+	}
+}
+
+func (c *Checker) error(tok expressions.Token, msg string) {
+	if !c.collecting {
+		reporting.ReportToken(tok, "", msg)
+	}
+}
+
+func (c *Checker) beginScope() {
+	c.scopes = append(c.scopes, scope{})
+}
+
+func (c *Checker) endScope() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+// declares the name in the innermost scope, the type of a reassigned unannotated name is any
+func (c *Checker) declare(name string, t Type, annotated bool) {
+	if !annotated && c.reassigned[name] {
+		t = anyType
+	}
+	c.scopes[len(c.scopes)-1][name] = &variable{typ: t, annotated: annotated}
+}
+
+// names declared outside of the checked code (e.g. by another module) are any
+func (c *Checker) lookup(name string) (*variable, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if v, ok := c.scopes[i][name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// the type named by an annotation, classes in scope are named by their names
+func (c *Checker) annotation(tok expressions.Token) Type {
+	if tok.Lexeme == "" {
+		return anyType
+	}
+	if t, ok := basics[tok.Lexeme]; ok {
+		return t
+	}
+	if v, ok := c.lookup(tok.Lexeme); ok {
+		if cls, ok := v.typ.(*class); ok {
+			return instance{class: cls}
+		}
+	}
+	c.error(tok, fmt.Sprintf("Unknown type '%s'.", tok.Lexeme))
+	return anyType
+}
+
+func (c *Checker) statements(stmts []statements.Statement) {
+	c.hoist(stmts)
+	for _, stmt := range stmts {
+		stmt.Accept(c)
+	}
+}
+
+// declares the functions and classes of the block before its statements are checked
+// so they can be called before they are declared, e.g. by a function declared earlier
+func (c *Checker) hoist(stmts []statements.Statement) {
+	for _, stmt := range stmts {
+		var name expressions.Token
+		switch stmt := stmt.(type) {
+		case statements.FunctionStatement:
+			name = stmt.Name
+		case statements.ClassStatement:
+			name = stmt.Name
+		default:
+			continue
+		}
+		if t, ok := c.declared[positionOf(name)]; ok {
+			c.declare(name.Lexeme, t, false)
+		}
+	}
+}
+
+// the type of the function declaration, the first pass makes it and the second one fills it again
+// since the annotations may name classes that are declared later
+func (c *Checker) signature(stmt statements.FunctionStatement) *function {
+	pos := positionOf(stmt.Name)
+	f, ok := c.declared[pos].(*function)
+	if !ok || stmt.Name.Lexeme == "" {
+		f = &function{}
+		if stmt.Name.Lexeme != "" {
+			c.declared[pos] = f
+		}
+	}
+	params := make([]Type, 0, len(stmt.Args))
+	for i := range stmt.Args {
+		var typ expressions.Token
+		if i < len(stmt.Types) {
+			typ = stmt.Types[i]
+		}
+		params = append(params, c.annotation(typ))
+	}
+	required, _ := stmt.Arity()
+	*f = function{name: stmt.Name.Lexeme, params: params, ret: c.annotation(stmt.ReturnType), required: required, variadic: stmt.Variadic}
+	return f
+}
+
+// checks the body of the function with its parameters in scope
+func (c *Checker) function(stmt statements.FunctionStatement, sig *function, this Type) {
+	enclosingFn, enclosingThis := c.fn, c.this
+	c.fn, c.this = sig, this
+	c.beginScope()
+	for i, param := range stmt.Args {
+		annotated := i < len(stmt.Types) && stmt.Types[i].Lexeme != ""
+		// the rest parameter is a list of the extra arguments
+		if stmt.Variadic && i == len(stmt.Args)-1 {
+			c.declare(param.Lexeme, listType, false)
+			break
+		}
+		if def := stmt.Default(i); def != nil {
+			if value := c.expression(def); annotated && !assignable(sig.params[i], value) {
+				c.error(param, fmt.Sprintf("Can't assign '%s' to '%s' of type '%s'.", value, param.Lexeme, sig.params[i]))
+			}
+		}
+		c.declare(param.Lexeme, sig.params[i], annotated)
+	}
+	c.statements(stmt.Body)
+	c.endScope()
+	c.fn, c.this = enclosingFn, enclosingThis
+}
+
+func (c *Checker) VisitPrintStmt(stmt statements.PrintStatement) error {
+	c.expression(stmt.Expr)
+	return nil
+}
+
+func (c *Checker) VisitExprStmt(stmt statements.ExperssionStatement) error {
+	c.expression(stmt.Expr)
+	return nil
+}
+
+// an unannotated variable without an initializer is assigned later so it's any
+func (c *Checker) VisitVarDecStmt(stmt statements.VarDecStatement) error {
+	value := Type(nilType)
+	if stmt.Initializer != nil {
+		value = c.expression(stmt.Initializer)
+	}
+	if stmt.Type.Lexeme == "" {
+		if stmt.Initializer == nil {
+			value = anyType
+		}
+		c.declare(stmt.Token.Lexeme, value, false)
+		return nil
+	}
+	declared := c.annotation(stmt.Type)
+	if !assignable(declared, value) {
+		c.error(stmt.Token, fmt.Sprintf("Can't assign '%s' to '%s' of type '%s'.", value, stmt.Token.Lexeme, declared))
+	}
+	c.declare(stmt.Token.Lexeme, declared, true)
+	return nil
+}
+
+func (c *Checker) VisitBlockStmt(stmt statements.BlockStatement) error {
+	c.beginScope()
+	c.statements(stmt.Statements)
+	c.endScope()
+	return nil
+}
+
+func (c *Checker) VisitIfStmt(stmt statements.IfStatement) error {
+	c.expression(stmt.Condition)
+	stmt.ThenBranch.Accept(c)
+	if stmt.ElseBranch != nil {
+		stmt.ElseBranch.Accept(c)
+	}
+	return nil
+}
+
+func (c *Checker) VisitWhileStmt(stmt statements.WhileStatement) error {
+	c.expression(stmt.Condition)
+	stmt.Body.Accept(c)
+	if stmt.Increment != nil {
+		c.expression(stmt.Increment)
+	}
+	return nil
+}
+
+func (c *Checker) VisitFunctionStmt(stmt statements.FunctionStatement) error {
+	sig := c.signature(stmt)
+	// declared before the body is checked so it can call itself
+	c.declare(stmt.Name.Lexeme, sig, false)
+	c.function(stmt, sig, nil)
+	return nil
+}
+
+func (c *Checker) VisitReturnStmt(stmt statements.ReturnStatement) error {
+	value := Type(nilType)
+	if stmt.Value != nil {
+		value = c.expression(stmt.Value)
+	}
+	if c.fn != nil && !assignable(c.fn.ret, value) {
+		c.error(stmt.Keyword, fmt.Sprintf("Can't return '%s' from '%s' declared to return '%s'.", value, c.fn.displayName(), c.fn.ret))
+	}
+	return nil
+}
+
+func (c *Checker) VisitClassStmt(stmt statements.ClassStatement) error {
+	pos := positionOf(stmt.Name)
+	cls, ok := c.declared[pos].(*class)
+	if !ok {
+		cls = newClass(stmt.Name.Lexeme)
+		c.declared[pos] = cls
+	}
+	c.declare(stmt.Name.Lexeme, cls, false)
+
+	cls.superclass = nil
+	if stmt.Superclass.Token.Lexeme != "" {
+		switch superclass := c.expression(stmt.Superclass).(type) {
+		case *class:
+			cls.superclass = superclass
+		case basic:
+			if superclass != anyType {
+				c.error(stmt.Superclass.Token, "Superclass must be a class.")
+			}
+		}
+	}
+
+	// the members are known before any body is checked, methods may call the ones declared after them
+	signatures := map[string]*function{}
+	for _, method := range stmt.Methods {
+		sig := c.signature(method)
+		signatures[method.Name.Lexeme] = sig
+		cls.methods[method.Name.Lexeme] = sig
+	}
+	for _, getter := range stmt.Getters {
+		cls.getters[getter.Name.Lexeme] = c.annotation(getter.ReturnType)
+	}
+	for _, method := range stmt.StaticMethods {
+		cls.statics[method.Name.Lexeme] = c.signature(method)
+	}
+
+	for _, field := range stmt.StaticFields {
+		if field.Initializer == nil {
+			continue
+		}
+		value := c.expression(field.Initializer)
+		if declared := c.annotation(field.Type); !assignable(declared, value) {
+			c.error(field.Token, fmt.Sprintf("Can't assign '%s' to '%s' of type '%s'.", value, field.Token.Lexeme, declared))
+		}
+	}
+	this := instance{class: cls}
+	for _, method := range stmt.Methods {
+		c.function(method, signatures[method.Name.Lexeme], this)
+	}
+	for _, getter := range stmt.Getters {
+		c.function(getter, &function{name: getter.Name.Lexeme, ret: cls.getters[getter.Name.Lexeme]}, this)
+	}
+	for _, method := range stmt.StaticMethods {
+		c.function(method, cls.statics[method.Name.Lexeme], nil)
+	}
+	return nil
+}
+
+func (c *Checker) VisitSwitchStmt(stmt statements.SwitchStatement) error {
+	c.expression(stmt.Value)
+	for _, clause := range stmt.Cases {
+		for _, value := range clause.Values {
+			c.expression(value)
+		}
+		c.beginScope()
+		c.statements(clause.Body)
+		c.endScope()
+	}
+	if stmt.Default != nil {
+		c.beginScope()
+		c.statements(stmt.Default)
+		c.endScope()
+	}
+	return nil
+}
+
+func (c *Checker) VisitBreakStmt(stmt statements.BreakStatement) error {
+	return nil
+}
+
+func (c *Checker) VisitContinueStmt(stmt statements.ContinueStatement) error {
+	return nil
+}
+
+func (c *Checker) VisitThrowStmt(stmt statements.ThrowStatement) error {
+	c.expression(stmt.Value)
+	return nil
+}
+
+func (c *Checker) VisitTryStmt(stmt statements.TryStatement) error {
+	c.beginScope()
+	c.statements(stmt.Body)
+	c.endScope()
+	if stmt.CatchBody != nil {
+		c.beginScope()
+		c.declare(stmt.CatchName.Lexeme, anyType, true)
+		c.statements(stmt.CatchBody)
+		c.endScope()
+	}
+	if stmt.FinallyBody != nil {
+		c.beginScope()
+		c.statements(stmt.FinallyBody)
+		c.endScope()
+	}
+	return nil
+}
+
+// imported modules aren't checked, their values are any
+func (c *Checker) VisitImportStmt(stmt statements.ImportStatement) error {
+	if len(stmt.Names) != 0 {
+		for _, name := range stmt.Names {
+			c.declare(name.Lexeme, anyType, true)
+		}
+		return nil
+	}
+	name := interpreter.ImportName(stmt)
+	if stmt.Alias.Lexeme != "" {
+		name = stmt.Alias.Lexeme
+	}
+	c.declare(name, anyType, true)
+	return nil
+}
@@ -0,0 +1,122 @@
+package checker_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/checker"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// scans, parses, resolves and checks the source returning the reported errors as "line: message".
+// syntax and resolution errors are returned too, the checker only runs without them
+func check(t *testing.T, source string) []string {
+	t.Helper()
+	errors := []string{}
+	diagnostics := reporting.Collect(func() {
+		stmts := parser.New(scanner.New(source).ScanTokens()).Parse()
+		if reporting.HadError() {
+			return
+		}
+		resolver.New(interpreter.New()).Resolve(stmts)
+		if !reporting.HadError() {
+			checker.New().Check(stmts)
+		}
+	})
+	for _, d := range diagnostics {
+		errors = append(errors, fmt.Sprintf("%d: %s", d.Line, d.Msg))
+	}
+	return errors
+}
+
+type checkTest struct {
+	name   string
+	source string
+	want   []string
+}
+
+func runTests(t *testing.T, tests []checkTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := check(t, test.source); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAnnotatedMismatches(t *testing.T) {
+	runTests(t, []checkTest{
+		{"initializer", `let s: string = 1;`, []string{"1: Can't assign 'int' to 's' of type 'string'."}},
+		{"assignment", "let count: int = 0;\ncount = \"none\";", []string{"2: Can't assign 'string' to 'count' of type 'int'."}},
+		{"return", `func f(): number { return "a"; }`, []string{"1: Can't return 'string' from 'f' declared to return 'number'."}},
+		{"getter", `class P { get n: number { return "x"; } }`, []string{"1: Can't return 'string' from 'n' declared to return 'number'."}},
+		{"argument", `func f(x: int) {} f("a");`, []string{"1: Argument 1 of 'f' expects 'int' but got 'string'."}},
+		{"rest argument", `func f(a, ...rest: int) {} f(1, 2, "x");`, []string{"1: Argument 3 of 'f' expects 'int' but got 'string'."}},
+		{"unknown type", `let x: Shape = 1;`, []string{"1: Unknown type 'Shape'."}},
+		{"subclass", `class A {} class B extends A {} let a: A = B(); let b: B = A();`, []string{"1: Can't assign 'A' to 'b' of type 'B'."}},
+		{"nil and int", `let s: string = nil; let f: float = 1;`, []string{}},
+	})
+}
+
+func TestInference(t *testing.T) {
+	runTests(t, []checkTest{
+		{"binary", `let label = "a"; print label - 1;`, []string{"1: Operand must be a number, got 'string' and 'int'."}},
+		{"unary", `let s = "a"; print -s;`, []string{"1: Operand must be a number, got 'string'."}},
+		{"index", `let xs = [1]; print xs["a"];`, []string{"1: Index must be a number, got 'string'."}},
+		{"call", `let b = true; b();`, []string{"1: Can't call a value of type 'bool'."}},
+		{"property", `let n = 1; n.x;`, []string{"1: Only instances have properties, got 'int'."}},
+		{"return type", `func f(): string { return "a"; } print f() - 1;`, []string{"1: Operand must be a number, got 'string' and 'int'."}},
+		{"reassigned", `let label = "a"; label = 1; print label - 1;`, []string{}},
+	})
+}
+
+func TestArity(t *testing.T) {
+	runTests(t, []checkTest{
+		{"defaults", `func f(a, b = 1) {} f(); f(1); f(1, 2); f(1, 2, 3);`, []string{
+			"1: Expected 1 to 2 arguments but got 0.",
+			"1: Expected 1 to 2 arguments but got 3.",
+		}},
+		{"rest", `func f(a, ...rest) {} f(); f(1, 2, 3);`, []string{"1: Expected at least 1 argument but got 0."}},
+		{"init", `class P { init(x) {} } P();`, []string{"1: Expected 1 argument but got 0."}},
+		{"no init", `class P {} P(1);`, []string{"1: Expected 0 arguments but got 1."}},
+		{"method", `class P { m(a) {} } P().m(1, 2);`, []string{"1: Expected 1 argument but got 2."}},
+		{"declared later", `f(); func f(a) {}`, []string{"1: Expected 1 argument but got 0."}},
+	})
+}
+
+func TestNativeArity(t *testing.T) {
+	runTests(t, []checkTest{
+		{"missing", `len();`, []string{"1: Expected 1 argument but got 0."}},
+		{"missing second", `push([1]);`, []string{"1: Expected 2 arguments but got 1."}},
+		{"extra", `len(1, 2);`, []string{"1: Expected 1 argument but got 2."}},
+		{"none expected", `clock(1);`, []string{"1: Expected 0 arguments but got 1."}},
+		{"exact", `len("ab"); push([1], 2); clock();`, []string{}},
+	})
+}
+
+// values of type any are never reported
+func TestUnannotatedCodePasses(t *testing.T) {
+	runTests(t, []checkTest{
+		{"parameters", `func f(x) { return x + 1; } print f("a") + 1; print f(1) - 1;`, []string{}},
+		{"fields hide methods", `class Area {
+  init(name) { this.name = name; }
+  name() { return this.name; }
+  format() { return this.name + " has inhabitants"; }
+}
+class City extends Area {
+  format() { return "The city " + super.format(); }
+}
+print City("Riyadh").format();`, []string{}},
+		{"inherited field", `class B { init() { this.v = 1; } } class C extends B { get w { return this.v + "a"; } }`, []string{}},
+		{"field assigned outside", `class P {} let p = P(); p.x = 1; print p.x + "a";`, []string{}},
+		{"containers", `let m = {"a": 1}; let xs = [1, "a"]; print xs[1] + m["a"];`, []string{}},
+		{"closures", `func counter() { let n = 0; return () => { n = n + 1; return n; }; } print counter()() + "a";`, []string{}},
+	})
+}
@@ -0,0 +1,353 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// the static type of the expression
+func (c *Checker) expression(expr expressions.Experssion) Type {
+	t, _ := expr.Accept(c)
+	if t == nil {
+		return anyType
+	}
+	return t.(Type)
+}
+
+// reports whether the values of the type may be numbers
+func maybeNumber(t Type) bool {
+	return t == anyType || isNumber(t)
+}
+
+func (c *Checker) VisitBinary(expr expressions.Binary) (interface{}, error) {
+	left := c.expression(expr.Left)
+	right := c.expression(expr.Right)
+	return c.binary(expr.Operator, left, right), nil
+}
+
+// the type of applying the operator to the operands, compound assignments share it with the binary expressions
+func (c *Checker) binary(operator expressions.Token, left Type, right Type) Type {
+	switch operator.Kind {
+	case scanner.MINUS, scanner.SLASH, scanner.STAR, scanner.PERCENT:
+		if !maybeNumber(left) || !maybeNumber(right) {
+			c.error(operator, fmt.Sprintf("Operand must be a number, got '%s' and '%s'.", left, right))
+		}
+		return arithmetic(left, right)
+	// a negative exponent makes the power of two ints a float
+	case scanner.STAR_STAR:
+		if !maybeNumber(left) || !maybeNumber(right) {
+			c.error(operator, fmt.Sprintf("Operand must be a number, got '%s' and '%s'.", left, right))
+		}
+		if left == intType && right == intType {
+			return numberType
+		}
+		return arithmetic(left, right)
+	// whole floats are allowed too, the result is always an int
+	case scanner.AMPERSAND, scanner.PIPE, scanner.CARET, scanner.LESS_LESS, scanner.GREATER_GREATER:
+		if !maybeNumber(left) || !maybeNumber(right) {
+			c.error(operator, fmt.Sprintf("Operand must be a number, got '%s' and '%s'.", left, right))
+		}
+		return intType
+	case scanner.PLUS:
+		switch {
+		case left == stringType && (right == stringType || right == anyType),
+			right == stringType && left == anyType:
+			return stringType
+		case maybeNumber(left) && maybeNumber(right):
+			if left == anyType && right == anyType {
+				return anyType
+			}
+			return arithmetic(left, right)
+		}
+		c.error(operator, fmt.Sprintf("Operands must be two numbers or two strings, got '%s' and '%s'.", left, right))
+		return anyType
+	case scanner.GREATER, scanner.GREATER_EQUAL, scanner.LESS, scanner.LESS_EQUAL:
+		if !maybeNumber(left) || !maybeNumber(right) {
+			c.error(operator, fmt.Sprintf("Operand must be a number, got '%s' and '%s'.", left, right))
+		}
+		return boolType
+	}
+	return boolType
+}
+
+func (c *Checker) VisitGrouping(expr expressions.Grouping) (interface{}, error) {
+	return c.expression(expr.Expr), nil
+}
+
+func (c *Checker) VisitLiteral(expr expressions.Literal) (interface{}, error) {
+	switch expr.Value.(type) {
+	case int64:
+		return intType, nil
+	case float64:
+		return floatType, nil
+	case string:
+		return stringType, nil
+	case bool:
+		return boolType, nil
+	case nil:
+		return nilType, nil
+	}
+	return anyType, nil
+}
+
+func (c *Checker) VisitUnary(expr expressions.Unary) (interface{}, error) {
+	right := c.expression(expr.Right)
+	if expr.Operator.Kind == scanner.BANG {
+		return boolType, nil
+	}
+	if !maybeNumber(right) {
+		c.error(expr.Operator, fmt.Sprintf("Operand must be a number, got '%s'.", right))
+		return numberType, nil
+	}
+	if expr.Operator.Kind == scanner.TILDE {
+		return intType, nil
+	}
+	if right == anyType {
+		return numberType, nil
+	}
+	return right, nil
+}
+
+func (c *Checker) VisitVairable(expr expressions.Variable) (interface{}, error) {
+	if v, ok := c.lookup(expr.Token.Lexeme); ok {
+		return v.typ, nil
+	}
+	return anyType, nil
+}
+
+func (c *Checker) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
+	value := c.expression(expr.Value)
+	c.reassigned[expr.Token.Lexeme] = true
+	v, ok := c.lookup(expr.Token.Lexeme)
+	var current Type = anyType
+	if ok {
+		current = v.typ
+	}
+	if expr.Operator.Lexeme != "" {
+		value = c.binary(expr.Operator, current, value)
+	}
+	if ok && v.annotated && !assignable(v.typ, value) {
+		c.error(expr.Token, fmt.Sprintf("Can't assign '%s' to '%s' of type '%s'.", value, expr.Token.Lexeme, v.typ))
+	}
+	if expr.Postfix {
+		return current, nil
+	}
+	return value, nil
+}
+
+// the value is one of the operands
+func (c *Checker) VisitLogical(expr expressions.Logical) (interface{}, error) {
+	return join(c.expression(expr.Left), c.expression(expr.Right)), nil
+}
+
+func (c *Checker) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	c.expression(expr.Condition)
+	return join(c.expression(expr.Then), c.expression(expr.Else)), nil
+}
+
+// the value is the left operand unless it's nil
+func (c *Checker) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	left := c.expression(expr.Left)
+	right := c.expression(expr.Right)
+	if left == nilType {
+		return right, nil
+	}
+	return join(left, right), nil
+}
+
+// nil is allowed everywhere so the chain has the type of its last access
+func (c *Checker) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	return c.expression(expr.Expr), nil
+}
+
+func (c *Checker) VisitCall(expr expressions.Call) (interface{}, error) {
+	callee := c.expression(expr.Callee)
+	args := make([]Type, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		args = append(args, c.expression(arg))
+	}
+	switch callee := callee.(type) {
+	case *function:
+		c.arguments(expr.Parenth, callee, args)
+		return callee.ret, nil
+	case *class:
+		// calling a class runs its initializer
+		if init, ok := callee.method("init"); ok {
+			c.arguments(expr.Parenth, init, args)
+		} else if len(args) != 0 {
+			c.error(expr.Parenth, fmt.Sprintf("Expected %s but got %d.", interpreter.DescribeArity(0, 0), len(args)))
+		}
+		return instance{class: callee}, nil
+	case basic:
+		if callee == anyType || callee == functionType {
+			return anyType, nil
+		}
+	}
+	c.error(expr.Parenth, fmt.Sprintf("Can't call a value of type '%s'.", callee))
+	return anyType, nil
+}
+
+// checks the number and the types of the arguments against the signature
+func (c *Checker) arguments(paren expressions.Token, sig *function, args []Type) {
+	min, max := sig.required, len(sig.params)
+	if sig.variadic {
+		max = -1
+	}
+	if !interpreter.AcceptsArguments(min, max, len(args)) {
+		c.error(paren, fmt.Sprintf("Expected %s but got %d.", interpreter.DescribeArity(min, max), len(args)))
+		return
+	}
+	for i, arg := range args {
+		// the extra arguments have the type of the rest parameter
+		param := sig.params[len(sig.params)-1]
+		if i < len(sig.params) {
+			param = sig.params[i]
+		}
+		if !assignable(param, arg) {
+			c.error(paren, fmt.Sprintf("Argument %d of '%s' expects '%s' but got '%s'.", i+1, sig.displayName(), param, arg))
+		}
+	}
+}
+
+// only instances and classes have properties, fields aren't declared so they are any
+func (c *Checker) VisitPropertyAccess(expr expressions.PropertyAccess) (interface{}, error) {
+	switch obj := c.expression(expr.Obj).(type) {
+	case instance:
+		if obj.class.field(expr.Name.Lexeme) {
+			return anyType, nil
+		}
+		if method, ok := obj.class.method(expr.Name.Lexeme); ok {
+			return method, nil
+		}
+		if t, ok := obj.class.getter(expr.Name.Lexeme); ok {
+			return t, nil
+		}
+	case *class:
+		if method, ok := obj.static(expr.Name.Lexeme); ok {
+			return method, nil
+		}
+	case basic:
+		// optional accesses on nil give nil
+		if obj != anyType && !(expr.Optional && obj == nilType) {
+			c.error(expr.Name, fmt.Sprintf("Only instances have properties, got '%s'.", obj))
+		}
+	}
+	return anyType, nil
+}
+
+func (c *Checker) VisitPropertyAssignment(expr expressions.PropertyAssignment) (interface{}, error) {
+	obj := c.expression(expr.Obj)
+	value := c.expression(expr.Value)
+	switch obj := obj.(type) {
+	case instance:
+		// the first pass records the field so the accesses checked before this assignment know it too
+		obj.class.fields[expr.Name.Lexeme] = true
+	case basic:
+		if obj != anyType {
+			c.error(expr.Name, fmt.Sprintf("Only instances and classes have fields, got '%s'.", obj))
+		}
+	}
+	// fields aren't declared, only the operand of compound assignments is checked
+	if expr.Operator.Lexeme != "" {
+		value = c.binary(expr.Operator, anyType, value)
+	}
+	if expr.Postfix {
+		return anyType, nil
+	}
+	return value, nil
+}
+
+func (c *Checker) VisitThis(expr expressions.This) (interface{}, error) {
+	if c.this == nil {
+		return anyType, nil
+	}
+	return c.this, nil
+}
+
+func (c *Checker) VisitSuper(expr expressions.Super) (interface{}, error) {
+	if this, ok := c.this.(instance); ok && this.class.superclass != nil {
+		if method, ok := this.class.superclass.method(expr.Method.Lexeme); ok {
+			return method, nil
+		}
+	}
+	return anyType, nil
+}
+
+func (c *Checker) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	for _, element := range expr.Elements {
+		c.expression(element)
+	}
+	return listType, nil
+}
+
+// lists are indexed by numbers, maps by anything and strings give strings
+func (c *Checker) VisitIndex(expr expressions.Index) (interface{}, error) {
+	obj := c.expression(expr.Obj)
+	index := c.expression(expr.Index)
+	switch obj {
+	case listType, stringType:
+		if !maybeNumber(index) {
+			c.error(expr.Bracket, fmt.Sprintf("Index must be a number, got '%s'.", index))
+		}
+		if obj == stringType {
+			return stringType, nil
+		}
+	case mapType, anyType:
+	default:
+		c.error(expr.Bracket, fmt.Sprintf("Object of type '%s' is not subscriptable.", obj))
+	}
+	return anyType, nil
+}
+
+func (c *Checker) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	obj := c.expression(expr.Obj)
+	index := c.expression(expr.Index)
+	value := c.expression(expr.Value)
+	switch obj {
+	case listType:
+		if !maybeNumber(index) {
+			c.error(expr.Bracket, fmt.Sprintf("Index must be a number, got '%s'.", index))
+		}
+	case mapType, anyType:
+	default:
+		c.error(expr.Bracket, fmt.Sprintf("Object of type '%s' does not support index assignment.", obj))
+	}
+	return value, nil
+}
+
+func (c *Checker) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	obj := c.expression(expr.Obj)
+	for _, bound := range []expressions.Experssion{expr.Start, expr.End} {
+		if bound == nil {
+			continue
+		}
+		if t := c.expression(bound); !maybeNumber(t) && t != nilType {
+			c.error(expr.Bracket, fmt.Sprintf("Slice bounds must be numbers, got '%s'.", t))
+		}
+	}
+	switch obj {
+	case listType, stringType, anyType:
+		return obj, nil
+	}
+	c.error(expr.Bracket, fmt.Sprintf("Object of type '%s' can't be sliced.", obj))
+	return anyType, nil
+}
+
+func (c *Checker) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	for i := range expr.Keys {
+		c.expression(expr.Keys[i])
+		c.expression(expr.Values[i])
+	}
+	return mapType, nil
+}
+
+func (c *Checker) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	function := expr.Function.(statements.FunctionStatement)
+	sig := c.signature(function)
+	c.function(function, sig, c.this)
+	return sig, nil
+}
@@ -0,0 +1,209 @@
+package checker
+
+import (
+	"strings"
+)
+
+// the static type of an expression, any is the type of everything the checker can't tell
+type Type interface {
+	String() string
+}
+
+// the built-in types, named as they are written in annotations
+type basic string
+
+const (
+	anyType      basic = "any"
+	numberType   basic = "number"
+	intType      basic = "int"
+	floatType    basic = "float"
+	stringType   basic = "string"
+	boolType     basic = "bool"
+	nilType      basic = "nil"
+	listType     basic = "list"
+	mapType      basic = "map"
+	functionType basic = "function"
+)
+
+var basics = map[string]basic{}
+
+func init() {
+	for _, t := range []basic{anyType, numberType, intType, floatType, stringType, boolType, nilType, listType, mapType, functionType} {
+		basics[string(t)] = t
+	}
+}
+
+func (b basic) String() string {
+	return string(b)
+}
+
+// the signature of a function, unannotated parameters and return values are any
+type function struct {
+	name   string
+	params []Type
+	ret    Type
+	// the parameters after the required ones have a default value.
+	// the last parameter of a variadic function is the type of each extra argument
+	required int
+	variadic bool
+}
+
+// the name in the errors, lambdas are anonymous like in the stack traces
+func (f *function) displayName() string {
+	if f.name == "" {
+		return "anonymous"
+	}
+	return f.name
+}
+
+func (f *function) String() string {
+	params := make([]string, 0, len(f.params))
+	for _, param := range f.params {
+		params = append(params, param.String())
+	}
+	if f.variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	return "func(" + strings.Join(params, ", ") + "): " + f.ret.String()
+}
+
+// the type of a class value, calling it makes an instance
+type class struct {
+	name       string
+	superclass *class
+	methods    map[string]*function
+	getters    map[string]Type
+	statics    map[string]*function
+	// names assigned on instances (e.g. 'this.name = name' in init), fields are looked up before
+	// getters and methods at runtime so they hide the members with the same name
+	fields map[string]bool
+}
+
+func newClass(name string) *class {
+	return &class{
+		name:    name,
+		methods: map[string]*function{},
+		getters: map[string]Type{},
+		statics: map[string]*function{},
+		fields:  map[string]bool{},
+	}
+}
+
+func (c *class) String() string {
+	return "class " + c.name
+}
+
+// looks up the method through the superclass chain
+func (c *class) method(name string) (*function, bool) {
+	for ; c != nil; c = c.superclass {
+		if m, ok := c.methods[name]; ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func (c *class) field(name string) bool {
+	for ; c != nil; c = c.superclass {
+		if c.fields[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *class) getter(name string) (Type, bool) {
+	for ; c != nil; c = c.superclass {
+		if t, ok := c.getters[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (c *class) static(name string) (*function, bool) {
+	for ; c != nil; c = c.superclass {
+		if m, ok := c.statics[name]; ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// reports whether the class is other or one of its subclasses
+func (c *class) extends(other *class) bool {
+	for ; c != nil; c = c.superclass {
+		if c == other {
+			return true
+		}
+	}
+	return false
+}
+
+// an instance of a class, annotations name it by the class name
+type instance struct {
+	class *class
+}
+
+func (i instance) String() string {
+	return i.class.name
+}
+
+func isNumber(t Type) bool {
+	return t == intType || t == floatType || t == numberType
+}
+
+// the type of an arithmetic result: ints stay ints and a float makes the result a float
+func arithmetic(left Type, right Type) Type {
+	switch {
+	case left == intType && right == intType:
+		return intType
+	case left == floatType || right == floatType:
+		return floatType
+	}
+	return numberType
+}
+
+// reports whether a value of the type from can be stored where the type to is expected.
+// any goes both ways and nil is allowed everywhere, the checker is gradual.
+// number is any number so it's allowed where a float or an int is expected,
+// only a float is known not to be an int
+func assignable(to Type, from Type) bool {
+	if to == anyType || from == anyType || from == nilType || to == from {
+		return true
+	}
+	switch to := to.(type) {
+	case basic:
+		switch to {
+		case numberType, floatType:
+			return isNumber(from)
+		case intType:
+			return from == numberType
+		case functionType:
+			switch from.(type) {
+			case *function, *class:
+				return true
+			}
+		}
+	case instance:
+		if from, ok := from.(instance); ok {
+			return from.class.extends(to.class)
+		}
+	}
+	return false
+}
+
+// the type of a value that is either of the two types
+func join(a Type, b Type) Type {
+	switch {
+	case a == b:
+		return a
+	case a == nilType:
+		return b
+	case b == nilType:
+		return a
+	case isNumber(a) && isNumber(b):
+		return numberType
+	}
+	return anyType
+}
@@ -0,0 +1,141 @@
+package compiler
+
+import "github.com/Ahmed-Sermani/prolang/parser/expressions"
+
+type OpCode byte
+
+// instructions are a one byte opcode followed by its operands.
+// constant pool indexes, names and jump offsets are two bytes (big endian),
+// local slots, upvalue indexes and argument counts are a single byte.
+const (
+	// push the constant at the index
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_DUP
+	// moves the value on top of the stack under the two values beneath it
+	OP_ROTATE
+	// locals live in the stack window of the call frame
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	// globals are looked up by the name at the constant index
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	// defines a global that can't be assigned, the resolver rejects declaring the name again
+	OP_DEFINE_CONSTANT
+	OP_SET_GLOBAL
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	// pops the superclass and the receiver and pushes the method bound to the receiver
+	OP_GET_SUPER
+	OP_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_MODULO
+	OP_POWER
+	OP_BIT_AND
+	OP_BIT_OR
+	OP_BIT_XOR
+	OP_SHIFT_LEFT
+	OP_SHIFT_RIGHT
+	OP_NOT
+	OP_NEGATE
+	OP_BIT_NOT
+	OP_PRINT
+	// jumps are relative to the end of the instruction, OP_LOOP jumps backward
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	// jumps when the value on top of the stack is nil, the value is left on the stack
+	OP_JUMP_IF_NIL
+	// followed by a parameter index, jumps when the call passed an argument for the parameter
+	OP_JUMP_IF_PASSED
+	OP_LOOP
+	OP_CALL
+	// followed by a pair of bytes (is local, index) per captured variable
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	OP_CLASS
+	// sets the superclass of the class on top of the stack and pops it
+	OP_INHERIT
+	OP_METHOD
+	OP_GETTER
+	OP_STATIC_METHOD
+	// build a list (map) out of the top count values (key value pairs)
+	OP_LIST
+	OP_MAP
+	OP_GET_INDEX
+	OP_SET_INDEX
+	OP_SLICE
+	OP_THROW
+	// raises the error a finally clause was run for once the clause is done
+	OP_RETHROW
+	// installs an error handler, the operands are a flag set when the handler
+	// is a catch clause and the offset of the handler code
+	OP_TRY
+	// removes the innermost error handler
+	OP_END_TRY
+	// loads the module at the path in the constant pool and pushes it, a module is run once
+	OP_IMPORT
+)
+
+// a sequence of bytecode with the constants it refers to
+type Chunk struct {
+	Code []byte
+	// source token of every byte in Code, used to locate runtime errors
+	Tokens    []expressions.Token
+	Constants []interface{}
+}
+
+func (c *Chunk) write(b byte, tok expressions.Token) {
+	c.Code = append(c.Code, b)
+	c.Tokens = append(c.Tokens, tok)
+}
+
+func (c *Chunk) addConstant(value interface{}) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// compiled function, it lives in the constant pool of the enclosing function
+// and the vm wraps it into a closure when the declaration runs.
+// the top level script is compiled into a function as well.
+type Function struct {
+	Name string
+	// the class declaring the method, empty for functions
+	Class string
+	// the number of parameters, the rest parameter isn't counted.
+	// the parameters after the Required ones have a default value
+	Arity    int
+	Required int
+	// the extra arguments are collected in a list, it's the last parameter
+	Variadic     bool
+	UpvalueCount int
+	Chunk        Chunk
+	script       bool
+}
+
+// reports whether the function is the top level code of a script
+func (f *Function) IsScript() bool {
+	return f.script
+}
+
+func (f *Function) String() string {
+	if f.script {
+		return "<script>"
+	}
+	if f.Name == "" {
+		return "<func anonymous>"
+	}
+	return "<func " + f.Name + ">"
+}
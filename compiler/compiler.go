@@ -0,0 +1,1039 @@
+package compiler
+
+import (
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// the vm addresses locals and upvalues with a single byte
+const maxSlots = 256
+
+type functionKind int
+
+const (
+	SCRIPT functionKind = iota
+	FUNCTION
+	METHOD
+	INITIALIZER
+	STATIC
+)
+
+type local struct {
+	name string
+	// scope depth the local was declared at
+	depth int
+	// set when a closure captures the local so it's closed over when its scope ends
+	captured bool
+}
+
+type upvalue struct {
+	index byte
+	// whether index is a local slot of the enclosing function or one of its upvalues
+	isLocal bool
+}
+
+type loop struct {
+	label string
+	// scope depth enclosing the loop body, locals deeper than it are discarded on break and continue
+	depth int
+	// number of error handlers installed when the loop started
+	handlers int
+	breaks   []int
+	// continue jumps forward to the increment so they're patched once the body is compiled
+	continues []int
+}
+
+// an error handler installed at runtime while the code of a try body (or a catch body
+// followed by finally) runs. jumping out of it has to remove it and run the finally clause.
+type handler struct {
+	finally []statements.Statement
+	// number of loops when the handler was installed
+	loops int
+}
+
+// compilation state of a single function
+type funcCompiler struct {
+	enclosing *funcCompiler
+	function  *Function
+	kind      functionKind
+	locals    []local
+	upvalues  []upvalue
+	depth     int
+	loops     []*loop
+	handlers  []handler
+	// the jumps out of each optional chain being compiled, they're patched at the end of the chain
+	chains [][]int
+	// constant index of every name used by the function
+	names map[string]int
+}
+
+// lowers the resolved statements into bytecode.
+// It implements the expression and statement visitors, emitting the code of each node into
+// the function being compiled. Locals are assigned stack slots at compile time and the
+// variables captured by closures become upvalues. Top level names are globals.
+type Compiler struct {
+	current *funcCompiler
+	// the class whose members are being compiled
+	class string
+	// the last token seen, it's attached to every emitted byte
+	token expressions.Token
+}
+
+func New() *Compiler {
+	return &Compiler{}
+}
+
+// compiles a program into the function of the top level script.
+// compile errors are reported through the reporting package.
+func (c *Compiler) Compile(stmts []statements.Statement) *Function {
+	c.current = &funcCompiler{
+		function: &Function{script: true},
+		kind:     SCRIPT,
+		names:    map[string]int{},
+	}
+	// slot zero holds the function being called
+	c.current.locals = append(c.current.locals, local{name: "", depth: 0})
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+	c.emitReturn()
+	return c.current.function
+}
+
+func (c *Compiler) statement(stmt statements.Statement) {
+	stmt.Accept(c)
+}
+
+func (c *Compiler) statements(stmts []statements.Statement) {
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+}
+
+func (c *Compiler) expression(expr expressions.Experssion) {
+	expr.Accept(c)
+}
+
+func (c *Compiler) error(msg string) {
+	reporting.ReportToken(c.token, "", msg)
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return &c.current.function.Chunk
+}
+
+func (c *Compiler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().write(b, c.token)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, operands ...byte) {
+	c.emit(byte(op))
+	c.emit(operands...)
+}
+
+func (c *Compiler) emitShort(op OpCode, operand int) {
+	c.emit(byte(op), byte(operand>>8), byte(operand))
+}
+
+func (c *Compiler) makeConstant(value interface{}) int {
+	index := c.chunk().addConstant(value)
+	if index > 0xffff {
+		c.error("Too many constants in one function.")
+		return 0
+	}
+	return index
+}
+
+func (c *Compiler) emitConstant(value interface{}) {
+	c.emitShort(OP_CONSTANT, c.makeConstant(value))
+}
+
+// constant index of a name, names are added to the constant pool once per function
+func (c *Compiler) name(name string) int {
+	if index, ok := c.current.names[name]; ok {
+		return index
+	}
+	index := c.makeConstant(name)
+	c.current.names[name] = index
+	return index
+}
+
+// emits a forward jump with a placeholder offset and returns the position of the offset
+func (c *Compiler) emitJump(op OpCode, operands ...byte) int {
+	c.emitOp(op, operands...)
+	c.emit(0xff, 0xff)
+	return len(c.chunk().Code) - 2
+}
+
+// points the jump at position to the next instruction to be emitted
+func (c *Compiler) patchJump(position int) {
+	jump := len(c.chunk().Code) - position - 2
+	if jump > 0xffff {
+		c.error("Too much code to jump over.")
+	}
+	c.chunk().Code[position] = byte(jump >> 8)
+	c.chunk().Code[position+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(start int) {
+	jump := len(c.chunk().Code) - start + 3
+	if jump > 0xffff {
+		c.error("Loop body too large.")
+	}
+	c.emitShort(OP_LOOP, jump)
+}
+
+// initializers always return 'this', other functions return nil by default
+func (c *Compiler) emitReturn() {
+	if c.current.kind == INITIALIZER {
+		c.emitOp(OP_GET_LOCAL, 0)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	c.emitOp(OP_RETURN)
+}
+
+func (c *Compiler) beginScope() {
+	c.current.depth++
+}
+
+func (c *Compiler) endScope() {
+	c.current.depth--
+	locals := c.current.locals
+	for len(locals) > 0 && locals[len(locals)-1].depth > c.current.depth {
+		c.discard(locals[len(locals)-1])
+		locals = locals[:len(locals)-1]
+	}
+	c.current.locals = locals
+}
+
+// emits the code removing the locals deeper than depth from the stack without forgetting them,
+// it's used by jumps leaving scopes whose code continues after the jump.
+// a closure compiled later in the scope may still capture them so they're always closed over
+func (c *Compiler) discardLocals(depth int) {
+	locals := c.current.locals
+	for i := len(locals) - 1; i >= 0 && locals[i].depth > depth; i-- {
+		c.emitOp(OP_CLOSE_UPVALUE)
+	}
+}
+
+func (c *Compiler) discard(l local) {
+	if l.captured {
+		c.emitOp(OP_CLOSE_UPVALUE)
+	} else {
+		c.emitOp(OP_POP)
+	}
+}
+
+// adds a local for the value on top of the stack
+func (c *Compiler) addLocal(name string) {
+	if len(c.current.locals) == maxSlots {
+		c.error("Too many local variables in function.")
+		return
+	}
+	c.current.locals = append(c.current.locals, local{name: name, depth: c.current.depth})
+}
+
+// binds the value on top of the stack to a new variable in the current scope.
+// the resolver rejects assigning local constants, global ones are checked when the assignment runs
+func (c *Compiler) defineVariable(name string, constant bool) {
+	if c.current.depth > 0 {
+		c.addLocal(name)
+		return
+	}
+	if constant {
+		c.emitShort(OP_DEFINE_CONSTANT, c.name(name))
+		return
+	}
+	c.emitShort(OP_DEFINE_GLOBAL, c.name(name))
+}
+
+func resolveLocal(fc *funcCompiler, name string) int {
+	for i := len(fc.locals) - 1; i >= 0; i-- {
+		if fc.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// looks the name up in the enclosing functions, every function in between
+// gets an upvalue so the variable is passed down from closure to closure
+func (c *Compiler) resolveUpvalue(fc *funcCompiler, name string) int {
+	if fc.enclosing == nil {
+		return -1
+	}
+	if slot := resolveLocal(fc.enclosing, name); slot != -1 {
+		fc.enclosing.locals[slot].captured = true
+		return c.addUpvalue(fc, byte(slot), true)
+	}
+	if index := c.resolveUpvalue(fc.enclosing, name); index != -1 {
+		return c.addUpvalue(fc, byte(index), false)
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(fc *funcCompiler, index byte, isLocal bool) int {
+	for i, uv := range fc.upvalues {
+		if uv.index == index && uv.isLocal == isLocal {
+			return i
+		}
+	}
+	if len(fc.upvalues) == maxSlots {
+		c.error("Too many closure variables in function.")
+		return 0
+	}
+	fc.upvalues = append(fc.upvalues, upvalue{index: index, isLocal: isLocal})
+	fc.function.UpvalueCount = len(fc.upvalues)
+	return len(fc.upvalues) - 1
+}
+
+func (c *Compiler) getVariable(name string) {
+	if slot := resolveLocal(c.current, name); slot != -1 {
+		c.emitOp(OP_GET_LOCAL, byte(slot))
+	} else if index := c.resolveUpvalue(c.current, name); index != -1 {
+		c.emitOp(OP_GET_UPVALUE, byte(index))
+	} else {
+		c.emitShort(OP_GET_GLOBAL, c.name(name))
+	}
+}
+
+func (c *Compiler) setVariable(name string) {
+	if slot := resolveLocal(c.current, name); slot != -1 {
+		c.emitOp(OP_SET_LOCAL, byte(slot))
+	} else if index := c.resolveUpvalue(c.current, name); index != -1 {
+		c.emitOp(OP_SET_UPVALUE, byte(index))
+	} else {
+		c.emitShort(OP_SET_GLOBAL, c.name(name))
+	}
+}
+
+// compiles the function into its own chunk and emits the closure creating it.
+// slot zero of methods and initializers holds 'this'
+func (c *Compiler) function(declaration statements.FunctionStatement, kind functionKind) {
+	fc := &funcCompiler{
+		enclosing: c.current,
+		function:  &Function{Name: declaration.Name.Lexeme},
+		kind:      kind,
+		names:     map[string]int{},
+	}
+	if kind != FUNCTION {
+		fc.function.Class = c.class
+	}
+	receiver := ""
+	if kind == METHOD || kind == INITIALIZER {
+		receiver = "this"
+	}
+	fc.locals = append(fc.locals, local{name: receiver, depth: 0})
+	fc.function.Required, fc.function.Arity = declaration.Arity()
+	if declaration.Variadic {
+		fc.function.Arity = len(declaration.Args) - 1
+		fc.function.Variadic = true
+	}
+	c.current = fc
+
+	c.beginScope()
+	// the call leaves nil in the parameters without an argument, the defaults replace them.
+	// a default is compiled before its parameter is declared so it only sees the parameters before it
+	for i, arg := range declaration.Args {
+		if def := declaration.Default(i); def != nil {
+			c.token = arg
+			skip := c.emitJump(OP_JUMP_IF_PASSED, byte(i))
+			c.expression(def)
+			c.emitOp(OP_SET_LOCAL, byte(i+1))
+			c.emitOp(OP_POP)
+			c.patchJump(skip)
+		}
+		c.addLocal(arg.Lexeme)
+	}
+	c.statements(declaration.Body)
+	c.emitReturn()
+	c.current = fc.enclosing
+
+	c.emitShort(OP_CLOSURE, c.makeConstant(fc.function))
+	for _, uv := range fc.upvalues {
+		isLocal := byte(0)
+		if uv.isLocal {
+			isLocal = 1
+		}
+		c.emit(isLocal, uv.index)
+	}
+}
+
+// compiles a finally clause, it's inlined on every path leaving the guarded code
+func (c *Compiler) finallyBlock(stmts []statements.Statement) {
+	c.beginScope()
+	c.statements(stmts)
+	c.endScope()
+}
+
+// removes the error handlers installed after the first count ones running their finally clauses,
+// for return, break and continue leaving try statements
+func (c *Compiler) exitHandlers(count int) {
+	fc := c.current
+	handlers, loops := fc.handlers, fc.loops
+	for i := len(handlers) - 1; i >= count; i-- {
+		c.emitOp(OP_END_TRY)
+		if handlers[i].finally != nil {
+			// the clause runs outside of the handler and of the loops inside it
+			fc.handlers, fc.loops = handlers[:i], loops[:handlers[i].loops]
+			c.finallyBlock(handlers[i].finally)
+		}
+	}
+	fc.handlers, fc.loops = handlers, loops
+}
+
+// unlabeled break and continue target the innermost loop, the resolver made sure the loop exists
+func (c *Compiler) findLoop(label string) *loop {
+	loops := c.current.loops
+	for i := len(loops) - 1; i >= 0; i-- {
+		if label == "" || loops[i].label == label {
+			return loops[i]
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) VisitLiteral(expr expressions.Literal) (interface{}, error) {
+	switch expr.Value {
+	case nil:
+		c.emitOp(OP_NIL)
+	case true:
+		c.emitOp(OP_TRUE)
+	case false:
+		c.emitOp(OP_FALSE)
+	default:
+		c.emitConstant(expr.Value)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitGrouping(expr expressions.Grouping) (interface{}, error) {
+	c.expression(expr.Expr)
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnary(expr expressions.Unary) (interface{}, error) {
+	c.expression(expr.Right)
+	c.token = expr.Operator
+	switch expr.Operator.Kind {
+	case scanner.MINUS:
+		c.emitOp(OP_NEGATE)
+	case scanner.TILDE:
+		c.emitOp(OP_BIT_NOT)
+	case scanner.BANG:
+		c.emitOp(OP_NOT)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitBinary(expr expressions.Binary) (interface{}, error) {
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+	c.token = expr.Operator
+	switch expr.Operator.Kind {
+	case scanner.PLUS, scanner.MINUS, scanner.STAR, scanner.SLASH, scanner.PERCENT, scanner.STAR_STAR,
+		scanner.AMPERSAND, scanner.PIPE, scanner.CARET, scanner.LESS_LESS, scanner.GREATER_GREATER:
+		c.arithmetic(expr.Operator)
+	case scanner.GREATER:
+		c.emitOp(OP_GREATER)
+	case scanner.GREATER_EQUAL:
+		c.emitOp(OP_GREATER_EQUAL)
+	case scanner.LESS:
+		c.emitOp(OP_LESS)
+	case scanner.LESS_EQUAL:
+		c.emitOp(OP_LESS_EQUAL)
+	case scanner.EQUAL_EQUAL:
+		c.emitOp(OP_EQUAL)
+	case scanner.BANG_EQUAL:
+		c.emitOp(OP_EQUAL)
+		c.emitOp(OP_NOT)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) arithmetic(operator expressions.Token) {
+	c.token = operator
+	switch operator.Kind {
+	case scanner.PLUS:
+		c.emitOp(OP_ADD)
+	case scanner.MINUS:
+		c.emitOp(OP_SUBTRACT)
+	case scanner.STAR:
+		c.emitOp(OP_MULTIPLY)
+	case scanner.SLASH:
+		c.emitOp(OP_DIVIDE)
+	case scanner.PERCENT:
+		c.emitOp(OP_MODULO)
+	case scanner.STAR_STAR:
+		c.emitOp(OP_POWER)
+	case scanner.AMPERSAND:
+		c.emitOp(OP_BIT_AND)
+	case scanner.PIPE:
+		c.emitOp(OP_BIT_OR)
+	case scanner.CARET:
+		c.emitOp(OP_BIT_XOR)
+	case scanner.LESS_LESS:
+		c.emitOp(OP_SHIFT_LEFT)
+	case scanner.GREATER_GREATER:
+		c.emitOp(OP_SHIFT_RIGHT)
+	}
+}
+
+func (c *Compiler) VisitVairable(expr expressions.Variable) (interface{}, error) {
+	c.token = expr.Token
+	c.getVariable(expr.Token.Lexeme)
+	return nil, nil
+}
+
+// compound assignments push the current value first and combine it with the r-value,
+// x++ keeps a copy of the current value under the new one as its result
+func (c *Compiler) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
+	if expr.Operator.Lexeme == "" {
+		c.expression(expr.Value)
+		c.token = expr.Token
+		c.setVariable(expr.Token.Lexeme)
+		return nil, nil
+	}
+	c.token = expr.Token
+	c.getVariable(expr.Token.Lexeme)
+	if expr.Postfix {
+		c.emitOp(OP_DUP)
+	}
+	c.expression(expr.Value)
+	c.arithmetic(expr.Operator)
+	c.token = expr.Token
+	c.setVariable(expr.Token.Lexeme)
+	if expr.Postfix {
+		c.emitOp(OP_POP)
+	}
+	return nil, nil
+}
+
+// the left operand is left on the stack as the result when it short-circuits
+func (c *Compiler) VisitLogical(expr expressions.Logical) (interface{}, error) {
+	c.expression(expr.Left)
+	c.token = expr.Operator
+	if expr.Operator.Kind == scanner.OR {
+		elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+		endJump := c.emitJump(OP_JUMP)
+		c.patchJump(elseJump)
+		c.emitOp(OP_POP)
+		c.expression(expr.Right)
+		c.patchJump(endJump)
+		return nil, nil
+	}
+	endJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.expression(expr.Right)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	c.expression(expr.Condition)
+	c.token = expr.Question
+	elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.expression(expr.Then)
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(elseJump)
+	c.emitOp(OP_POP)
+	c.expression(expr.Else)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+// like 'or' the left operand is left on the stack as the result unless it's nil
+func (c *Compiler) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	c.expression(expr.Left)
+	c.token = expr.Operator
+	elseJump := c.emitJump(OP_JUMP_IF_NIL)
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(elseJump)
+	c.emitOp(OP_POP)
+	c.expression(expr.Right)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+// optional accesses on nil jump to the end of the chain leaving the nil as its value
+func (c *Compiler) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	c.current.chains = append(c.current.chains, nil)
+	c.expression(expr.Expr)
+	jumps := c.current.chains[len(c.current.chains)-1]
+	c.current.chains = c.current.chains[:len(c.current.chains)-1]
+	for _, jump := range jumps {
+		c.patchJump(jump)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitCall(expr expressions.Call) (interface{}, error) {
+	c.expression(expr.Callee)
+	for _, arg := range expr.Args {
+		c.expression(arg)
+	}
+	c.token = expr.Parenth
+	if len(expr.Args) >= maxSlots {
+		c.error("Can't have more than 255 arguments.")
+	}
+	c.emitOp(OP_CALL, byte(len(expr.Args)))
+	return nil, nil
+}
+
+func (c *Compiler) VisitPropertyAccess(expr expressions.PropertyAccess) (interface{}, error) {
+	c.expression(expr.Obj)
+	c.token = expr.Name
+	if expr.Optional {
+		chain := len(c.current.chains) - 1
+		c.current.chains[chain] = append(c.current.chains[chain], c.emitJump(OP_JUMP_IF_NIL))
+	}
+	c.emitShort(OP_GET_PROPERTY, c.name(expr.Name.Lexeme))
+	return nil, nil
+}
+
+// the object of compound assignments is duplicated to read the property and assign it
+func (c *Compiler) VisitPropertyAssignment(expr expressions.PropertyAssignment) (interface{}, error) {
+	c.expression(expr.Obj)
+	if expr.Operator.Lexeme != "" {
+		c.token = expr.Name
+		c.emitOp(OP_DUP)
+		c.emitShort(OP_GET_PROPERTY, c.name(expr.Name.Lexeme))
+		if expr.Postfix {
+			// object, current -> current, object, current
+			c.emitOp(OP_DUP)
+			c.emitOp(OP_ROTATE)
+		}
+	}
+	c.expression(expr.Value)
+	if expr.Operator.Lexeme != "" {
+		c.arithmetic(expr.Operator)
+	}
+	c.token = expr.Name
+	c.emitShort(OP_SET_PROPERTY, c.name(expr.Name.Lexeme))
+	if expr.Postfix {
+		c.emitOp(OP_POP)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitThis(expr expressions.This) (interface{}, error) {
+	c.token = expr.Keywork
+	c.getVariable("this")
+	return nil, nil
+}
+
+// 'super' is a local of the scope enclosing the methods, holding the superclass
+func (c *Compiler) VisitSuper(expr expressions.Super) (interface{}, error) {
+	c.token = expr.Keyword
+	c.getVariable("this")
+	c.getVariable("super")
+	c.emitShort(OP_GET_SUPER, c.name(expr.Method.Lexeme))
+	return nil, nil
+}
+
+func (c *Compiler) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	c.token = expr.Keyword
+	c.function(expr.Function.(statements.FunctionStatement), FUNCTION)
+	return nil, nil
+}
+
+// literals don't have a token, the elements are located at the bracket until they set their own
+func (c *Compiler) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	c.token = expr.Bracket
+	for _, element := range expr.Elements {
+		c.expression(element)
+	}
+	c.token = expr.Bracket
+	c.emitShort(OP_LIST, len(expr.Elements))
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	c.token = expr.Brace
+	for i := range expr.Keys {
+		c.expression(expr.Keys[i])
+		c.expression(expr.Values[i])
+	}
+	c.token = expr.Brace
+	c.emitShort(OP_MAP, len(expr.Keys))
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndex(expr expressions.Index) (interface{}, error) {
+	c.expression(expr.Obj)
+	c.expression(expr.Index)
+	c.token = expr.Bracket
+	c.emitOp(OP_GET_INDEX)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	c.expression(expr.Obj)
+	c.expression(expr.Index)
+	c.expression(expr.Value)
+	c.token = expr.Bracket
+	c.emitOp(OP_SET_INDEX)
+	return nil, nil
+}
+
+// omitted bounds are pushed as nil
+func (c *Compiler) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	c.expression(expr.Obj)
+	for _, bound := range []expressions.Experssion{expr.Start, expr.End} {
+		if bound != nil {
+			c.expression(bound)
+		} else {
+			c.emitOp(OP_NIL)
+		}
+	}
+	c.token = expr.Bracket
+	c.emitOp(OP_SLICE)
+	return nil, nil
+}
+
+func (c *Compiler) VisitExprStmt(stmt statements.ExperssionStatement) error {
+	c.expression(stmt.Expr)
+	c.emitOp(OP_POP)
+	return nil
+}
+
+func (c *Compiler) VisitPrintStmt(stmt statements.PrintStatement) error {
+	c.token = stmt.Keyword
+	c.expression(stmt.Expr)
+	c.emitOp(OP_PRINT)
+	return nil
+}
+
+func (c *Compiler) VisitVarDecStmt(stmt statements.VarDecStatement) error {
+	c.token = stmt.Token
+	if stmt.Initializer != nil {
+		c.expression(stmt.Initializer)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	c.token = stmt.Token
+	c.defineVariable(stmt.Token.Lexeme, stmt.Constant)
+	return nil
+}
+
+func (c *Compiler) VisitBlockStmt(stmt statements.BlockStatement) error {
+	c.beginScope()
+	c.statements(stmt.Statements)
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitIfStmt(stmt statements.IfStatement) error {
+	c.expression(stmt.Condition)
+	elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+	c.statement(stmt.ThenBranch)
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(elseJump)
+	c.emitOp(OP_POP)
+	if stmt.ElseBranch != nil {
+		c.statement(stmt.ElseBranch)
+	}
+	c.patchJump(endJump)
+	return nil
+}
+
+// continue jumps to the increment, break jumps past the loop
+func (c *Compiler) VisitWhileStmt(stmt statements.WhileStatement) error {
+	start := len(c.chunk().Code)
+	c.expression(stmt.Condition)
+	exitJump := c.emitJump(OP_JUMP_IF_FALSE)
+	c.emitOp(OP_POP)
+
+	l := &loop{label: stmt.Label.Lexeme, depth: c.current.depth, handlers: len(c.current.handlers)}
+	c.current.loops = append(c.current.loops, l)
+	c.statement(stmt.Body)
+	c.current.loops = c.current.loops[:len(c.current.loops)-1]
+
+	for _, jump := range l.continues {
+		c.patchJump(jump)
+	}
+	if stmt.Increment != nil {
+		c.expression(stmt.Increment)
+		c.emitOp(OP_POP)
+	}
+	c.emitLoop(start)
+	c.patchJump(exitJump)
+	c.emitOp(OP_POP)
+	for _, jump := range l.breaks {
+		c.patchJump(jump)
+	}
+	return nil
+}
+
+// the switch value is kept in a hidden local, each case value is compared with it in turn.
+// a matching value jumps to the case body and the end of every body jumps past the switch
+func (c *Compiler) VisitSwitchStmt(stmt statements.SwitchStatement) error {
+	c.beginScope()
+	c.expression(stmt.Value)
+	c.addLocal("")
+	slot := byte(len(c.current.locals) - 1)
+
+	ends := []int{}
+	for _, clause := range stmt.Cases {
+		c.token = clause.Keyword
+		matches := []int{}
+		for _, value := range clause.Values {
+			c.emitOp(OP_GET_LOCAL, slot)
+			c.expression(value)
+			c.emitOp(OP_EQUAL)
+			next := c.emitJump(OP_JUMP_IF_FALSE)
+			c.emitOp(OP_POP)
+			matches = append(matches, c.emitJump(OP_JUMP))
+			c.patchJump(next)
+			c.emitOp(OP_POP)
+		}
+		nextCase := c.emitJump(OP_JUMP)
+		for _, jump := range matches {
+			c.patchJump(jump)
+		}
+		c.beginScope()
+		c.statements(clause.Body)
+		c.endScope()
+		ends = append(ends, c.emitJump(OP_JUMP))
+		c.patchJump(nextCase)
+	}
+	if stmt.Default != nil {
+		c.beginScope()
+		c.statements(stmt.Default)
+		c.endScope()
+	}
+	for _, jump := range ends {
+		c.patchJump(jump)
+	}
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitBreakStmt(stmt statements.BreakStatement) error {
+	c.token = stmt.Keyword
+	l := c.findLoop(stmt.Label.Lexeme)
+	c.exitHandlers(l.handlers)
+	c.discardLocals(l.depth)
+	l.breaks = append(l.breaks, c.emitJump(OP_JUMP))
+	return nil
+}
+
+func (c *Compiler) VisitContinueStmt(stmt statements.ContinueStatement) error {
+	c.token = stmt.Keyword
+	l := c.findLoop(stmt.Label.Lexeme)
+	c.exitHandlers(l.handlers)
+	c.discardLocals(l.depth)
+	l.continues = append(l.continues, c.emitJump(OP_JUMP))
+	return nil
+}
+
+func (c *Compiler) VisitThrowStmt(stmt statements.ThrowStatement) error {
+	c.expression(stmt.Value)
+	c.token = stmt.Keyword
+	c.emitOp(OP_THROW)
+	return nil
+}
+
+// the try body runs under a handler jumping to the catch clause (or straight to the finally
+// clause when there's no catch). the handler restores the stack to the locals the statement
+// started with and pushes the error, so it becomes the catch variable or a hidden local
+// the finally clause raises again once it's done.
+// the finally clause is inlined after the body, after the catch clause and on the error path.
+func (c *Compiler) VisitTryStmt(stmt statements.TryStatement) error {
+	c.token = stmt.Keyword
+	fc := c.current
+	hasCatch := stmt.CatchBody != nil
+	hasFinally := stmt.FinallyBody != nil
+
+	handlerJump := c.emitJump(OP_TRY, boolByte(hasCatch))
+	fc.handlers = append(fc.handlers, handler{finally: stmt.FinallyBody, loops: len(fc.loops)})
+	c.beginScope()
+	c.statements(stmt.Body)
+	c.endScope()
+	fc.handlers = fc.handlers[:len(fc.handlers)-1]
+	c.emitOp(OP_END_TRY)
+	if hasFinally {
+		c.finallyBlock(stmt.FinallyBody)
+	}
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(handlerJump)
+
+	if hasCatch {
+		c.beginScope()
+		c.addLocal(stmt.CatchName.Lexeme)
+		// errors raised by the catch clause still run the finally clause
+		var finallyJump int
+		if hasFinally {
+			finallyJump = c.emitJump(OP_TRY, boolByte(false))
+			fc.handlers = append(fc.handlers, handler{finally: stmt.FinallyBody, loops: len(fc.loops)})
+		}
+		c.statements(stmt.CatchBody)
+		if hasFinally {
+			fc.handlers = fc.handlers[:len(fc.handlers)-1]
+			c.emitOp(OP_END_TRY)
+		}
+		c.endScope()
+		if hasFinally {
+			c.finallyBlock(stmt.FinallyBody)
+			catchEndJump := c.emitJump(OP_JUMP)
+			// the handler of the catch clause keeps the catch variable on the stack
+			c.patchJump(finallyJump)
+			c.beginScope()
+			c.addLocal("")
+			c.rethrowAfter(stmt.FinallyBody)
+			c.endScope()
+			c.patchJump(catchEndJump)
+		}
+	} else {
+		c.rethrowAfter(stmt.FinallyBody)
+	}
+	c.patchJump(endJump)
+	return nil
+}
+
+// runs the finally clause with the pending error as a hidden local then raises it again
+func (c *Compiler) rethrowAfter(finally []statements.Statement) {
+	c.beginScope()
+	c.addLocal("")
+	c.finallyBlock(finally)
+	c.emitOp(OP_GET_LOCAL, byte(len(c.current.locals)-1))
+	c.emitOp(OP_RETHROW)
+	c.endScope()
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (c *Compiler) VisitFunctionStmt(stmt statements.FunctionStatement) error {
+	c.token = stmt.Name
+	// a local function is bound before its body is compiled so it can call itself
+	if c.current.depth > 0 {
+		c.addLocal(stmt.Name.Lexeme)
+		c.function(stmt, FUNCTION)
+		return nil
+	}
+	c.function(stmt, FUNCTION)
+	c.defineVariable(stmt.Name.Lexeme, stmt.Constant)
+	return nil
+}
+
+func (c *Compiler) VisitReturnStmt(stmt statements.ReturnStatement) error {
+	c.token = stmt.Keyword
+	if stmt.Value != nil {
+		c.expression(stmt.Value)
+	} else if c.current.kind == INITIALIZER {
+		c.emitOp(OP_GET_LOCAL, 0)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	// the value is kept in a hidden local while the enclosing finally clauses run
+	if len(c.current.handlers) > 0 {
+		c.beginScope()
+		c.addLocal("")
+		c.exitHandlers(0)
+		c.emitOp(OP_GET_LOCAL, byte(len(c.current.locals)-1))
+		c.emitOp(OP_RETURN)
+		c.endScope()
+		return nil
+	}
+	c.emitOp(OP_RETURN)
+	return nil
+}
+
+// the class is bound before its methods are compiled so they can refer to it.
+// when there's a superclass, a scope holding it as the 'super' local encloses the methods.
+func (c *Compiler) VisitClassStmt(stmt statements.ClassStatement) error {
+	c.token = stmt.Name
+	name := stmt.Name.Lexeme
+	if c.current.depth > 0 {
+		c.addLocal(name)
+	}
+	c.emitShort(OP_CLASS, c.name(name))
+	if c.current.depth == 0 {
+		c.defineVariable(name, stmt.Constant)
+	}
+
+	hasSuperclass := stmt.Superclass.Token.Lexeme != ""
+	if hasSuperclass {
+		c.token = stmt.Superclass.Token
+		c.getVariable(stmt.Superclass.Token.Lexeme)
+		c.beginScope()
+		c.addLocal("super")
+		c.getVariable(name)
+		c.emitOp(OP_INHERIT)
+	}
+
+	enclosingClass := c.class
+	c.class = name
+	c.getVariable(name)
+	for _, method := range stmt.Methods {
+		c.token = method.Name
+		kind := METHOD
+		if method.Name.Lexeme == "init" {
+			kind = INITIALIZER
+		}
+		c.function(method, kind)
+		c.emitShort(OP_METHOD, c.name(method.Name.Lexeme))
+	}
+	for _, getter := range stmt.Getters {
+		c.token = getter.Name
+		c.function(getter, METHOD)
+		c.emitShort(OP_GETTER, c.name(getter.Name.Lexeme))
+	}
+	for _, method := range stmt.StaticMethods {
+		c.token = method.Name
+		c.function(method, STATIC)
+		c.emitShort(OP_STATIC_METHOD, c.name(method.Name.Lexeme))
+	}
+	c.emitOp(OP_POP)
+	c.class = enclosingClass
+
+	if hasSuperclass {
+		c.endScope()
+	}
+
+	// static fields are initialized in order once the class is bound
+	for _, field := range stmt.StaticFields {
+		c.getVariable(name)
+		if field.Initializer != nil {
+			c.expression(field.Initializer)
+		} else {
+			c.emitOp(OP_NIL)
+		}
+		c.token = field.Token
+		c.emitShort(OP_SET_PROPERTY, c.name(field.Token.Lexeme))
+		c.emitOp(OP_POP)
+	}
+	return nil
+}
+
+// the module is bound to a variable, a selective import binds each name to the member instead.
+// the module is imported again for every name, only the first import runs it
+func (c *Compiler) VisitImportStmt(stmt statements.ImportStatement) error {
+	c.token = stmt.Keyword
+	path := c.makeConstant(stmt.Path.Literal.Value.(string))
+	if len(stmt.Names) == 0 {
+		c.emitShort(OP_IMPORT, path)
+		c.defineVariable(interpreter.ImportName(stmt), false)
+		return nil
+	}
+	for _, name := range stmt.Names {
+		c.emitShort(OP_IMPORT, path)
+		c.token = name
+		c.emitShort(OP_GET_PROPERTY, c.name(name.Lexeme))
+		c.defineVariable(name.Lexeme, false)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/interpreter/environment"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+type stepMode int
+
+const (
+	// run until a breakpoint
+	CONTINUE stepMode = iota
+	// pause at the next statement
+	STEP_IN
+	// pause at the next statement of the same function (or of a caller)
+	STEP_OVER
+	// pause at the next statement of a caller
+	STEP_OUT
+)
+
+// lines of the source shown around the current line by 'list'
+const listContext = 2
+
+const help = `commands:
+  c, continue      run until the next breakpoint
+  s, step          step into the next statement
+  n, next          step over function calls
+  o, out           run until the current function returns
+  b, break LINE    set a breakpoint
+  d, delete LINE   remove a breakpoint
+  breakpoints      list the breakpoints
+  p, print EXPR    evaluate an expression in the current scope
+  e, env           print the scope chain
+  bt, stack        print the call stack
+  l, list          show the source around the current line
+  q, quit          stop the program
+  h, help          show this help`
+
+type frame struct {
+	name string
+	// line of the statement the frame is executing
+	line int
+}
+
+// interactive debugger for the tree-walking interpreter.
+// It traces the interpreter pausing before statements when stepping or when a breakpoint is hit,
+// then reads commands until the execution is resumed.
+type Debugger struct {
+	inter       *interpreter.Interpreter
+	source      []string
+	in          *bufio.Scanner
+	out         io.Writer
+	breakpoints map[int]bool
+	// the top level script is the first frame
+	frames []frame
+	mode   stepMode
+	// number of frames when the last step command was given
+	depth int
+	// set while evaluating an expression so the statements it runs don't pause
+	evaluating bool
+}
+
+func New(inter *interpreter.Interpreter, source string, in io.Reader, out io.Writer) *Debugger {
+	d := &Debugger{
+		inter:       inter,
+		source:      strings.Split(source, "\n"),
+		in:          bufio.NewScanner(in),
+		out:         out,
+		breakpoints: map[int]bool{},
+		frames:      []frame{{name: "<script>"}},
+		// pause before the first statement so breakpoints can be set
+		mode: STEP_IN,
+	}
+	inter.SetTracer(d)
+	return d
+}
+
+// runs the program under the debugger, runtime errors are reported by the interpreter.
+// quitting isn't an error
+func (d *Debugger) Run(stmts []statements.Statement) error {
+	err := d.inter.Interpret(stmts)
+	if _, quit := err.(interpreter.ErrorAbort); quit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(d.out, "Program finished.")
+	return nil
+}
+
+// blocks don't pause by themselves, their statements do
+func (d *Debugger) BeforeStatement(inter *interpreter.Interpreter, stmt statements.Statement) error {
+	if d.evaluating {
+		return nil
+	}
+	if _, ok := stmt.(statements.BlockStatement); ok {
+		return nil
+	}
+	line := stmt.Line()
+	d.frames[len(d.frames)-1].line = line
+	if !d.shouldPause(line) {
+		return nil
+	}
+	return d.pause(line)
+}
+
+func (d *Debugger) EnterFunction(function *interpreter.FunctionCallable) {
+	d.frames = append(d.frames, frame{name: function.String()})
+}
+
+func (d *Debugger) ExitFunction(function *interpreter.FunctionCallable) {
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+func (d *Debugger) shouldPause(line int) bool {
+	switch d.mode {
+	case STEP_IN:
+		return true
+	case STEP_OVER:
+		if len(d.frames) <= d.depth {
+			return true
+		}
+	case STEP_OUT:
+		if len(d.frames) < d.depth {
+			return true
+		}
+	}
+	return d.breakpoints[line]
+}
+
+// reads commands until one resumes the execution, the end of the input quits
+func (d *Debugger) pause(line int) error {
+	fmt.Fprintf(d.out, "Paused at line %d: %s\n", line, strings.TrimSpace(d.sourceLine(line)))
+	for {
+		fmt.Fprint(d.out, "(debug) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			return interpreter.ErrorAbort{}
+		}
+		input := strings.TrimSpace(d.in.Text())
+		command, arg := input, ""
+		if i := strings.IndexAny(input, " \t"); i != -1 {
+			command, arg = input[:i], strings.TrimSpace(input[i+1:])
+		}
+
+		switch command {
+		case "":
+		case "c", "continue":
+			d.mode = CONTINUE
+			return nil
+		case "s", "step":
+			d.mode = STEP_IN
+			return nil
+		case "n", "next":
+			d.mode, d.depth = STEP_OVER, len(d.frames)
+			return nil
+		case "o", "out":
+			d.mode, d.depth = STEP_OUT, len(d.frames)
+			return nil
+		case "b", "break":
+			if n, ok := d.lineArg(arg); ok {
+				d.breakpoints[n] = true
+				fmt.Fprintf(d.out, "Breakpoint set at line %d\n", n)
+			}
+		case "d", "delete":
+			if n, ok := d.lineArg(arg); ok {
+				delete(d.breakpoints, n)
+				fmt.Fprintf(d.out, "Breakpoint removed from line %d\n", n)
+			}
+		case "breakpoints":
+			d.printBreakpoints()
+		case "p", "print":
+			d.evaluate(arg)
+		case "e", "env":
+			d.printEnvironment()
+		case "bt", "stack":
+			d.printStack()
+		case "l", "list":
+			d.list(line)
+		case "q", "quit":
+			return interpreter.ErrorAbort{}
+		case "h", "help":
+			fmt.Fprintln(d.out, help)
+		default:
+			fmt.Fprintf(d.out, "Unknown command '%s', type 'help' for the list of commands\n", command)
+		}
+	}
+}
+
+func (d *Debugger) sourceLine(line int) string {
+	if line < 1 || line > len(d.source) {
+		return ""
+	}
+	return d.source[line-1]
+}
+
+func (d *Debugger) lineArg(arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(d.source) {
+		fmt.Fprintf(d.out, "Invalid line '%s'\n", arg)
+		return 0, false
+	}
+	return n, true
+}
+
+func (d *Debugger) printBreakpoints() {
+	if len(d.breakpoints) == 0 {
+		fmt.Fprintln(d.out, "No breakpoints")
+		return
+	}
+	lines := []int{}
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		fmt.Fprintf(d.out, "line %d: %s\n", line, strings.TrimSpace(d.sourceLine(line)))
+	}
+}
+
+// evaluates the expression in the scope of the paused statement.
+// statements run by the expression (e.g. calling a function) don't pause
+func (d *Debugger) evaluate(source string) {
+	expr, err := parser.New(scanner.New(source).ScanTokens()).ParseExpression()
+	if err != nil || reporting.HadError() {
+		// a mistake in the expression shouldn't end the session
+		reporting.UnsetError()
+		return
+	}
+	d.evaluating = true
+	value, err := d.inter.EvaluateDynamic(expr)
+	d.evaluating = false
+	if err != nil {
+		fmt.Fprintf(d.out, "Error: %s\n", err.Error())
+		return
+	}
+	fmt.Fprintln(d.out, repr(value))
+}
+
+// prints each scope from the innermost one to the globals, built-ins are left out
+func (d *Debugger) printEnvironment() {
+	level := 0
+	for env := d.inter.Environment(); env.GetEnclosing() != nil; env = env.GetEnclosing() {
+		name := fmt.Sprintf("scope %d", level)
+		if env.GetEnclosing().GetEnclosing() == nil {
+			name = "globals"
+		}
+		fmt.Fprintf(d.out, "%s: %s\n", name, formatScope(env))
+		level++
+	}
+}
+
+func formatScope(env *environment.Environment) string {
+	names := env.Names()
+	if len(names) == 0 {
+		return "(empty)"
+	}
+	sort.Strings(names)
+	bindings := make([]string, 0, len(names))
+	for _, name := range names {
+		value, _ := env.Lookup(name)
+		bindings = append(bindings, name+" = "+repr(value))
+	}
+	return strings.Join(bindings, ", ")
+}
+
+// innermost frame first
+func (d *Debugger) printStack() {
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		fmt.Fprintf(d.out, "#%d %s at line %d\n", len(d.frames)-1-i, d.frames[i].name, d.frames[i].line)
+	}
+}
+
+// shows the source around the line, the current line is marked with '>' and breakpoints with '*'
+func (d *Debugger) list(line int) {
+	for n := line - listContext; n <= line+listContext; n++ {
+		if n < 1 || n > len(d.source) {
+			continue
+		}
+		marker := " "
+		if d.breakpoints[n] {
+			marker = "*"
+		}
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, n, d.source[n-1])
+	}
+}
+
+// strings are quoted so they can be told apart from other values
+func repr(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return interpreter.Stringify(value)
+}
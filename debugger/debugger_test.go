@@ -0,0 +1,122 @@
+package debugger_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/debugger"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+const program = `func add(a, b) {
+  let sum = a + b;
+  return sum;
+}
+let x = add(1, 2);
+print x;
+print add(x, 4);`
+
+// runs the program under the debugger reading the commands, the output of the program
+// is interleaved with the one of the debugger. The syntax errors of printed expressions
+// are collected so they don't end up in the test output
+func debug(t *testing.T, commands string) string {
+	t.Helper()
+	inter := interpreter.New()
+	var stmts []statements.Statement
+	diagnostics := reporting.Collect(func() {
+		stmts = parser.New(scanner.New(program).ScanTokens()).Parse()
+		resolver.New(inter).Resolve(stmts)
+	})
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected compile error: %s", diagnostics[0].Msg)
+	}
+	var out bytes.Buffer
+	inter.SetOutput(&out)
+	var err error
+	reporting.Collect(func() {
+		err = debugger.New(inter, program, strings.NewReader(commands), &out).Run(stmts)
+	})
+	if err != nil {
+		t.Fatalf("unexpected runtime error: %s", err)
+	}
+	return out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		want     string
+	}{
+		{"breakpoints", "b 3\nbreakpoints\nc\np sum\nc\np sum\nd 3\nc\n", `Paused at line 1: func add(a, b) {
+(debug) Breakpoint set at line 3
+(debug) line 3: return sum;
+(debug) Paused at line 3: return sum;
+(debug) 3
+(debug) 3
+Paused at line 3: return sum;
+(debug) 7
+(debug) Breakpoint removed from line 3
+(debug) 7
+Program finished.
+`},
+		{"step in", "s\ns\ns\ns\nbt\nc\n", `Paused at line 1: func add(a, b) {
+(debug) Paused at line 5: let x = add(1, 2);
+(debug) Paused at line 2: let sum = a + b;
+(debug) Paused at line 3: return sum;
+(debug) Paused at line 6: print x;
+(debug) #0 <script> at line 6
+(debug) 3
+7
+Program finished.
+`},
+		{"step over", "n\nn\nn\nn\n", `Paused at line 1: func add(a, b) {
+(debug) Paused at line 5: let x = add(1, 2);
+(debug) Paused at line 6: print x;
+(debug) 3
+Paused at line 7: print add(x, 4);
+(debug) 7
+Program finished.
+`},
+		{"step out", "b 2\nc\nbt\nd 2\no\nc\n", `Paused at line 1: func add(a, b) {
+(debug) Breakpoint set at line 2
+(debug) Paused at line 2: let sum = a + b;
+(debug) #0 <func add> at line 2
+#1 <script> at line 5
+(debug) Breakpoint removed from line 2
+(debug) Paused at line 6: print x;
+(debug) 3
+7
+Program finished.
+`},
+		{"print", "b 3\nc\np a * 10\np add(a, b)\np \"s\" + str(a)\np nope\np a +\ne\nq\n", `Paused at line 1: func add(a, b) {
+(debug) Breakpoint set at line 3
+(debug) Paused at line 3: return sum;
+(debug) 10
+(debug) 3
+(debug) "s1"
+(debug) Error: Undefined Variable 'nope'.[line 1]
+(debug) (debug) scope 0: a = 1, b = 2, sum = 3
+globals: add = <func add>
+(debug) `},
+		{"invalid commands", "b 99\nb x\njump\n", "Paused at line 1: func add(a, b) {\n" +
+			"(debug) Invalid line '99'\n" +
+			"(debug) Invalid line 'x'\n" +
+			"(debug) Unknown command 'jump', type 'help' for the list of commands\n" +
+			// the end of the input quits
+			"(debug) \n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := debug(t, test.commands); got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package formatter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// the tree keeps the groupings of the source so expressions are printed without adding parentheses
+
+func (p *printer) expression(expr expressions.Experssion) {
+	expr.Accept(p)
+}
+
+func (p *printer) VisitBinary(expr expressions.Binary) (interface{}, error) {
+	p.expression(expr.Left)
+	p.write(" " + expr.Operator.Lexeme + " ")
+	p.expression(expr.Right)
+	return nil, nil
+}
+
+func (p *printer) VisitLogical(expr expressions.Logical) (interface{}, error) {
+	p.expression(expr.Left)
+	p.write(" " + expr.Operator.Lexeme + " ")
+	p.expression(expr.Right)
+	return nil, nil
+}
+
+func (p *printer) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	p.expression(expr.Condition)
+	p.write(" ? ")
+	p.expression(expr.Then)
+	p.write(" : ")
+	p.expression(expr.Else)
+	return nil, nil
+}
+
+func (p *printer) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	p.expression(expr.Left)
+	p.write(" ?? ")
+	p.expression(expr.Right)
+	return nil, nil
+}
+
+func (p *printer) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	p.expression(expr.Expr)
+	return nil, nil
+}
+
+func (p *printer) VisitGrouping(expr expressions.Grouping) (interface{}, error) {
+	p.write("(")
+	p.expression(expr.Expr)
+	p.write(")")
+	return nil, nil
+}
+
+func (p *printer) VisitLiteral(expr expressions.Literal) (interface{}, error) {
+	p.write(literal(expr.Value))
+	return nil, nil
+}
+
+func literal(value interface{}) string {
+	switch value := value.(type) {
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		s := strconv.FormatFloat(value, 'f', -1, 64)
+		// a float without a fractional part would be read back as an integer
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case string:
+		return `"` + value + `"`
+	}
+	return "nil"
+}
+
+func (p *printer) VisitUnary(expr expressions.Unary) (interface{}, error) {
+	p.write(expr.Operator.Lexeme)
+	// keeps '- -x' and '- --x' from being printed as '--x' and '---x'
+	if expr.Operator.Lexeme == "-" && negative(expr.Right) {
+		p.write(" ")
+	}
+	p.expression(expr.Right)
+	return nil, nil
+}
+
+// reports whether the expression is printed starting with a minus
+func negative(expr expressions.Experssion) bool {
+	switch expr := expr.(type) {
+	case expressions.Unary:
+		return expr.Operator.Lexeme == "-"
+	case expressions.Assgin:
+		return expr.Operator.Lexeme == "--" && !expr.Postfix
+	case expressions.PropertyAssignment:
+		return expr.Operator.Lexeme == "--" && !expr.Postfix
+	}
+	return false
+}
+
+func (p *printer) VisitVairable(expr expressions.Variable) (interface{}, error) {
+	p.write(expr.Token.Lexeme)
+	return nil, nil
+}
+
+func (p *printer) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
+	p.assignment(expr.Operator, expr.Postfix, func() { p.write(expr.Token.Lexeme) }, expr.Value)
+	return nil, nil
+}
+
+// prints the target followed by the operator and the value, increments and decrements
+// are printed without the value they add
+func (p *printer) assignment(operator expressions.Token, postfix bool, target func(), value expressions.Experssion) {
+	switch operator.Lexeme {
+	case "":
+		target()
+		p.write(" = ")
+		p.expression(value)
+	case "++", "--":
+		if !postfix {
+			p.write(operator.Lexeme)
+		}
+		target()
+		if postfix {
+			p.write(operator.Lexeme)
+		}
+	default:
+		target()
+		p.write(" " + operator.Lexeme + " ")
+		p.expression(value)
+	}
+}
+
+func (p *printer) VisitCall(expr expressions.Call) (interface{}, error) {
+	p.expression(expr.Callee)
+	p.write("(")
+	for i, arg := range expr.Args {
+		if i != 0 {
+			p.write(", ")
+		}
+		p.expression(arg)
+	}
+	p.write(")")
+	return nil, nil
+}
+
+func (p *printer) VisitPropertyAccess(expr expressions.PropertyAccess) (interface{}, error) {
+	p.expression(expr.Obj)
+	if expr.Optional {
+		p.write("?")
+	}
+	p.write("." + expr.Name.Lexeme)
+	return nil, nil
+}
+
+func (p *printer) VisitPropertyAssignment(expr expressions.PropertyAssignment) (interface{}, error) {
+	p.assignment(expr.Operator, expr.Postfix, func() {
+		p.expression(expr.Obj)
+		p.write("." + expr.Name.Lexeme)
+	}, expr.Value)
+	return nil, nil
+}
+
+func (p *printer) VisitThis(expr expressions.This) (interface{}, error) {
+	p.write("this")
+	return nil, nil
+}
+
+func (p *printer) VisitSuper(expr expressions.Super) (interface{}, error) {
+	p.write("super." + expr.Method.Lexeme)
+	return nil, nil
+}
+
+func (p *printer) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	p.elements(expr.Bracket, "[", "]", len(expr.Elements), func(i int) {
+		p.expression(expr.Elements[i])
+	})
+	return nil, nil
+}
+
+func (p *printer) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	p.elements(expr.Brace, "{", "}", len(expr.Keys), func(i int) {
+		p.expression(expr.Keys[i])
+		p.write(": ")
+		p.expression(expr.Values[i])
+	})
+	return nil, nil
+}
+
+// prints the elements of a list or the entries of a map.
+// literals written with the first element on a new line are printed with an element per line
+func (p *printer) elements(bracket expressions.Token, opening string, closing string, count int, print func(int)) {
+	open, ok := p.positions[tokenPos(bracket)]
+	if !ok || count == 0 || p.tokens[open+1].Line == bracket.Line {
+		p.write(opening)
+		for i := 0; i < count; i++ {
+			if i != 0 {
+				p.write(", ")
+			}
+			print(i)
+		}
+		p.write(closing)
+		return
+	}
+	p.enclosed(open, opening, closing, ",", p.elementLines(open), print)
+}
+
+// the line each element between the brackets starts at, elements are separated by the commas outside of nested brackets
+func (p *printer) elementLines(open int) []int {
+	close := p.closing[open]
+	lines := []int{p.tokens[open+1].Line}
+	depth := 0
+	for i := open + 1; i < close; i++ {
+		switch p.tokens[i].Kind {
+		case scanner.LEFT_PAREN, scanner.LEFT_BRACKET, scanner.LEFT_BRACE:
+			depth++
+		case scanner.RIGHT_PAREN, scanner.RIGHT_BRACKET, scanner.RIGHT_BRACE:
+			depth--
+		case scanner.COMMA:
+			// a trailing comma doesn't start an element
+			if depth == 0 && i+1 < close {
+				lines = append(lines, p.tokens[i+1].Line)
+			}
+		}
+	}
+	return lines
+}
+
+func (p *printer) VisitIndex(expr expressions.Index) (interface{}, error) {
+	p.expression(expr.Obj)
+	p.write("[")
+	p.expression(expr.Index)
+	p.write("]")
+	return nil, nil
+}
+
+func (p *printer) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	p.expression(expr.Obj)
+	p.write("[")
+	p.expression(expr.Index)
+	p.write("] = ")
+	p.expression(expr.Value)
+	return nil, nil
+}
+
+func (p *printer) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	p.expression(expr.Obj)
+	p.write("[")
+	if expr.Start != nil {
+		p.expression(expr.Start)
+	}
+	p.write(":")
+	if expr.End != nil {
+		p.expression(expr.End)
+	}
+	p.write("]")
+	return nil, nil
+}
+
+func (p *printer) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	function := expr.Function.(statements.FunctionStatement)
+	if expr.Keyword.Kind != scanner.ARROW {
+		p.write("func ")
+		p.parameters(function)
+		p.write(" ")
+		p.block(function.Body, p.bodyBrace(expr.Keyword))
+		return nil, nil
+	}
+	p.parameters(function)
+	p.write(" => ")
+	// an expression body is parsed into a return statement holding the arrow token
+	if len(function.Body) == 1 {
+		if ret, ok := function.Body[0].(statements.ReturnStatement); ok && ret.Keyword.Kind == scanner.ARROW {
+			p.expression(ret.Value)
+			return nil, nil
+		}
+	}
+	p.block(function.Body, p.braceAfter(expr.Keyword))
+	return nil, nil
+}
@@ -0,0 +1,287 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+const indentation = "    "
+
+// returned when the source can't be formatted because it doesn't parse
+type SyntaxError struct {
+	Diagnostics []reporting.Diagnostic
+}
+
+func (e *SyntaxError) Error() string {
+	msgs := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		msgs = append(msgs, d.String())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// reprints the source in the canonical style.
+// the syntax tree doesn't keep the layout so statements and expressions are printed from it,
+// the source tokens are only used to place the comments and to keep blank lines between statements
+// and multi-line list and map literals.
+// the syntax errors are located in the file, an empty name for a source that doesn't come from a file
+func Format(source string, file string) (string, error) {
+	var tokens, comments []expressions.Token
+	var stmts []statements.Statement
+	diagnostics := reporting.Collect(func() {
+		s := scanner.New(source)
+		s.SetFile(file)
+		s.KeepComments()
+		tokens = s.ScanTokens()
+		comments = s.Comments()
+		stmts = parser.New(tokens).Parse()
+	})
+	if len(diagnostics) != 0 {
+		return "", &SyntaxError{Diagnostics: diagnostics}
+	}
+
+	p := newPrinter(source, tokens, comments)
+	p.program(stmts)
+	return p.out.String(), nil
+}
+
+type pos struct {
+	line   int
+	column int
+}
+
+func tokenPos(tok expressions.Token) pos {
+	return pos{line: tok.Line, column: tok.Column}
+}
+
+type printer struct {
+	out    strings.Builder
+	lines  []string
+	tokens []expressions.Token
+	// index of each token in tokens by its position
+	positions map[pos]int
+	// index of the closing bracket, parenthesis or brace matching each opening one
+	closing  map[int]int
+	comments []expressions.Token
+	// the next comment to print
+	next  int
+	depth int
+	// set until the first item of the current block is printed, blank lines are only kept between items
+	first bool
+}
+
+func newPrinter(source string, tokens []expressions.Token, comments []expressions.Token) *printer {
+	p := &printer{
+		lines:     strings.Split(source, "\n"),
+		tokens:    tokens,
+		positions: map[pos]int{},
+		closing:   map[int]int{},
+		comments:  comments,
+	}
+	opened := []int{}
+	for i, tok := range tokens {
+		p.positions[tokenPos(tok)] = i
+		switch tok.Kind {
+		case scanner.LEFT_PAREN, scanner.LEFT_BRACKET, scanner.LEFT_BRACE:
+			opened = append(opened, i)
+		case scanner.RIGHT_PAREN, scanner.RIGHT_BRACKET, scanner.RIGHT_BRACE:
+			if len(opened) != 0 {
+				p.closing[opened[len(opened)-1]] = i
+				opened = opened[:len(opened)-1]
+			}
+		}
+	}
+	return p
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) indent() {
+	p.write(strings.Repeat(indentation, p.depth))
+}
+
+// index of the first token of the kind after the given token
+func (p *printer) after(tok expressions.Token, kind expressions.TokenType) int {
+	i, ok := p.positions[tokenPos(tok)]
+	if !ok {
+		return -1
+	}
+	for j := i + 1; j < len(p.tokens); j++ {
+		if p.tokens[j].Kind == kind {
+			return j
+		}
+	}
+	return -1
+}
+
+// index of the first opening brace after the given token, it opens the block of a declaration or a clause
+func (p *printer) braceAfter(tok expressions.Token) int {
+	return p.after(tok, scanner.LEFT_BRACE)
+}
+
+// index of the opening brace of the body of the function named by the token (or of the 'func' keyword),
+// it's looked up after the parameters as their default values may hold braces too
+func (p *printer) bodyBrace(tok expressions.Token) int {
+	if close, ok := p.closing[p.after(tok, scanner.LEFT_PAREN)]; ok {
+		return p.braceAfter(p.tokens[close])
+	}
+	return p.braceAfter(tok)
+}
+
+// the line of the token closing the one at the index
+func (p *printer) closingLine(open int) int {
+	if close, ok := p.closing[open]; ok {
+		return p.tokens[close].Line
+	}
+	return p.tokens[len(p.tokens)-1].Line
+}
+
+// comments on their own line are printed on their own line, the others follow code
+func (p *printer) ownLine(comment expressions.Token) bool {
+	if comment.Line > len(p.lines) {
+		return true
+	}
+	return strings.TrimSpace(p.lines[comment.Line-1][:comment.Column-1]) == ""
+}
+
+func (p *printer) blankBefore(line int) bool {
+	return line >= 2 && line-1 <= len(p.lines) && strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// starts a line for an item of a block starting at the source line.
+// a blank line is kept if the source has one before the item
+func (p *printer) item(line int) {
+	if !p.first && p.blankBefore(line) {
+		p.write("\n")
+	}
+	p.first = false
+	p.indent()
+}
+
+// prints the comments before the line each on its own line
+func (p *printer) leading(line int) {
+	for p.next < len(p.comments) && p.comments[p.next].Line < line {
+		comment := p.comments[p.next]
+		p.item(comment.Line)
+		p.write(strings.TrimRight(comment.Lexeme, " \t\r"))
+		p.write("\n")
+		p.next++
+	}
+}
+
+// prints the comments that follow code before the line at the end of the current line
+func (p *printer) trailing(limit int) {
+	inline := true
+	for p.next < len(p.comments) && p.comments[p.next].Line < limit && !p.ownLine(p.comments[p.next]) {
+		comment := strings.TrimRight(p.comments[p.next].Lexeme, " \t\r")
+		if inline {
+			p.write(" " + comment)
+			inline = false
+		} else {
+			p.write("\n")
+			p.indent()
+			p.write(comment)
+		}
+		p.next++
+	}
+}
+
+func (p *printer) program(stmts []statements.Statement) {
+	p.first = true
+	for i, stmt := range stmts {
+		line := p.startLine(stmt)
+		limit := len(p.lines) + 1
+		if i+1 < len(stmts) {
+			limit = p.startLine(stmts[i+1])
+		}
+		p.leading(line)
+		p.item(line)
+		p.statement(stmt)
+		p.trailing(limit)
+		p.write("\n")
+	}
+	p.leading(len(p.lines) + 1)
+}
+
+// prints the statements of a block between braces, open is the index of the opening brace
+func (p *printer) block(stmts []statements.Statement, open int) {
+	lines := make([]int, 0, len(stmts))
+	for _, stmt := range stmts {
+		lines = append(lines, p.startLine(stmt))
+	}
+	p.braced(open, lines, func(i int) {
+		p.statement(stmts[i])
+	})
+}
+
+// prints items between braces each on its own lines with the comments around them.
+// lines holds the source line each item starts at and print prints the item at the index
+func (p *printer) braced(open int, lines []int, print func(int)) {
+	p.enclosed(open, "{", "}", "", lines, print)
+}
+
+// prints items between the opening and closing text each on its own lines followed by the separator
+func (p *printer) enclosed(open int, opening string, closing string, separator string, lines []int, print func(int)) {
+	closeLine := p.closingLine(open)
+	if len(lines) == 0 && (p.next >= len(p.comments) || p.comments[p.next].Line >= closeLine) {
+		p.write(opening + closing)
+		return
+	}
+	p.write(opening)
+	first := closeLine
+	if len(lines) != 0 {
+		first = lines[0]
+	}
+	p.trailing(first)
+	p.write("\n")
+
+	enclosing := p.first
+	p.first = true
+	p.depth++
+	for i, line := range lines {
+		limit := closeLine
+		if i+1 < len(lines) {
+			limit = lines[i+1]
+		}
+		p.leading(line)
+		p.item(line)
+		print(i)
+		p.write(separator)
+		p.trailing(limit)
+		p.write("\n")
+	}
+	p.leading(closeLine)
+	p.depth--
+	p.first = enclosing
+
+	p.indent()
+	p.write(closing)
+}
+
+// the source line a statement starts at, the line of its first token
+func (p *printer) startLine(stmt statements.Statement) int {
+	switch stmt := stmt.(type) {
+	case statements.WhileStatement:
+		if stmt.Label.Lexeme != "" {
+			return stmt.Label.Line
+		}
+	case statements.BlockStatement:
+		// for loops with an initializer are blocks holding the initializer and the loop
+		if stmt.Brace.Kind == scanner.FOR && len(stmt.Statements) == 2 {
+			return p.startLine(stmt.Statements[1])
+		}
+	case statements.VarDecStatement:
+		// the 'let' before the name
+		if i, ok := p.positions[tokenPos(stmt.Token)]; ok && i > 0 {
+			return p.tokens[i-1].Line
+		}
+	}
+	return stmt.Line()
+}
@@ -0,0 +1,103 @@
+package formatter_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/formatter"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/modules"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// formatting moves statements to other lines
+var errorLine = regexp.MustCompile(`\s*\[line \d+\]$`)
+
+// the behavior of a script: its output, the messages of its compile errors and its runtime error
+type behavior struct {
+	output   string
+	errors   []string
+	runError string
+}
+
+// runs the source on the tree-walking interpreter, modules are imported relative to the path
+func run(source string, path string) behavior {
+	var b behavior
+	inter := interpreter.New()
+	inter.SetPath(path)
+	loader := modules.New()
+	loader.SetMain(path)
+	inter.SetImporter(loader)
+	diagnostics := reporting.Collect(func() {
+		s := scanner.New(source)
+		s.SetFile(path)
+		stmts := parser.New(s.ScanTokens()).Parse()
+		if reporting.HadError() {
+			return
+		}
+		resolver.New(inter).Resolve(stmts)
+		if reporting.HadError() {
+			return
+		}
+		var out bytes.Buffer
+		inter.SetOutput(&out)
+		if err := inter.Execute(stmts); err != nil {
+			b.runError = errorLine.ReplaceAllString(err.Error(), "")
+		}
+		b.output = out.String()
+	})
+	for _, d := range diagnostics {
+		b.errors = append(b.errors, d.Msg)
+	}
+	return b
+}
+
+// formats every script of the golden suite, formatting is idempotent and doesn't change what the script does
+func TestGoldenSuite(t *testing.T) {
+	paths := []string{}
+	err := filepath.Walk("../test", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == ".pl" {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden tests found")
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			source, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			once, err := formatter.Format(string(source), path)
+			if _, ok := err.(*formatter.SyntaxError); ok {
+				t.Skip("the script tests syntax errors")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			twice, err := formatter.Format(once, path)
+			if err != nil {
+				t.Fatalf("the formatted script doesn't parse: %s", err)
+			}
+			if twice != once {
+				t.Errorf("formatting isn't idempotent, formatted once:\n%s\nformatted twice:\n%s", once, twice)
+			}
+			if got, want := run(once, path), run(string(source), path); !reflect.DeepEqual(got, want) {
+				t.Errorf("the formatted script behaves differently, got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package formatter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// statements are printed from the current position without the line break after them,
+// the block printing them adds it after the comments following the statement
+
+func (p *printer) statement(stmt statements.Statement) {
+	stmt.Accept(p)
+}
+
+func (p *printer) VisitPrintStmt(stmt statements.PrintStatement) error {
+	p.write("print ")
+	p.expression(stmt.Expr)
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitExprStmt(stmt statements.ExperssionStatement) error {
+	p.expression(stmt.Expr)
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitVarDecStmt(stmt statements.VarDecStatement) error {
+	keyword := "let "
+	if stmt.Constant {
+		keyword = "const "
+	}
+	p.write(keyword + stmt.Token.Lexeme + annotation(stmt.Type))
+	if stmt.Initializer != nil {
+		p.write(" = ")
+		p.expression(stmt.Initializer)
+	}
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitBlockStmt(stmt statements.BlockStatement) error {
+	// for loops with an initializer are desugared into a block holding the initializer and the loop
+	if stmt.Brace.Kind == scanner.FOR && len(stmt.Statements) == 2 {
+		p.forLoop(stmt.Statements[0], stmt.Statements[1].(statements.WhileStatement))
+		return nil
+	}
+	p.block(stmt.Statements, p.positions[tokenPos(stmt.Brace)])
+	return nil
+}
+
+func (p *printer) VisitIfStmt(stmt statements.IfStatement) error {
+	p.write("if (")
+	p.expression(stmt.Condition)
+	p.write(") ")
+	p.statement(stmt.ThenBranch)
+	if stmt.ElseBranch == nil {
+		return nil
+	}
+	if _, ok := stmt.ThenBranch.(statements.BlockStatement); ok && !isForLoop(stmt.ThenBranch) {
+		p.write(" else ")
+	} else {
+		p.write("\n")
+		p.indent()
+		p.write("else ")
+	}
+	p.statement(stmt.ElseBranch)
+	return nil
+}
+
+func (p *printer) VisitWhileStmt(stmt statements.WhileStatement) error {
+	if stmt.Keyword.Kind == scanner.FOR {
+		p.forLoop(nil, stmt)
+		return nil
+	}
+	p.label(stmt.Label)
+	p.write("while (")
+	p.expression(stmt.Condition)
+	p.write(") ")
+	p.statement(stmt.Body)
+	return nil
+}
+
+// prints a for loop, the initializer is nil when it's omitted
+func (p *printer) forLoop(initializer statements.Statement, loop statements.WhileStatement) {
+	p.label(loop.Label)
+	p.write("for (")
+	if initializer != nil {
+		p.statement(initializer)
+	} else {
+		p.write(";")
+	}
+	p.write(" ")
+	p.expression(loop.Condition)
+	p.write(";")
+	if loop.Increment != nil {
+		p.write(" ")
+		p.expression(loop.Increment)
+	}
+	p.write(") ")
+	p.statement(loop.Body)
+}
+
+func isForLoop(stmt statements.Statement) bool {
+	block, ok := stmt.(statements.BlockStatement)
+	return ok && block.Brace.Kind == scanner.FOR
+}
+
+func (p *printer) label(label expressions.Token) {
+	if label.Lexeme != "" {
+		p.write(label.Lexeme + ": ")
+	}
+}
+
+func (p *printer) VisitFunctionStmt(stmt statements.FunctionStatement) error {
+	if stmt.Constant {
+		p.write("const ")
+	}
+	p.write("func ")
+	p.function(stmt)
+	return nil
+}
+
+// prints the name, the parameters and the body of a function or a method
+func (p *printer) function(stmt statements.FunctionStatement) {
+	p.write(stmt.Name.Lexeme)
+	p.parameters(stmt)
+	p.write(" ")
+	p.block(stmt.Body, p.bodyBrace(stmt.Name))
+}
+
+// prints the parameter list and the return type of the function
+func (p *printer) parameters(function statements.FunctionStatement) {
+	p.write("(")
+	for i, param := range function.Args {
+		if i > 0 {
+			p.write(", ")
+		}
+		if function.Variadic && i == len(function.Args)-1 {
+			p.write("...")
+		}
+		p.write(param.Lexeme)
+		if i < len(function.Types) {
+			p.write(annotation(function.Types[i]))
+		}
+		if def := function.Default(i); def != nil {
+			p.write(" = ")
+			p.expression(def)
+		}
+	}
+	p.write(")" + annotation(function.ReturnType))
+}
+
+// the type annotation of a declaration, empty when it isn't annotated
+func annotation(typ expressions.Token) string {
+	if typ.Lexeme == "" {
+		return ""
+	}
+	return ": " + typ.Lexeme
+}
+
+func (p *printer) VisitReturnStmt(stmt statements.ReturnStatement) error {
+	p.write("return")
+	if stmt.Value != nil {
+		p.write(" ")
+		p.expression(stmt.Value)
+	}
+	p.write(";")
+	return nil
+}
+
+// a member of a class body, the class keeps its members grouped by kind so they are sorted back into the source order
+type member struct {
+	name  expressions.Token
+	print func()
+}
+
+func (p *printer) VisitClassStmt(stmt statements.ClassStatement) error {
+	if stmt.Constant {
+		p.write("const ")
+	}
+	p.write("class " + stmt.Name.Lexeme)
+	if stmt.Superclass.Token.Lexeme != "" {
+		p.write(" extends " + stmt.Superclass.Token.Lexeme)
+	}
+	p.write(" ")
+
+	members := []member{}
+	for _, field := range stmt.StaticFields {
+		field := field
+		members = append(members, member{name: field.Token, print: func() {
+			p.write("static ")
+			p.VisitVarDecStmt(field)
+		}})
+	}
+	for _, method := range stmt.StaticMethods {
+		method := method
+		members = append(members, member{name: method.Name, print: func() {
+			p.write("static ")
+			p.function(method)
+		}})
+	}
+	for _, getter := range stmt.Getters {
+		getter := getter
+		members = append(members, member{name: getter.Name, print: func() {
+			p.write("get " + getter.Name.Lexeme + annotation(getter.ReturnType) + " ")
+			p.block(getter.Body, p.braceAfter(getter.Name))
+		}})
+	}
+	for _, method := range stmt.Methods {
+		method := method
+		members = append(members, member{name: method.Name, print: func() {
+			p.function(method)
+		}})
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		a, b := members[i].name, members[j].name
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+
+	lines := make([]int, 0, len(members))
+	for _, m := range members {
+		lines = append(lines, m.name.Line)
+	}
+	p.braced(p.braceAfter(stmt.Name), lines, func(i int) {
+		members[i].print()
+	})
+	return nil
+}
+
+func (p *printer) VisitBreakStmt(stmt statements.BreakStatement) error {
+	p.write("break")
+	if stmt.Label.Lexeme != "" {
+		p.write(" " + stmt.Label.Lexeme)
+	}
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitContinueStmt(stmt statements.ContinueStatement) error {
+	p.write("continue")
+	if stmt.Label.Lexeme != "" {
+		p.write(" " + stmt.Label.Lexeme)
+	}
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitThrowStmt(stmt statements.ThrowStatement) error {
+	p.write("throw ")
+	p.expression(stmt.Value)
+	p.write(";")
+	return nil
+}
+
+func (p *printer) VisitTryStmt(stmt statements.TryStatement) error {
+	p.write("try ")
+	open := p.braceAfter(stmt.Keyword)
+	p.block(stmt.Body, open)
+	// the last closing brace printed, the next clause's block comes after it
+	end := p.tokens[p.closing[open]]
+	if stmt.CatchBody != nil {
+		p.write(" catch (" + stmt.CatchName.Lexeme + ") ")
+		open = p.braceAfter(stmt.CatchName)
+		p.block(stmt.CatchBody, open)
+		end = p.tokens[p.closing[open]]
+	}
+	if stmt.FinallyBody != nil {
+		p.write(" finally ")
+		p.block(stmt.FinallyBody, p.braceAfter(end))
+	}
+	return nil
+}
+
+// the clauses are indented inside the braces and their bodies one level deeper
+func (p *printer) VisitSwitchStmt(stmt statements.SwitchStatement) error {
+	p.write("switch (")
+	p.expression(stmt.Value)
+	p.write(") ")
+	// the brace after the closing parenthesis, the value may hold braces of its own
+	open := p.after(stmt.Keyword, scanner.LEFT_PAREN)
+	if close, ok := p.closing[open]; ok {
+		open = p.braceAfter(p.tokens[close])
+	}
+
+	lines := make([]int, 0, len(stmt.Cases)+1)
+	for _, clause := range stmt.Cases {
+		lines = append(lines, clause.Keyword.Line)
+	}
+	if stmt.Default != nil {
+		lines = append(lines, stmt.DefaultKeyword.Line)
+	}
+	p.braced(open, lines, func(i int) {
+		if i == len(stmt.Cases) {
+			p.write("default:")
+			p.clauseBody(stmt.Default)
+			return
+		}
+		clause := stmt.Cases[i]
+		p.write("case ")
+		for j, value := range clause.Values {
+			if j != 0 {
+				p.write(", ")
+			}
+			p.expression(value)
+		}
+		p.write(":")
+		p.clauseBody(clause.Body)
+	})
+	return nil
+}
+
+// prints the statements of a switch clause each on its own line after the clause,
+// the enclosing braces print the comments following the last one
+func (p *printer) clauseBody(stmts []statements.Statement) {
+	p.depth++
+	p.first = true
+	for _, stmt := range stmts {
+		line := p.startLine(stmt)
+		p.trailing(line)
+		p.write("\n")
+		p.leading(line)
+		p.item(line)
+		p.statement(stmt)
+	}
+	p.depth--
+}
+
+func (p *printer) VisitImportStmt(stmt statements.ImportStatement) error {
+	if len(stmt.Names) != 0 {
+		names := make([]string, 0, len(stmt.Names))
+		for _, name := range stmt.Names {
+			names = append(names, name.Lexeme)
+		}
+		p.write("from " + stmt.Path.Lexeme + " import " + strings.Join(names, ", ") + ";")
+		return nil
+	}
+	p.write("import " + stmt.Path.Lexeme)
+	if stmt.Alias.Lexeme != "" {
+		p.write(" as " + stmt.Alias.Lexeme)
+	}
+	p.write(";")
+	return nil
+}
@@ -0,0 +1,298 @@
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/modules"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/runner"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+	"github.com/Ahmed-Sermani/prolang/vm"
+	"github.com/Ahmed-Sermani/prolang/work"
+)
+
+// golden tests are scripts annotated with the behavior they expect:
+//   print 1 + 2; // expect: 3
+//   print nope;  // expect runtime error: Undefined Variable 'nope'.
+//   let = 1;     // error at line 3: at '=' Expect variable name.
+// the output of the print statements is compared line by line with the expect annotations,
+// an expected runtime error must be raised on the line of its annotation.
+
+var (
+	expectOutput       = regexp.MustCompile(`^expect: ?(.*)$`)
+	expectRuntimeError = regexp.MustCompile(`^expect runtime error: ?(.*)$`)
+	expectError        = regexp.MustCompile(`^error at line (\d+): ?(.*)$`)
+	// the location runtime errors end with
+	errorLine = regexp.MustCompile(`^(?s)(.*)\[line (\d+)\]$`)
+)
+
+type expected struct {
+	line int
+	text string
+}
+
+// the annotations of a test file
+type expectations struct {
+	output        []expected
+	runtimeError  *expected
+	compileErrors []expected
+}
+
+type Result struct {
+	Path string
+	// the differences between the expected and the actual behavior, empty when the test passed
+	Failures []string
+	// the test was stopped or never started because the run was interrupted
+	Interrupted bool
+}
+
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Result) fail(format string, args ...interface{}) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+// a test file run by the worker pool
+type test struct {
+	path    string
+	timeout time.Duration
+	result  *Result
+	// run by the bytecode vm instead of the tree-walking interpreter
+	vm bool
+	// shared by the tests of a run, set once one of them is interrupted
+	interrupted *int32
+}
+
+// runs the tests in parallel on the given number of workers, each test is stopped after the timeout.
+// the results are in the order of the paths. once the run is interrupted (e.g. by Ctrl-C) the running
+// tests are stopped, the remaining ones are skipped and runner.ErrInterrupt is returned.
+// useVM runs the tests on the bytecode vm, the compile errors include the ones of the compiler
+func Run(paths []string, workers int, timeout time.Duration, useVM bool) ([]Result, error) {
+	results := make([]Result, len(paths))
+	var interrupted int32
+	pool := work.New(workers)
+	for i, path := range paths {
+		results[i].Path = path
+		pool.Run(&test{path: path, timeout: timeout, result: &results[i], vm: useVM, interrupted: &interrupted})
+	}
+	pool.Shutdown()
+	if interrupted == 1 {
+		return results, runner.ErrInterrupt
+	}
+	return results, nil
+}
+
+func (t *test) Task() {
+	if atomic.LoadInt32(t.interrupted) == 1 {
+		t.result.Interrupted = true
+		return
+	}
+	source, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		t.result.fail("can't read the file: %s", err)
+		return
+	}
+
+	var stmts []statements.Statement
+	var script *compiler.Function
+	var annotations expectations
+	inter := interpreter.New()
+	inter.SetPath(t.path)
+	loader := modules.New()
+	loader.SetMain(t.path)
+	inter.SetImporter(loader)
+	diagnostics := reporting.Collect(func() {
+		s := scanner.New(string(source))
+		s.SetFile(t.path)
+		s.KeepComments()
+		tokens := s.ScanTokens()
+		annotations = parseExpectations(s)
+		stmts = parser.New(tokens).Parse()
+		// the resolver can't walk a partial syntax tree
+		if !reporting.HadError() {
+			resolver.New(inter).Resolve(stmts)
+		}
+		if t.vm && !reporting.HadError() {
+			script = compiler.New().Compile(stmts)
+		}
+	})
+
+	t.checkCompileErrors(annotations.compileErrors, diagnostics)
+	if len(diagnostics) != 0 {
+		// the program isn't run so the expected output is missing
+		for _, e := range annotations.output {
+			t.result.fail("line %d: expected %q, got no output", e.line, e.text)
+		}
+		return
+	}
+
+	var out bytes.Buffer
+	var runErr error
+	var trace func(error) []string
+	var cancel func()
+	r := runner.New(t.timeout)
+	if t.vm {
+		machine := vm.New()
+		machine.SetFile(t.path)
+		loader := modules.New()
+		loader.SetMain(t.path)
+		machine.SetImporter(loader)
+		machine.SetOutput(&out)
+		r.Add(func(int) {
+			runErr = machine.Execute(script)
+		})
+		trace, cancel = machine.Trace, machine.Cancel
+	} else {
+		inter.SetOutput(&out)
+		stop := &canceler{}
+		inter.SetTracer(stop)
+		r.Add(func(int) {
+			runErr = inter.Execute(stmts)
+		})
+		trace, cancel = inter.Trace, func() { atomic.StoreInt32(&stop.canceled, 1) }
+	}
+	if err := r.Start(); err != nil {
+		cancel()
+		if err == runner.ErrInterrupt {
+			atomic.StoreInt32(t.interrupted, 1)
+			t.result.Interrupted = true
+			return
+		}
+		t.result.fail("timed out after %s", t.timeout)
+		return
+	}
+
+	t.checkOutput(annotations.output, out.String())
+	var frames []string
+	if runErr != nil {
+		frames = trace(runErr)
+	}
+	t.checkRuntimeError(annotations.runtimeError, runErr, frames)
+}
+
+// the expectations are read from the comments of the file
+func parseExpectations(s *scanner.Scanner) expectations {
+	var annotations expectations
+	for _, comment := range s.Comments() {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Lexeme, "//"))
+		if m := expectOutput.FindStringSubmatch(text); m != nil {
+			annotations.output = append(annotations.output, expected{line: comment.Line, text: m[1]})
+		} else if m := expectRuntimeError.FindStringSubmatch(text); m != nil {
+			annotations.runtimeError = &expected{line: comment.Line, text: m[1]}
+		} else if m := expectError.FindStringSubmatch(text); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			annotations.compileErrors = append(annotations.compileErrors, expected{line: line, text: m[2]})
+		}
+	}
+	return annotations
+}
+
+func (t *test) checkCompileErrors(want []expected, diagnostics []reporting.Diagnostic) {
+	got := make([]expected, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		got = append(got, expected{line: d.Line, text: strings.TrimSpace(d.Where + " " + strings.TrimSpace(d.Msg))})
+	}
+	sort.SliceStable(want, func(i, j int) bool { return want[i].line < want[j].line })
+	sort.SliceStable(got, func(i, j int) bool { return got[i].line < got[j].line })
+
+	// errors are matched in order, the unmatched ones are reported
+	matched := make([]bool, len(got))
+	for _, w := range want {
+		found := false
+		for i, g := range got {
+			if !matched[i] && g == w {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			t.result.fail("line %d: expected error %q", w.line, w.text)
+		}
+	}
+	for i, g := range got {
+		if !matched[i] {
+			t.result.fail("line %d: unexpected error %q", g.line, g.text)
+		}
+	}
+}
+
+func (t *test) checkOutput(want []expected, output string) {
+	got := []string{}
+	if output != "" {
+		got = strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	}
+	for i, w := range want {
+		if i >= len(got) {
+			t.result.fail("line %d: expected %q, got no output", w.line, w.text)
+		} else if got[i] != w.text {
+			t.result.fail("line %d: expected %q, got %q", w.line, w.text, got[i])
+		}
+	}
+	for _, g := range got[min(len(want), len(got)):] {
+		t.result.fail("unexpected output %q", g)
+	}
+}
+
+// the trace is shown for unexpected errors
+func (t *test) checkRuntimeError(want *expected, err error, trace []string) {
+	if err == nil {
+		if want != nil {
+			t.result.fail("line %d: expected runtime error %q, got none", want.line, want.text)
+		}
+		return
+	}
+	msg, line := err.Error(), 0
+	if m := errorLine.FindStringSubmatch(msg); m != nil {
+		msg = m[1]
+		line, _ = strconv.Atoi(m[2])
+	}
+	if want == nil {
+		t.result.fail("line %d: unexpected runtime error %q", line, msg)
+		for _, frame := range trace {
+			t.result.fail("    %s", frame)
+		}
+		return
+	}
+	if msg != want.text || line != want.line {
+		t.result.fail("line %d: expected runtime error %q, got %q at line %d", want.line, want.text, msg, line)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// stops the interpreter before its next statement once the test timed out.
+// implements interpreter.Tracer
+type canceler struct {
+	canceled int32
+}
+
+func (c *canceler) BeforeStatement(inter *interpreter.Interpreter, stmt statements.Statement) error {
+	if atomic.LoadInt32(&c.canceled) == 1 {
+		return interpreter.ErrorAbort{}
+	}
+	return nil
+}
+
+func (c *canceler) EnterFunction(function *interpreter.FunctionCallable) {}
+
+func (c *canceler) ExitFunction(function *interpreter.FunctionCallable) {}
@@ -0,0 +1,36 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// runs the golden suite of the language under test/ on both backends
+func TestSuite(t *testing.T) {
+	paths := []string{}
+	err := filepath.Walk("../test", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Ext(path) == ".pl" {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden tests found")
+	}
+	for _, useVM := range []bool{false, true} {
+		results, err := Run(paths, 4, 5*time.Second, useVM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			for _, failure := range result.Failures {
+				t.Errorf("%s (vm: %t): %s", result.Path, useVM, failure)
+			}
+		}
+	}
+}
@@ -10,7 +10,28 @@ import (
 
 type Callable interface {
 	Call(*Interpreter, []interface{}) (interface{}, error)
-	ArgsNum() int
+	// the number of arguments the callable accepts, max is -1 when there's no upper bound
+	Arity() (min int, max int)
+}
+
+// reports whether a callable of the arity accepts the number of arguments
+func accepts(min int, max int, argc int) bool {
+	return argc >= min && (max == -1 || argc <= max)
+}
+
+// describes the number of arguments a callable of the arity accepts e.g. "1 to 2 arguments"
+func describeArity(min int, max int) string {
+	noun := "arguments"
+	if (max == -1 || min == max) && min == 1 {
+		noun = "argument"
+	}
+	switch {
+	case max == -1:
+		return fmt.Sprintf("at least %d %s", min, noun)
+	case min == max:
+		return fmt.Sprintf("%d %s", min, noun)
+	}
+	return fmt.Sprintf("%d to %d %s", min, max, noun)
 }
 
 type FunctionCallable struct {
@@ -18,6 +39,11 @@ type FunctionCallable struct {
 	Closure     *environment.Environment
 	// flags the current function is an initializer
 	IsInit bool
+	// the module the function is declared in, its globals and resolution information
+	// are used while the function body runs
+	module *Module
+	// the class declaring the method, empty for functions
+	class string
 }
 
 // implementing the callable interface
@@ -27,15 +53,38 @@ func (f *FunctionCallable) Call(inter *Interpreter, args []interface{}) (interfa
 	// the call not on the function deleration
 	environment := environment.New(f.Closure)
 
-	// add args to the function environment
-	for i, param := range f.Declaration.Args {
-		environment.Define(param.Lexeme, args[i])
+	if err := inter.pushFrame(f); err != nil {
+		return nil, err
+	}
+	defer inter.popFrame()
+
+	if inter.tracer != nil {
+		inter.tracer.EnterFunction(f)
+		defer inter.tracer.ExitFunction(f)
+	}
+
+	if f.module != nil {
+		outerModule := inter.module
+		inter.module = f.module
+		defer func() {
+			inter.module = outerModule
+		}()
+	}
+
+	err := f.parameters(inter, environment, args)
+	if err != nil {
+		return nil, err
 	}
 	// execute function body
-	err := inter.executeBlock(f.Declaration.Body, environment)
+	err = inter.executeBlock(f.Declaration.Body, environment)
 
 	// handling the unwind of return statement
 	returnValue, isReturn := err.(ErrorHandleReturn)
+	// any other error keeps unwinding
+	if err != nil && !isReturn {
+		inter.unwinding(err)
+		return nil, err
+	}
 	// handle empty return from initializer default to 'this'
 	if isReturn && f.IsInit {
 		return f.Closure.GetAt(0, expressions.Token{Lexeme: "this"})
@@ -50,27 +99,60 @@ func (f *FunctionCallable) Call(inter *Interpreter, args []interface{}) (interfa
 		return f.Closure.GetAt(0, expressions.Token{Lexeme: "this"})
 	}
 	// default return value is nil
-	return nil, err
+	return nil, nil
+}
+
+// defines the parameters in the function environment, the missing arguments get their default values.
+// the defaults run in the function environment so they see the parameters before them
+func (f *FunctionCallable) parameters(inter *Interpreter, env *environment.Environment, args []interface{}) error {
+	for i, param := range f.Declaration.Args {
+		if f.Declaration.Variadic && i == len(f.Declaration.Args)-1 {
+			rest := []interface{}{}
+			if i < len(args) {
+				rest = append(rest, args[i:]...)
+			}
+			env.Define(param.Lexeme, NewList(rest))
+			break
+		}
+		if i < len(args) {
+			env.Define(param.Lexeme, args[i])
+			continue
+		}
+		value, err := inter.evaluateIn(f.Declaration.Default(i), env)
+		if err != nil {
+			inter.unwinding(err)
+			return err
+		}
+		env.Define(param.Lexeme, value)
+	}
+	return nil
 }
 
-func (f *FunctionCallable) ArgsNum() int {
-	return len(f.Declaration.Args)
+func (f *FunctionCallable) Arity() (int, int) {
+	return f.Declaration.Arity()
 }
 
 func (f *FunctionCallable) bind(i *Instance) *FunctionCallable {
 	environment := environment.New(f.Closure)
 	environment.Define("this", i)
-	return &FunctionCallable{Declaration: f.Declaration, Closure: environment, IsInit: f.IsInit}
+	return &FunctionCallable{Declaration: f.Declaration, Closure: environment, IsInit: f.IsInit, module: f.module, class: f.class}
 }
 
 func (f *FunctionCallable) String() string {
+	if f.Declaration.Name.Lexeme == "" {
+		return "<func anonymous>"
+	}
 	return "<func " + f.Declaration.Name.Lexeme + ">"
 }
 
 type ClassCallable struct {
 	name       string
 	methods    map[string]*FunctionCallable
+	getters    map[string]*FunctionCallable
 	superclass *ClassCallable
+	// static methods and fields are looked up on the class itself
+	staticMethods map[string]*FunctionCallable
+	fields        map[string]interface{}
 }
 
 type Instance struct {
@@ -84,7 +166,10 @@ func (c *ClassCallable) Call(inter *Interpreter, args []interface{}) (interface{
 	init := instance.class.lookForMethod("init")
 	if init != nil {
 		// bind 'this' then call the initializer method
-		init.bind(instance).Call(inter, args)
+		_, err := init.bind(instance).Call(inter, args)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return instance, nil
 }
@@ -101,27 +186,68 @@ func (c *ClassCallable) lookForMethod(name string) *FunctionCallable {
 	return nil
 }
 
+func (c *ClassCallable) lookForGetter(name string) *FunctionCallable {
+	getter, ok := c.getters[name]
+	if ok {
+		return getter
+	}
+	if c.superclass != nil {
+		return c.superclass.lookForGetter(name)
+	}
+	return nil
+}
+
+// lookup a static field or a static method on the class and its superclasses
+func (c *ClassCallable) Get(name expressions.Token) (interface{}, error) {
+	for class := c; class != nil; class = class.superclass {
+		if field, ok := class.fields[name.Lexeme]; ok {
+			return field, nil
+		}
+		if method, ok := class.staticMethods[name.Lexeme]; ok {
+			return method, nil
+		}
+	}
+	return nil, &UndefinedProperty{
+		InterpretationError: InterpretationError{
+			token: name,
+			msg:   fmt.Sprintf("Undefined static property '%s' on class '%s'", name.Lexeme, c.name),
+		},
+	}
+}
+
+// set a static field on the class
+func (c *ClassCallable) Set(name expressions.Token, value interface{}) {
+	c.fields[name.Lexeme] = value
+}
+
 // the number of arguments of class is the same as the number of arguments on the initializer
-func (c *ClassCallable) ArgsNum() int {
+func (c *ClassCallable) Arity() (int, int) {
 	init := c.lookForMethod("init")
 	// default to zero there's no initializer
 	if init == nil {
-		return 0
+		return 0, 0
 	}
-	return init.ArgsNum()
+	return init.Arity()
 }
 
 func (c *ClassCallable) String() string {
 	return "<class " + c.name + ">"
 }
 
-// lookup a property on an instance
-func (i *Instance) Get(name expressions.Token) (interface{}, error) {
+// lookup a property on an instance.
+// fields shadow getters and getters shadow methods, getters run right away
+func (i *Instance) Get(inter *Interpreter, name expressions.Token) (interface{}, error) {
 	property, exists := i.fields[name.Lexeme]
 	if exists {
 		return property, nil
 	}
 
+	getter := i.class.lookForGetter(name.Lexeme)
+	if getter != nil {
+		inter.callSite = name
+		return getter.bind(i).Call(inter, nil)
+	}
+
 	method := i.class.lookForMethod(name.Lexeme)
 	// return the method and bind the current instance to 'this'
 	if method != nil {
@@ -130,7 +256,8 @@ func (i *Instance) Get(name expressions.Token) (interface{}, error) {
 
 	return nil, &UndefinedProperty{
 		InterpretationError: InterpretationError{
-			msg: fmt.Sprintf("Undefined property '%s' on object of '%s'", name.Lexeme, i.class.name),
+			token: name,
+			msg:   fmt.Sprintf("Undefined property '%s' on object of '%s'", name.Lexeme, i.class.name),
 		},
 	}
 }
@@ -144,3 +271,25 @@ func (i *Instance) Set(name expressions.Token, value interface{}) {
 func (i *Instance) String() string {
 	return "<instance of " + i.class.name + ">"
 }
+
+// signature of a host function exposed to scripts
+type NativeFunc func(*Interpreter, []interface{}) (interface{}, error)
+
+// wraps a Go function so it can be called from scripts like any other callable
+type NativeCallable struct {
+	name  string
+	arity int
+	fn    NativeFunc
+}
+
+func (n *NativeCallable) Call(inter *Interpreter, args []interface{}) (interface{}, error) {
+	return n.fn(inter, args)
+}
+
+func (n *NativeCallable) Arity() (int, int) {
+	return n.arity, n.arity
+}
+
+func (n *NativeCallable) String() string {
+	return "<native func " + n.name + ">"
+}
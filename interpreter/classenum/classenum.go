@@ -5,4 +5,6 @@ const (
 	NONE = iota
 	CLASS
 	SUPCLASS
+	// inside a static method or a static field initializer where there's no instance
+	STATIC
 )
@@ -4,22 +4,66 @@ import (
 	"fmt"
 
 	"github.com/Ahmed-Sermani/prolang/parser/expressions"
-	"github.com/Ahmed-Sermani/prolang/reporting"
 )
 
 type ErrorUndefinedVairable struct {
-	msg string
+	msg   string
+	token expressions.Token
+	// a defined name close to the undefined one, empty if there is none
+	similar string
 }
 
 func (e ErrorUndefinedVairable) Error() string {
+	return e.Message() + fmt.Sprintf("[line %d]", e.token.Line)
+}
+
+func (e ErrorUndefinedVairable) Message() string {
 	if e.msg == "" {
 		return "Undefined Vairable"
 	}
 	return e.msg
 }
 
+func (e ErrorUndefinedVairable) Line() int {
+	return e.token.Line
+}
+
+func (e ErrorUndefinedVairable) Token() expressions.Token {
+	return e.token
+}
+
+func (e ErrorUndefinedVairable) Hint() string {
+	if e.similar == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean '%s'?", e.similar)
+}
+
+// raised when assigning a name bound by a constant declaration
+type ErrorConstantAssignment struct {
+	token expressions.Token
+}
+
+func (e ErrorConstantAssignment) Error() string {
+	return e.Message() + fmt.Sprintf("[line %d]", e.token.Line)
+}
+
+func (e ErrorConstantAssignment) Message() string {
+	return fmt.Sprintf("Can't assign to constant '%s'.", e.token.Lexeme)
+}
+
+func (e ErrorConstantAssignment) Line() int {
+	return e.token.Line
+}
+
+func (e ErrorConstantAssignment) Token() expressions.Token {
+	return e.token
+}
+
 type Environment struct {
 	values map[string]interface{}
+	// the names bound by constant declarations, functions and classes
+	constants map[string]bool
 	// scoping support
 	// refer to the outer scope variables
 	enclosing *Environment
@@ -28,6 +72,7 @@ type Environment struct {
 func New(enclosing *Environment) *Environment {
 	return &Environment{
 		values:    map[string]interface{}{},
+		constants: map[string]bool{},
 		enclosing: enclosing,
 	}
 }
@@ -38,37 +83,106 @@ func (env *Environment) GetEnclosing() *Environment {
 
 func (env *Environment) Define(name string, value interface{}) {
 	env.values[name] = value
+	delete(env.constants, name)
+}
+
+// binds a name that can't be assigned, the resolver rejects declaring the name again
+func (env *Environment) DefineConstant(name string, value interface{}) {
+	env.values[name] = value
+	env.constants[name] = true
+}
+
+// looks up a name in this scope only without walking the enclosing scopes
+func (env *Environment) Lookup(name string) (interface{}, bool) {
+	v, ok := env.values[name]
+	return v, ok
+}
+
+// the names defined in this scope only
+func (env *Environment) Names() []string {
+	names := make([]string, 0, len(env.values))
+	for name := range env.values {
+		names = append(names, name)
+	}
+	return names
 }
 
 func (env *Environment) Get(t expressions.Token) (interface{}, error) {
-	v, ok := env.values[t.Lexeme]
-	if ok {
-		return v, nil
+	// lookup the variable into the outer scopes
+	for scope := env; scope != nil; scope = scope.enclosing {
+		if v, ok := scope.values[t.Lexeme]; ok {
+			return v, nil
+		}
 	}
+	// reporting is left to the interpreter so the error can be caught by scripts
+	return nil, env.undefined(t)
+}
 
-	// recursive lookup the variable into the outer scopes
-	if env.enclosing != nil {
-		return env.enclosing.Get(t)
+func (env *Environment) undefined(t expressions.Token) error {
+	return ErrorUndefinedVairable{msg: fmt.Sprintf("Undefined Variable '%s'.", t.Lexeme), token: t, similar: env.similar(t.Lexeme)}
+}
+
+// the visible name closest to the misspelled one, empty if none is within two edits
+func (env *Environment) similar(name string) string {
+	best, distance := "", 3
+	for scope := env; scope != nil; scope = scope.enclosing {
+		for candidate := range scope.values {
+			d := editDistance(name, candidate)
+			if d < distance || (d == distance && candidate < best) {
+				best, distance = candidate, d
+			}
+		}
+	}
+	// one or two letters names are close to every short name
+	if distance >= len(name) {
+		return ""
 	}
-	err := ErrorUndefinedVairable{msg: fmt.Sprintf("Undefined Variable '%s'.", t.Lexeme)}
-	reporting.ReportRuntimeError(err)
-	return nil, err
+	return best
+}
 
+// the Levenshtein distance between the two strings
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
 }
 
-func (env *Environment) Assgin(t expressions.Token, value interface{}) error {
-	if _, ok := env.values[t.Lexeme]; ok {
-		env.values[t.Lexeme] = value
-		return nil
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
 	}
+	return m
+}
 
-	// recursive lookup into the outer scopes to the variable to assgin
-	if env.enclosing != nil {
-		return env.enclosing.Assgin(t, value)
+func (env *Environment) Assgin(t expressions.Token, value interface{}) error {
+	// lookup into the outer scopes to the variable to assgin
+	for scope := env; scope != nil; scope = scope.enclosing {
+		if _, ok := scope.values[t.Lexeme]; ok {
+			if scope.constants[t.Lexeme] {
+				return ErrorConstantAssignment{token: t}
+			}
+			scope.values[t.Lexeme] = value
+			return nil
+		}
 	}
-	err := ErrorUndefinedVairable{msg: fmt.Sprintf("Undefined Variable '%s'.", t.Lexeme)}
-	reporting.ReportRuntimeError(err)
-	return err
+	return env.undefined(t)
 }
 
 // walks a fixed number of predecessors up the parent chain and
@@ -81,6 +195,9 @@ func (env *Environment) GetAt(level int, t expressions.Token) (interface{}, erro
 
 func (env *Environment) AssginAt(level int, t expressions.Token, value interface{}) error {
 	predecEnv := env.predecessors(level)
+	if predecEnv.constants[t.Lexeme] {
+		return ErrorConstantAssignment{token: t}
+	}
 	predecEnv.values[t.Lexeme] = value
 	return nil
 }
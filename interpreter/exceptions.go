@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter/environment"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+// error to handle unwind for throw statement, it carries the thrown value
+// up to the closest enclosing catch clause
+type ErrorHandleThrow struct {
+	value interface{}
+	token expressions.Token
+}
+
+func (e ErrorHandleThrow) Error() string {
+	return fmt.Sprintf("Uncaught %s[line %d]", stringify(e.value), e.token.Line)
+}
+
+func (e ErrorHandleThrow) Value() interface{} {
+	return e.value
+}
+
+func (e ErrorHandleThrow) Message() string {
+	return "Uncaught " + stringify(e.value)
+}
+
+// the throw keyword, an uncaught throw is reported there
+func (e ErrorHandleThrow) Token() expressions.Token {
+	return e.token
+}
+
+// implemented by the runtime errors that can be surfaced to scripts
+type runtimeError interface {
+	error
+	Message() string
+	Line() int
+}
+
+func (e *InterpretationError) Message() string {
+	if e.msg == "" {
+		return "error while interpreting expressions"
+	}
+	return e.msg
+}
+
+func (e *InterpretationError) Line() int {
+	return e.token.Line
+}
+
+// the token the error was raised at, the diagnostics underline it
+func (e *InterpretationError) Token() expressions.Token {
+	return e.token
+}
+
+// attaches a token to an error that was created without one (e.g. from a native function)
+func (e *InterpretationError) locate(token expressions.Token) {
+	if e.token.Line == 0 {
+		e.token = token
+	}
+}
+
+type locatable interface {
+	locate(expressions.Token)
+}
+
+// class of the instances runtime errors are converted into when they get caught
+var errorClass = &ClassCallable{name: "Error", methods: map[string]*FunctionCallable{}}
+
+// stops the program without reporting an error, e.g. when quitting the debugger
+type ErrorAbort struct{}
+
+func (e ErrorAbort) Error() string {
+	return "aborted"
+}
+
+// return, break and continue use errors to unwind but they must never be caught.
+// neither can an abort
+func isControlFlow(err error) bool {
+	switch err.(type) {
+	case ErrorHandleReturn, ErrorHandleBreak, ErrorHandleContinue, ErrorAbort:
+		return true
+	}
+	return false
+}
+
+// the value a catch clause binds for the given error.
+// thrown values are passed as is and runtime errors become Error instances
+// with message, line and kind fields.
+func caughtValue(err error) interface{} {
+	if thrown, ok := err.(ErrorHandleThrow); ok {
+		return thrown.value
+	}
+	instance := &Instance{class: errorClass, fields: map[string]interface{}{}}
+	instance.fields["kind"] = errorKind(err)
+	if rerr, ok := err.(runtimeError); ok {
+		instance.fields["message"] = rerr.Message()
+		instance.fields["line"] = int64(rerr.Line())
+	} else {
+		instance.fields["message"] = err.Error()
+		instance.fields["line"] = int64(0)
+	}
+	return instance
+}
+
+// a stable name of a runtime error that scripts can switch on
+func errorKind(err error) string {
+	switch err.(type) {
+	case *ErrorOpNumMismatch:
+		return "OperandMismatch"
+	case *ObjNotCallable:
+		return "NotCallable"
+	case *ArgsNumMismatch:
+		return "ArgumentsMismatch"
+	case *InvalidPropertyAccess:
+		return "InvalidPropertyAccess"
+	case *UndefinedProperty:
+		return "UndefinedProperty"
+	case *InvalidFieldAssignment:
+		return "InvalidFieldAssignment"
+	case *InvalidSuperclass:
+		return "InvalidSuperclass"
+	case *InvalidArgument:
+		return "InvalidArgument"
+	case *IndexOutOfRange:
+		return "IndexOutOfRange"
+	case *InvalidIndex:
+		return "InvalidIndex"
+	case *KeyNotFound:
+		return "KeyNotFound"
+	case *UnhashableKey:
+		return "UnhashableKey"
+	case *IntegerOverflow:
+		return "IntegerOverflow"
+	case *DivisionByZero:
+		return "DivisionByZero"
+	case *StackOverflow:
+		return "StackOverflow"
+	case environment.ErrorUndefinedVairable:
+		return "UndefinedVariable"
+	case environment.ErrorConstantAssignment:
+		return "ConstantAssignment"
+	}
+	return "RuntimeError"
+}
@@ -1,7 +1,10 @@
 package interpreter
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"strconv"
 
@@ -20,10 +23,7 @@ type InterpretationError struct {
 
 // implementing the error interface
 func (e *InterpretationError) Error() string {
-	if e.msg == "" {
-		e.msg = "error while interpreting expressions"
-	}
-	return e.msg + fmt.Sprintf("[line %d]", e.token.Line)
+	return e.Message() + fmt.Sprintf("[line %d]", e.token.Line)
 }
 
 type ErrorOpNumMismatch struct {
@@ -63,32 +63,83 @@ func (e ErrorHandleReturn) Error() string {
 	return ""
 }
 
-type Locals map[expressions.Experssion]*int
+// errors to handle unwind for break and continue statements.
+// label is empty when the innermost loop is targeted
+type ErrorHandleBreak struct {
+	label string
+}
+
+func (e ErrorHandleBreak) Error() string {
+	return ""
+}
+
+type ErrorHandleContinue struct {
+	label string
+}
+
+func (e ErrorHandleContinue) Error() string {
+	return ""
+}
+
+// error to unwind an optional chain once an optional access is made on nil
+type ErrorHandleShortCircuit struct{}
+
+func (e ErrorHandleShortCircuit) Error() string {
+	return ""
+}
+
+// resolution information keyed by the unique id of the resolved expression.
+// the expressions themselves can't be keys since some of them hold slices (e.g. xs = [1]).
+type Locals map[int]int
 
 // implement expression visitor and statement visitor interface
 type Interpreter struct {
 	// current scope pointer
 	environment *environment.Environment
-	// top level scope pointer
-	globals *environment.Environment
-	// stores resolution information generated by the resolver
-	locals Locals
+	// the module being executed, it holds the top level scope pointer
+	// and the resolution information generated by the resolver
+	module *Module
+	// scope enclosing the top level scope of every module, it holds the built-ins
+	builtins *environment.Environment
+	// loads modules for import statements
+	importer Importer
+	// lazily created reader used by the 'input' built-in
+	stdin *bufio.Reader
+	// where print statements write
+	stdout io.Writer
+	// follows the execution when set, e.g. the debugger
+	tracer Tracer
+	// the call stack, callSite is the token of the call being made until the callee pushes its frame
+	frames   []Frame
+	callSite expressions.Token
+	// the stack at the point the error being unwound left its first frame, nil when there is none
+	unwound []Frame
+	// set while evaluating an expression the resolver didn't see,
+	// its variables are looked up through the scope chain
+	dynamic bool
 }
 
 func New() *Interpreter {
-	envPtr := environment.New(nil)
-	return &Interpreter{
-		environment: envPtr,
-		globals:     envPtr,
-		locals:      Locals{},
+	inter := &Interpreter{
+		builtins: environment.New(nil),
+		stdout:   os.Stdout,
 	}
+	inter.module = inter.NewModule("")
+	inter.environment = inter.module.globals
+	inter.defineCoreNatives()
+	return inter
 }
 
 func (inter *Interpreter) Interpret(stmts []statements.Statement) error {
 	for _, stmt := range stmts {
+		inter.unwound = nil
 		err := inter.execute(stmt)
+		// an aborted program isn't a runtime error
+		if _, aborted := err.(ErrorAbort); aborted {
+			return err
+		}
 		if err != nil {
-			reporting.ReportRuntimeError(err)
+			inter.Report(err)
 			return err
 		}
 	}
@@ -96,7 +147,30 @@ func (inter *Interpreter) Interpret(stmts []statements.Statement) error {
 	return nil
 }
 
+// reports a runtime error with the stack it unwound
+func (inter *Interpreter) Report(err error) {
+	reporting.ReportRuntimeErrorTrace(err, inter.Trace(err))
+}
+
+// the stack trace of an uncaught error, nil if it was raised outside of functions
+func (inter *Interpreter) Trace(err error) []string {
+	if len(inter.unwound) == 0 {
+		return nil
+	}
+	at := expressions.Token{}
+	if located, ok := err.(interface{ Token() expressions.Token }); ok {
+		at = located.Token()
+	}
+	return StackTrace(inter.unwound, at)
+}
+
 func (inter *Interpreter) execute(stmt statements.Statement) error {
+	if inter.tracer != nil {
+		err := inter.tracer.BeforeStatement(inter, stmt)
+		if err != nil {
+			return err
+		}
+	}
 	return stmt.Accept(inter)
 }
 
@@ -114,7 +188,7 @@ func (inter *Interpreter) VisitGrouping(expr expressions.Grouping) (interface{},
 }
 
 // evaluate the operand expression. Then apply the unary operator itself to the result of that.
-// There are two different unary expressions, identified by the type of the operator token.
+// There are three different unary expressions, identified by the type of the operator token.
 func (inter *Interpreter) VisitUnary(expr expressions.Unary) (interface{}, error) {
 	right, err := inter.evaluate(expr.Right)
 	if err != nil {
@@ -123,13 +197,10 @@ func (inter *Interpreter) VisitUnary(expr expressions.Unary) (interface{}, error
 
 	switch expr.Operator.Kind {
 	case scanner.MINUS:
-		refVal := reflect.ValueOf(right)
-		val, err := reflectFloat64(refVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
 		// negating the right operand in case of minus operator
-		return -val, nil
+		return negate(expr.Operator, right)
+	case scanner.TILDE:
+		return complement(expr.Operator, right)
 	case scanner.BANG:
 		refVal := reflect.ValueOf(right)
 		// applying the ! operator
@@ -143,122 +214,27 @@ func (inter *Interpreter) VisitBinary(expr expressions.Binary) (interface{}, err
 
 	left, err := inter.evaluate(expr.Left)
 	if err != nil {
-		return nil, &InterpretationError{token: expr.Operator}
+		return nil, err
 	}
 	right, err := inter.evaluate(expr.Right)
 	if err != nil {
-		return nil, &InterpretationError{token: expr.Operator}
+		return nil, err
 	}
 
 	switch expr.Operator.Kind {
 
 	// arithmetic operator
-	case scanner.MINUS:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-
-		return (lVal - rVal), nil
-
-	case scanner.SLASH:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-
-		return (lVal / rVal), nil
-
-	case scanner.STAR:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-
-		return (lVal * rVal), nil
+	case scanner.MINUS, scanner.SLASH, scanner.STAR, scanner.PERCENT, scanner.STAR_STAR:
+		return arithmetic(expr.Operator, left, right)
+	// bitwise operators
+	case scanner.AMPERSAND, scanner.PIPE, scanner.CARET, scanner.LESS_LESS, scanner.GREATER_GREATER:
+		return bitwise(expr.Operator, left, right)
 	// + supports additions on numbers and concatenation on strings
 	case scanner.PLUS:
-		lRefVal := reflect.ValueOf(left)
-		rRefVal := reflect.ValueOf(right)
-		if lRefVal.Kind() == reflect.Float64 && rRefVal.Kind() == reflect.Float64 {
-			return lRefVal.Float() + rRefVal.Float(), nil
-		} else if lRefVal.Kind() == reflect.String && rRefVal.Kind() == reflect.String {
-			return lRefVal.String() + rRefVal.String(), nil
-		} else {
-			return nil, &ErrorOpNumMismatch{
-				InterpretationError{
-					token: expr.Operator,
-					msg:   "Operands must be two numbers or two strings",
-				},
-			}
-		}
+		return add(expr.Operator, left, right)
 	// comparison operators
-	case scanner.GREATER:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		return lVal > rVal, nil
-	case scanner.GREATER_EQUAL:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		return lVal >= rVal, nil
-	case scanner.LESS:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		return lVal < rVal, nil
-	case scanner.LESS_EQUAL:
-		lRefVal := reflect.ValueOf(left)
-		lVal, err := reflectFloat64(lRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		rRefVal := reflect.ValueOf(right)
-		rVal, err := reflectFloat64(rRefVal, expr.Operator)
-		if err != nil {
-			return nil, err
-		}
-		return lVal <= rVal, nil
+	case scanner.GREATER, scanner.GREATER_EQUAL, scanner.LESS, scanner.LESS_EQUAL:
+		return compare(expr.Operator, left, right)
 	// equality
 	case scanner.EQUAL_EQUAL:
 		return isEqual(left, right), nil
@@ -274,16 +250,25 @@ func (inter *Interpreter) VisitVairable(expr expressions.Variable) (interface{},
 }
 
 // evaluates the r-value.
-// then stores it in the named variable
+// then stores it in the named variable.
+// compound assignments read the variable before evaluating the r-value
 func (inter *Interpreter) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
-	value, err := inter.evaluate(expr.Value)
+	var current interface{}
+	if expr.Operator.Lexeme != "" {
+		var err error
+		current, err = inter.lookUpVar(expr.Token, expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	value, err := inter.update(expr.Operator, current, expr.Value)
 	if err != nil {
 		return nil, err
 	}
 
-	level := inter.locals[expr]
-	if level != nil {
-		err := inter.environment.AssginAt(*level, expr.Token, value)
+	level, ok := inter.module.locals[expr.Uuid]
+	if ok {
+		err := inter.environment.AssginAt(level, expr.Token, value)
 		if err != nil {
 			return nil, err
 		}
@@ -293,24 +278,64 @@ func (inter *Interpreter) VisitAssgin(expr expressions.Assgin) (interface{}, err
 			return nil, err
 		}
 	}
+	if expr.Postfix {
+		return current, nil
+	}
 	return value, nil
 }
 
+// evaluates the r-value of an assignment, it's combined with the current value
+// of the target for compound assignments, increments and decrements
+func (inter *Interpreter) update(operator expressions.Token, current interface{}, operand expressions.Experssion) (interface{}, error) {
+	value, err := inter.evaluate(operand)
+	if err != nil || operator.Lexeme == "" {
+		return value, err
+	}
+	return Arithmetic(operator, current, value)
+}
+
 func (inter *Interpreter) VisitLogical(expr expressions.Logical) (interface{}, error) {
 	left, err := inter.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
 	}
-	refVal := reflect.ValueOf(left)
-	if expr.Operator.Kind == scanner.OR && isTruthy(refVal) {
-		return left, nil
-	} else if !isTruthy(refVal) {
+	// 'or' short-circuits on a truthy left operand and 'and' on a falsy one
+	if isTruthy(reflect.ValueOf(left)) == (expr.Operator.Kind == scanner.OR) {
 		return left, nil
 	}
 
 	return inter.evaluate(expr.Right)
 }
 
+func (inter *Interpreter) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	condition, err := inter.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if isTruthy(reflect.ValueOf(condition)) {
+		return inter.evaluate(expr.Then)
+	}
+	return inter.evaluate(expr.Else)
+}
+
+// unlike 'or' only nil gives the right operand, false is kept
+func (inter *Interpreter) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	left, err := inter.evaluate(expr.Left)
+	if err != nil || left != nil {
+		return left, err
+	}
+	return inter.evaluate(expr.Right)
+}
+
+// the rest of the chain is skipped once an optional access is made on nil
+func (inter *Interpreter) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	value, err := inter.evaluate(expr.Expr)
+	if _, ok := err.(ErrorHandleShortCircuit); ok {
+		return nil, nil
+	}
+	return value, err
+}
+
 func (inter *Interpreter) VisitCall(expr expressions.Call) (interface{}, error) {
 	callee, err := inter.evaluate(expr.Callee)
 	if err != nil {
@@ -329,19 +354,29 @@ func (inter *Interpreter) VisitCall(expr expressions.Call) (interface{}, error)
 	if !ok {
 		return nil, &ObjNotCallable{
 			InterpretationError: InterpretationError{
-				msg: fmt.Sprintf("Object %s is not callable", callee),
+				token: expr.Parenth,
+				msg:   fmt.Sprintf("Object %s is not callable", stringify(callee)),
 			},
 		}
 	}
 
-	if len(args) != function.ArgsNum() {
-		return nil, &ObjNotCallable{
+	if min, max := function.Arity(); !accepts(min, max, len(args)) {
+		return nil, &ArgsNumMismatch{
 			InterpretationError: InterpretationError{
-				msg: fmt.Sprintf("Function %s does not have the correct number of arguments", callee),
+				token: expr.Parenth,
+				msg:   fmt.Sprintf("Function %s expected %s but got %d", stringify(callee), describeArity(min, max), len(args)),
 			},
 		}
 	}
-	return function.Call(inter, args)
+	inter.callSite = expr.Parenth
+	value, err := function.Call(inter, args)
+	// natives don't push a frame so the call site is still pending
+	inter.callSite = expressions.Token{}
+	// errors raised by native functions don't know where they were called from
+	if lerr, ok := err.(locatable); ok {
+		lerr.locate(expr.Parenth)
+	}
+	return value, err
 
 }
 
@@ -350,18 +385,28 @@ func (inter *Interpreter) VisitPropertyAccess(expr expressions.PropertyAccess) (
 	if err != nil {
 		return nil, err
 	}
+	if expr.Optional && obj == nil {
+		return nil, ErrorHandleShortCircuit{}
+	}
 	instance, ok := obj.(*Instance)
 	if ok {
-		property, err := instance.Get(expr.Name)
+		property, err := instance.Get(inter, expr.Name)
 		if err != nil {
 			return nil, err
 		}
 		return property, nil
 	}
+	if m, ok := obj.(*Module); ok {
+		return m.Get(expr.Name)
+	}
+	if class, ok := obj.(*ClassCallable); ok {
+		return class.Get(expr.Name)
+	}
 
 	err = &InvalidPropertyAccess{
 		InterpretationError: InterpretationError{
-			msg: "not an instance, only instances have properties ",
+			token: expr.Name,
+			msg:   "not an instance, only instances have properties ",
 		},
 	}
 	return nil, err
@@ -373,19 +418,40 @@ func (inter *Interpreter) VisitPropertyAssignment(expr expressions.PropertyAssig
 	if err != nil {
 		return nil, err
 	}
+	// static fields are assigned on the class itself
+	class, isClass := obj.(*ClassCallable)
 	instance, ok := obj.(*Instance)
-	if !ok {
+	if !ok && !isClass {
 		return nil, &InvalidFieldAssignment{
 			InterpretationError: InterpretationError{
-				msg: "not an instance, field assignment only allowed on instances",
+				token: expr.Name,
+				msg:   "not an instance, field assignment only allowed on instances and classes",
 			},
 		}
 	}
-	value, err := inter.evaluate(expr.Value)
+	var current interface{}
+	if expr.Operator.Lexeme != "" {
+		if isClass {
+			current, err = class.Get(expr.Name)
+		} else {
+			current, err = instance.Get(inter, expr.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	value, err := inter.update(expr.Operator, current, expr.Value)
 	if err != nil {
 		return nil, err
 	}
-	instance.Set(expr.Name, value)
+	if isClass {
+		class.Set(expr.Name, value)
+	} else {
+		instance.Set(expr.Name, value)
+	}
+	if expr.Postfix {
+		return current, nil
+	}
 	return value, nil
 }
 
@@ -396,32 +462,133 @@ func (inter *Interpreter) VisitThis(expr expressions.This) (interface{}, error)
 
 func (inter *Interpreter) VisitSuper(expr expressions.Super) (interface{}, error) {
 	// looking up 'super' in the proper env
-	level := inter.locals[expr]
-	superclass, err := inter.environment.GetAt(*level, expressions.Token{Lexeme: "super"})
+	level, ok := inter.module.locals[expr.Uuid]
+	if !ok {
+		return nil, &InterpretationError{token: expr.Keyword, msg: "Can't use 'super' here"}
+	}
+	superclass, err := inter.environment.GetAt(level, expressions.Token{Lexeme: "super"})
 	if err != nil {
 		return nil, err
 	}
 	// getting 'this' from the previous environment
-	this, err := inter.environment.GetAt(*level-1, expressions.Token{Lexeme: "this"})
+	this, err := inter.environment.GetAt(level-1, expressions.Token{Lexeme: "this"})
 	if err != nil {
 		return nil, err
 	}
 	super := superclass.(*ClassCallable)
+	instance, ok := this.(*Instance)
+	if !ok {
+		return nil, &InterpretationError{token: expr.Keyword, msg: "Undefined Instance"}
+	}
+	// getters of the superclass run right away
+	if getter := super.lookForGetter(expr.Method.Lexeme); getter != nil {
+		inter.callSite = expr.Method
+		return getter.bind(instance).Call(inter, nil)
+	}
 	method := super.lookForMethod(expr.Method.Lexeme)
 	if method == nil {
 		return nil, &UndefinedProperty{
 			InterpretationError: InterpretationError{
-				msg: "Undefined property " + expr.Method.Lexeme,
+				token: expr.Method,
+				msg:   "Undefined property " + expr.Method.Lexeme,
 			},
 		}
 	}
-	instance, ok := this.(*Instance)
-	if !ok {
-		return nil, &InterpretationError{msg: "Undefined Instance"}
-	}
 	return method.bind(instance), nil
 }
 
+// anonymous functions close over the current environment like function declarations do
+// but they are not bound to any name
+func (inter *Interpreter) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	declaration := expr.Function.(statements.FunctionStatement)
+	return &FunctionCallable{Declaration: declaration, Closure: inter.environment, module: inter.module}, nil
+}
+
+func (inter *Interpreter) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := inter.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewList(elements), nil
+}
+
+func (inter *Interpreter) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	m := NewMap()
+	for i := range expr.Keys {
+		key, err := inter.evaluate(expr.Keys[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := inter.evaluate(expr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		err = m.Set(key, value, expr.Brace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// subscript reads are allowed on lists, maps and strings
+func (inter *Interpreter) VisitIndex(expr expressions.Index) (interface{}, error) {
+	obj, err := inter.evaluate(expr.Obj)
+	if err != nil {
+		return nil, err
+	}
+	index, err := inter.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	return getIndex(obj, index, expr.Bracket)
+}
+
+func (inter *Interpreter) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	obj, err := inter.evaluate(expr.Obj)
+	if err != nil {
+		return nil, err
+	}
+	index, err := inter.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	value, err := inter.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	err = setIndex(obj, index, value, expr.Bracket)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (inter *Interpreter) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	obj, err := inter.evaluate(expr.Obj)
+	if err != nil {
+		return nil, err
+	}
+	var start, end interface{}
+	if expr.Start != nil {
+		start, err = inter.evaluate(expr.Start)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if expr.End != nil {
+		end, err = inter.evaluate(expr.End)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slice(obj, start, end, expr.Bracket)
+}
+
 func (inter *Interpreter) VisitExprStmt(stmt statements.ExperssionStatement) error {
 	_, err := inter.evaluate(stmt.Expr)
 	return err
@@ -433,7 +600,7 @@ func (inter *Interpreter) VisitPrintStmt(stmt statements.PrintStatement) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(stringify(val))
+	fmt.Fprintln(inter.stdout, stringify(val))
 	return err
 
 }
@@ -450,6 +617,10 @@ func (inter *Interpreter) VisitVarDecStmt(stmt statements.VarDecStatement) error
 		}
 		value = tvalue
 	}
+	if stmt.Constant {
+		inter.environment.DefineConstant(stmt.Token.Lexeme, value)
+		return nil
+	}
 	inter.environment.Define(stmt.Token.Lexeme, value)
 	return nil
 }
@@ -459,6 +630,30 @@ func (inter *Interpreter) VisitBlockStmt(stmt statements.BlockStatement) error {
 	return inter.executeBlock(stmt.Statements, environment.New(inter.environment))
 }
 
+// runs the body of the first case having a value equal to the switch value, the default body when none has.
+// the case values are evaluated in order until one matches
+func (inter *Interpreter) VisitSwitchStmt(stmt statements.SwitchStatement) error {
+	value, err := inter.evaluate(stmt.Value)
+	if err != nil {
+		return err
+	}
+	for _, clause := range stmt.Cases {
+		for _, expr := range clause.Values {
+			caseValue, err := inter.evaluate(expr)
+			if err != nil {
+				return err
+			}
+			if isEqual(value, caseValue) {
+				return inter.executeBlock(clause.Body, environment.New(inter.environment))
+			}
+		}
+	}
+	if stmt.Default != nil {
+		return inter.executeBlock(stmt.Default, environment.New(inter.environment))
+	}
+	return nil
+}
+
 // evaluates the condition. If truthy, executes the then branch.
 // Otherwise, if there is an else branch, execute that.
 func (inter *Interpreter) VisitIfStmt(stmt statements.IfStatement) error {
@@ -480,7 +675,8 @@ func (inter *Interpreter) VisitIfStmt(stmt statements.IfStatement) error {
 	return nil
 }
 
-// evaluated the condition . while it's truthy execute the body
+// evaluated the condition . while it's truthy execute the body then the increment if exists.
+// break and continue unwind up to the loop they target, other errors (including return) keep unwinding
 func (inter *Interpreter) VisitWhileStmt(stmt statements.WhileStatement) error {
 	for {
 		condiction, err := inter.evaluate(stmt.Condition)
@@ -491,20 +687,83 @@ func (inter *Interpreter) VisitWhileStmt(stmt statements.WhileStatement) error {
 			break
 		}
 		err = inter.execute(stmt.Body)
+		if brk, ok := err.(ErrorHandleBreak); ok && targetsLoop(brk.label, stmt) {
+			break
+		}
+		if cont, ok := err.(ErrorHandleContinue); ok && targetsLoop(cont.label, stmt) {
+			err = nil
+		}
 		if err != nil {
-			return nil
+			return err
+		}
+		if stmt.Increment != nil {
+			_, err = inter.evaluate(stmt.Increment)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+func (inter *Interpreter) VisitThrowStmt(stmt statements.ThrowStatement) error {
+	value, err := inter.evaluate(stmt.Value)
+	if err != nil {
+		return err
+	}
+	return ErrorHandleThrow{value: value, token: stmt.Keyword}
+}
+
+// executes the try body, if it fails with a thrown value or a runtime error the catch clause
+// runs with the error bound to its variable. the finally clause always runs last and
+// an error raised by it (or a return, break or continue inside it) replaces the pending one.
+func (inter *Interpreter) VisitTryStmt(stmt statements.TryStatement) error {
+	err := inter.executeBlock(stmt.Body, environment.New(inter.environment))
+
+	if err != nil && !isControlFlow(err) && stmt.CatchBody != nil {
+		inter.unwound = nil
+		catchEnv := environment.New(inter.environment)
+		catchEnv.Define(stmt.CatchName.Lexeme, caughtValue(err))
+		err = inter.executeBlock(stmt.CatchBody, catchEnv)
+	}
+
+	if stmt.FinallyBody != nil {
+		// the finally clause may raise its own error
+		unwound := inter.unwound
+		inter.unwound = nil
+		finallyErr := inter.executeBlock(stmt.FinallyBody, environment.New(inter.environment))
+		if finallyErr != nil {
+			return finallyErr
+		}
+		inter.unwound = unwound
+	}
+	return err
+}
+
+func (inter *Interpreter) VisitBreakStmt(stmt statements.BreakStatement) error {
+	return ErrorHandleBreak{label: stmt.Label.Lexeme}
+}
+
+func (inter *Interpreter) VisitContinueStmt(stmt statements.ContinueStatement) error {
+	return ErrorHandleContinue{label: stmt.Label.Lexeme}
+}
+
+// unlabeled break and continue target the innermost loop
+func targetsLoop(label string, stmt statements.WhileStatement) bool {
+	return label == "" || label == stmt.Label.Lexeme
+}
+
 // convert function parse time representation to its runtime representation
 func (inter *Interpreter) VisitFunctionStmt(stmt statements.FunctionStatement) error {
 	// after creating the FunctionCallable,
 	// it create a new binding in the current environment and store a reference to it there.
 	// binding as *FunctionCallable because FunctionCallable implements Callable interface as pointer receiver
-	function := &FunctionCallable{Declaration: stmt, Closure: inter.environment}
-	inter.environment.Define(stmt.Name.Lexeme, function)
+	function := &FunctionCallable{Declaration: stmt, Closure: inter.environment, module: inter.module}
+	if stmt.Constant {
+		inter.environment.DefineConstant(stmt.Name.Lexeme, function)
+	} else {
+		inter.environment.Define(stmt.Name.Lexeme, function)
+	}
 	return nil
 }
 
@@ -535,7 +794,8 @@ func (inter *Interpreter) VisitClassStmt(stmt statements.ClassStatement) error {
 		if _, ok := super.(*ClassCallable); !ok {
 			return &InvalidSuperclass{
 				InterpretationError: InterpretationError{
-					msg: "Superclass must be a class",
+					token: stmt.Superclass.Token,
+					msg:   "Superclass must be a class",
 				},
 			}
 		}
@@ -556,15 +816,28 @@ func (inter *Interpreter) VisitClassStmt(stmt statements.ClassStatement) error {
 	// Each method declaration converted into a FunctionCallable object
 	// flag the initializer if exists
 	for _, method := range stmt.Methods {
-		function := &FunctionCallable{Declaration: method, Closure: inter.environment, IsInit: method.Name.Lexeme == "init"}
+		function := &FunctionCallable{Declaration: method, Closure: inter.environment, IsInit: method.Name.Lexeme == "init", module: inter.module, class: stmt.Name.Lexeme}
 		methods[method.Name.Lexeme] = function
 	}
-	var class *ClassCallable
+	getters := map[string]*FunctionCallable{}
+	for _, getter := range stmt.Getters {
+		getters[getter.Name.Lexeme] = &FunctionCallable{Declaration: getter, Closure: inter.environment, module: inter.module, class: stmt.Name.Lexeme}
+	}
+	staticMethods := map[string]*FunctionCallable{}
+	for _, method := range stmt.StaticMethods {
+		staticMethods[method.Name.Lexeme] = &FunctionCallable{Declaration: method, Closure: inter.environment, module: inter.module, class: stmt.Name.Lexeme}
+	}
+
+	class := &ClassCallable{
+		name:          stmt.Name.Lexeme,
+		methods:       methods,
+		getters:       getters,
+		staticMethods: staticMethods,
+		fields:        map[string]interface{}{},
+	}
 	super, ok := superclass.(*ClassCallable)
 	if ok {
-		class = &ClassCallable{name: stmt.Name.Lexeme, methods: methods, superclass: super}
-	} else {
-		class = &ClassCallable{name: stmt.Name.Lexeme, methods: methods}
+		class.superclass = super
 	}
 
 	// pop the super environment
@@ -572,8 +845,26 @@ func (inter *Interpreter) VisitClassStmt(stmt statements.ClassStatement) error {
 		inter.environment = inter.environment.GetEnclosing()
 	}
 
-	err := inter.environment.Assgin(stmt.Name, class)
-	return err
+	if stmt.Constant {
+		inter.environment.DefineConstant(stmt.Name.Lexeme, class)
+	} else {
+		inter.environment.Define(stmt.Name.Lexeme, class)
+	}
+
+	// static fields are initialized in order once the class is bound
+	// so their initializers can refer to the class itself
+	for _, field := range stmt.StaticFields {
+		var value interface{}
+		if field.Initializer != nil {
+			var err error
+			value, err = inter.evaluate(field.Initializer)
+			if err != nil {
+				return err
+			}
+		}
+		class.Set(field.Token, value)
+	}
+	return nil
 }
 
 func (inter *Interpreter) executeBlock(stmts []statements.Statement, innerEnv *environment.Environment) error {
@@ -600,14 +891,43 @@ func (inter *Interpreter) evaluate(expr expressions.Experssion) (interface{}, er
 	return expr.Accept(inter)
 }
 
+// evaluates the expression in the environment e.g. the default values of the parameters
+func (inter *Interpreter) evaluateIn(expr expressions.Experssion, env *environment.Environment) (interface{}, error) {
+	outerEnv := inter.environment
+	inter.environment = env
+	defer func() {
+		inter.environment = outerEnv
+	}()
+	return inter.evaluate(expr)
+}
+
 // Append new variable resolution (used by the resolver)
 func (inter *Interpreter) Resolve(expr expressions.Experssion, level int) {
-	inter.locals[expr] = &level
+	inter.module.locals[resolutionId(expr)] = level
+}
+
+// the unique id of the expressions the resolver resolves
+func resolutionId(expr expressions.Experssion) int {
+	switch expr := expr.(type) {
+	case expressions.Variable:
+		return expr.Uuid
+	case expressions.Assgin:
+		return expr.Uuid
+	case expressions.This:
+		return expr.Uuid
+	case expressions.Super:
+		return expr.Uuid
+	}
+	panic(fmt.Sprintf("can't resolve expression of type %T", expr))
 }
 
 // define what to consider true and false
 // currently only false and nil considered falsy
 func isTruthy(ref reflect.Value) bool {
+	// the zero reflect.Value is nil itself
+	if !ref.IsValid() {
+		return false
+	}
 	if ref.Kind() == reflect.Ptr && ref.IsNil() {
 		return false
 	}
@@ -619,40 +939,81 @@ func isTruthy(ref reflect.Value) bool {
 
 // Interface values are comparable. Two interface values are equal if they have identical dynamic types and equal dynamic values
 // or if both have value nil.
+// lists and maps are compared structurally.
 func isEqual(left interface{}, right interface{}) bool {
-	return left == right
+	return equal(left, right, map[[2]interface{}]bool{})
 }
 
-func reflectFloat64(ref reflect.Value, operator expressions.Token) (float64, error) {
-	err := checkNumOperands(ref, operator)
-	if err != nil {
-		return 0.0, err
+// seen holds the container pairs already being compared further up so a list or
+// map that contains itself compares equal instead of recursing forever
+func equal(left interface{}, right interface{}, seen map[[2]interface{}]bool) bool {
+	if isNumber(left) && isNumber(right) {
+		return numbersEqual(left, right)
 	}
-	return ref.Float(), nil
-}
-
-func checkNumOperands(operand reflect.Value, operator expressions.Token) error {
-	if operand.Kind() != reflect.Float64 {
-		return &ErrorOpNumMismatch{
-			InterpretationError{
-				token: operator,
-				msg:   "Operand must be a number.",
-			},
+	switch l := left.(type) {
+	case *List:
+		r, ok := right.(*List)
+		if !ok {
+			return false
+		}
+		if l == r {
+			return true
+		}
+		if l.Len() != r.Len() {
+			return false
+		}
+		pair := [2]interface{}{l, r}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for i := range l.elements {
+			if !equal(l.elements[i], r.elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Map:
+		r, ok := right.(*Map)
+		if !ok {
+			return false
 		}
+		if l == r {
+			return true
+		}
+		if l.Len() != r.Len() {
+			return false
+		}
+		pair := [2]interface{}{l, r}
+		if seen[pair] {
+			return true
+		}
+		seen[pair] = true
+		for i, key := range l.keys {
+			j, exists := r.index[key]
+			if !exists || !equal(l.values[i], r.values[j], seen) {
+				return false
+			}
+		}
+		return true
 	}
-	return nil
+	return left == right
 }
 
 // convert obj of type interface{} into its approperate string representation
 func stringify(obj interface{}) string {
+	if obj == nil {
+		return "nil"
+	}
 	refVal := reflect.ValueOf(obj)
 	if refVal.Kind() == reflect.Ptr && refVal.IsNil() {
 		return "nil"
 	}
+	if refVal.Kind() == reflect.Int64 {
+		return strconv.FormatInt(refVal.Int(), 10)
+	}
 	if refVal.Kind() == reflect.Float64 {
-		text := fmt.Sprintf("%f", refVal.Float())
-
-		return text
+		return formatFloat(refVal.Float())
 	}
 
 	if refVal.Kind() == reflect.Bool {
@@ -674,10 +1035,55 @@ func stringify(obj interface{}) string {
 }
 
 func (inter *Interpreter) lookUpVar(name expressions.Token, expr expressions.Experssion) (interface{}, error) {
-	level := inter.locals[expr]
-	if level != nil {
-		return inter.environment.GetAt(*level, name)
+	level, ok := inter.module.locals[resolutionId(expr)]
+	if ok {
+		return inter.environment.GetAt(level, name)
 	}
-	return inter.globals.Get(name)
+	if inter.dynamic {
+		return inter.environment.Get(name)
+	}
+	return inter.module.globals.Get(name)
+
+}
+
+// follows the execution of a program, e.g. the debugger.
+type Tracer interface {
+	// called before each statement executes, returning an error stops the program
+	BeforeStatement(inter *Interpreter, stmt statements.Statement) error
+	// called when a function starts running and once it returns
+	EnterFunction(function *FunctionCallable)
+	ExitFunction(function *FunctionCallable)
+}
+
+func (inter *Interpreter) SetTracer(tracer Tracer) {
+	inter.tracer = tracer
+}
+
+// sets where print statements write, the standard output by default
+func (inter *Interpreter) SetOutput(out io.Writer) {
+	inter.stdout = out
+}
+
+// the innermost scope of the code being executed
+func (inter *Interpreter) Environment() *environment.Environment {
+	return inter.environment
+}
 
+// evaluates a resolved expression in the current scope without reporting errors,
+// e.g. the interactive prompt shows the value of the expressions typed at it
+func (inter *Interpreter) Evaluate(expr expressions.Experssion) (interface{}, error) {
+	inter.unwound = nil
+	return inter.evaluate(expr)
+}
+
+// evaluates an expression the resolver didn't see (e.g. typed in the debugger)
+// in the current scope, its variables are looked up through the scope chain
+func (inter *Interpreter) EvaluateDynamic(expr expressions.Experssion) (interface{}, error) {
+	inter.dynamic = true
+	defer func() {
+		inter.dynamic = false
+		// the errors of the expression aren't reported
+		inter.unwound = nil
+	}()
+	return inter.evaluate(expr)
 }
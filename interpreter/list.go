@@ -0,0 +1,243 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+type IndexOutOfRange struct {
+	InterpretationError
+}
+
+type InvalidIndex struct {
+	InterpretationError
+}
+
+// runtime representation of list values.
+// lists are mutable and shared by reference.
+type List struct {
+	elements []interface{}
+}
+
+func NewList(elements []interface{}) *List {
+	return &List{elements: elements}
+}
+
+func (l *List) Len() int {
+	return len(l.elements)
+}
+
+func (l *List) Elements() []interface{} {
+	return l.elements
+}
+
+func (l *List) Get(index interface{}, bracket expressions.Token) (interface{}, error) {
+	i, err := toIndex(index, len(l.elements), bracket)
+	if err != nil {
+		return nil, err
+	}
+	return l.elements[i], nil
+}
+
+func (l *List) Set(index interface{}, value interface{}, bracket expressions.Token) error {
+	i, err := toIndex(index, len(l.elements), bracket)
+	if err != nil {
+		return err
+	}
+	l.elements[i] = value
+	return nil
+}
+
+// returns a new list with a copy of the elements between start and end
+func (l *List) Slice(start, end int) *List {
+	elements := make([]interface{}, end-start)
+	copy(elements, l.elements[start:end])
+	return NewList(elements)
+}
+
+func (l *List) Push(value interface{}) {
+	l.elements = append(l.elements, value)
+}
+
+// removes the last element and returns it
+func (l *List) Pop() (interface{}, bool) {
+	if len(l.elements) == 0 {
+		return nil, false
+	}
+	last := l.elements[len(l.elements)-1]
+	l.elements = l.elements[:len(l.elements)-1]
+	return last, true
+}
+
+func (l *List) String() string {
+	return l.format(map[interface{}]bool{})
+}
+
+// seen holds the containers currently being printed so a list that contains itself prints as [...]
+func (l *List) format(seen map[interface{}]bool) string {
+	if seen[l] {
+		return "[...]"
+	}
+	seen[l] = true
+	defer delete(seen, l)
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, element := range l.elements {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(reprNested(element, seen))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// like stringify but quotes strings so they can be told apart inside collections
+func repr(obj interface{}) string {
+	if s, ok := obj.(string); ok {
+		return strconv.Quote(s)
+	}
+	return stringify(obj)
+}
+
+// like repr but passes the containers being printed on to nested lists and maps
+func reprNested(obj interface{}, seen map[interface{}]bool) string {
+	switch v := obj.(type) {
+	case *List:
+		return v.format(seen)
+	case *Map:
+		return v.format(seen)
+	}
+	return repr(obj)
+}
+
+// converts an index value into a position checking it's a whole number within [0, length)
+func toIndex(index interface{}, length int, bracket expressions.Token) (int, error) {
+	i, err := toWholeNumber(index, bracket)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || i >= length {
+		return 0, &IndexOutOfRange{
+			InterpretationError: InterpretationError{
+				token: bracket,
+				msg:   fmt.Sprintf("Index %d out of range for length %d", i, length),
+			},
+		}
+	}
+	return i, nil
+}
+
+// resolves the optional bounds of a slice checking 0 <= start <= end <= length
+func toSliceBounds(start, end interface{}, length int, bracket expressions.Token) (int, int, error) {
+	from, to := 0, length
+	if start != nil {
+		i, err := toWholeNumber(start, bracket)
+		if err != nil {
+			return 0, 0, err
+		}
+		from = i
+	}
+	if end != nil {
+		i, err := toWholeNumber(end, bracket)
+		if err != nil {
+			return 0, 0, err
+		}
+		to = i
+	}
+	if from < 0 || to > length || from > to {
+		return 0, 0, &IndexOutOfRange{
+			InterpretationError: InterpretationError{
+				token: bracket,
+				msg:   fmt.Sprintf("Slice bounds [%d:%d] out of range for length %d", from, to, length),
+			},
+		}
+	}
+	return from, to, nil
+}
+
+// floats are accepted as long as they hold a whole number
+func toWholeNumber(index interface{}, bracket expressions.Token) (int, error) {
+	if i, ok := index.(int64); ok {
+		return int(i), nil
+	}
+	f, ok := index.(float64)
+	if !ok || f != math.Trunc(f) {
+		return 0, &InvalidIndex{
+			InterpretationError: InterpretationError{
+				token: bracket,
+				msg:   "Index must be a whole number",
+			},
+		}
+	}
+	return int(f), nil
+}
+
+// subscript reads are allowed on lists, maps and strings
+func getIndex(obj interface{}, index interface{}, bracket expressions.Token) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *List:
+		return obj.Get(index, bracket)
+	case *Map:
+		return obj.Get(index, bracket)
+	case string:
+		// strings are indexed by characters (runes) not bytes
+		runes := []rune(obj)
+		i, err := toIndex(index, len(runes), bracket)
+		if err != nil {
+			return nil, err
+		}
+		return string(runes[i]), nil
+	}
+	return nil, &InvalidIndex{
+		InterpretationError: InterpretationError{
+			token: bracket,
+			msg:   fmt.Sprintf("Object of type '%s' is not subscriptable", typeName(obj)),
+		},
+	}
+}
+
+// subscript writes are allowed on lists and maps, strings are immutable
+func setIndex(obj interface{}, index interface{}, value interface{}, bracket expressions.Token) error {
+	switch obj := obj.(type) {
+	case *List:
+		return obj.Set(index, value, bracket)
+	case *Map:
+		return obj.Set(index, value, bracket)
+	}
+	return &InvalidIndex{
+		InterpretationError: InterpretationError{
+			token: bracket,
+			msg:   fmt.Sprintf("Object of type '%s' does not support index assignment", typeName(obj)),
+		},
+	}
+}
+
+// start and end are nil when the bound is omitted
+func slice(obj interface{}, start interface{}, end interface{}, bracket expressions.Token) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *List:
+		from, to, err := toSliceBounds(start, end, obj.Len(), bracket)
+		if err != nil {
+			return nil, err
+		}
+		return obj.Slice(from, to), nil
+	case string:
+		runes := []rune(obj)
+		from, to, err := toSliceBounds(start, end, len(runes), bracket)
+		if err != nil {
+			return nil, err
+		}
+		return string(runes[from:to]), nil
+	}
+	return nil, &InvalidIndex{
+		InterpretationError: InterpretationError{
+			token: bracket,
+			msg:   fmt.Sprintf("Object of type '%s' can't be sliced", typeName(obj)),
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+type KeyNotFound struct {
+	InterpretationError
+}
+
+type UnhashableKey struct {
+	InterpretationError
+}
+
+// runtime representation of map values.
+// entries are iterated in insertion order, the index maps a key to its position in keys/values.
+type Map struct {
+	index  map[interface{}]int
+	keys   []interface{}
+	values []interface{}
+}
+
+func NewMap() *Map {
+	return &Map{index: map[interface{}]int{}}
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map) Keys() []interface{} {
+	return m.keys
+}
+
+func (m *Map) Values() []interface{} {
+	return m.values
+}
+
+func (m *Map) Get(key interface{}, bracket expressions.Token) (interface{}, error) {
+	err := checkHashable(key, bracket)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := m.index[mapKey(key)]
+	if !ok {
+		return nil, &KeyNotFound{
+			InterpretationError: InterpretationError{
+				token: bracket,
+				msg:   fmt.Sprintf("Key %s not found", repr(key)),
+			},
+		}
+	}
+	return m.values[i], nil
+}
+
+// sets the value of a key, new keys are appended at the end of the iteration order
+func (m *Map) Set(key interface{}, value interface{}, bracket expressions.Token) error {
+	err := checkHashable(key, bracket)
+	if err != nil {
+		return err
+	}
+	key = mapKey(key)
+	if i, ok := m.index[key]; ok {
+		m.values[i] = value
+		return nil
+	}
+	m.index[key] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+	return nil
+}
+
+func (m *Map) Has(key interface{}) bool {
+	_, ok := m.index[mapKey(key)]
+	return ok
+}
+
+// removes a key keeping the order of the remaining entries, reports whether the key existed
+func (m *Map) Delete(key interface{}) bool {
+	key = mapKey(key)
+	i, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	delete(m.index, key)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	m.values = append(m.values[:i], m.values[i+1:]...)
+	for j := i; j < len(m.keys); j++ {
+		m.index[m.keys[j]] = j
+	}
+	return true
+}
+
+func (m *Map) String() string {
+	return m.format(map[interface{}]bool{})
+}
+
+// seen holds the containers currently being printed so a map that contains itself prints as {...}
+func (m *Map) format(seen map[interface{}]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+	seen[m] = true
+	defer delete(seen, m)
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, key := range m.keys {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(repr(key))
+		sb.WriteString(": ")
+		sb.WriteString(reprNested(m.values[i], seen))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// whole floats are stored as ints so keys that compare equal (e.g. 1 and 1.0) hit the same entry
+func mapKey(key interface{}) interface{} {
+	if f, ok := key.(float64); ok && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f)
+	}
+	return key
+}
+
+// lists and maps are compared by their content so they can't be used as keys
+func checkHashable(key interface{}, bracket expressions.Token) error {
+	switch key.(type) {
+	case *List, *Map:
+		return &UnhashableKey{
+			InterpretationError: InterpretationError{
+				token: bracket,
+				msg:   fmt.Sprintf("Unhashable key of type '%s'", typeName(key)),
+			},
+		}
+	}
+	return nil
+}
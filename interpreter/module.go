@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter/environment"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+)
+
+type ImportError struct {
+	InterpretationError
+}
+
+// the runtime state owned by a single source file.
+// every module has its own top level scope and its own resolution information
+// so declarations don't leak between files.
+type Module struct {
+	Path string
+	// top level scope of the module, its enclosing scope holds the built-ins
+	globals *environment.Environment
+	// stores resolution information generated by the resolver for this module
+	locals Locals
+}
+
+// loads and executes the module for an import statement.
+// from is the path of the importing module (empty for the REPL) and path is the imported path as written.
+// the interpreter can't depend on the resolver so loading is provided from outside.
+type Importer interface {
+	Import(inter *Interpreter, from string, path string) (*Module, error)
+}
+
+// creates a module with an empty top level scope
+func (inter *Interpreter) NewModule(path string) *Module {
+	return &Module{
+		Path:    path,
+		globals: environment.New(inter.builtins),
+		locals:  Locals{},
+	}
+}
+
+// sets the path of the module being executed, relative imports are resolved against it
+func (inter *Interpreter) SetPath(path string) {
+	inter.module.Path = path
+}
+
+func (inter *Interpreter) SetImporter(importer Importer) {
+	inter.importer = importer
+}
+
+// runs fn with m as the current module, resolving and executing statements inside fn
+// targets m. the previous module and environment are restored afterwards.
+func (inter *Interpreter) InModule(m *Module, fn func() error) error {
+	outerModule := inter.module
+	outerEnv := inter.environment
+	inter.module = m
+	inter.environment = m.globals
+	defer func() {
+		inter.module = outerModule
+		inter.environment = outerEnv
+	}()
+	return fn()
+}
+
+// executes statements in the current environment without reporting errors
+func (inter *Interpreter) Execute(stmts []statements.Statement) error {
+	for _, stmt := range stmts {
+		err := inter.execute(stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// names starting with an underscore are private to their module
+func isExported(name string) bool {
+	return !strings.HasPrefix(name, "_")
+}
+
+// looks up an exported top level declaration of the module
+func (m *Module) Get(name expressions.Token) (interface{}, error) {
+	value, ok := m.globals.Lookup(name.Lexeme)
+	if !ok || !isExported(name.Lexeme) {
+		return nil, &UndefinedProperty{
+			InterpretationError: InterpretationError{
+				token: name,
+				msg:   fmt.Sprintf("Module '%s' has no exported member '%s'", m.name(), name.Lexeme),
+			},
+		}
+	}
+	return value, nil
+}
+
+// the file name without its extension
+func (m *Module) name() string {
+	base := filepath.Base(m.Path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func (m *Module) String() string {
+	return "<module " + m.name() + ">"
+}
+
+func (inter *Interpreter) VisitImportStmt(stmt statements.ImportStatement) error {
+	if inter.importer == nil {
+		return &ImportError{
+			InterpretationError: InterpretationError{
+				token: stmt.Keyword,
+				msg:   "Imports are not supported by this interpreter",
+			},
+		}
+	}
+	path := stmt.Path.Literal.Value.(string)
+	m, err := inter.importer.Import(inter, inter.module.Path, path)
+	if err != nil {
+		if lerr, ok := err.(locatable); ok {
+			lerr.locate(stmt.Keyword)
+		}
+		return err
+	}
+
+	// selective import binds each name directly
+	if len(stmt.Names) != 0 {
+		for _, name := range stmt.Names {
+			value, err := m.Get(name)
+			if err != nil {
+				return err
+			}
+			inter.environment.Define(name.Lexeme, value)
+		}
+		return nil
+	}
+	inter.environment.Define(ImportName(stmt), m)
+	return nil
+}
+
+// the name a whole module import is bound to, the alias if given otherwise the file name
+func ImportName(stmt statements.ImportStatement) string {
+	if stmt.Alias.Lexeme != "" {
+		return stmt.Alias.Lexeme
+	}
+	m := Module{Path: stmt.Path.Literal.Value.(string)}
+	return m.name()
+}
+
+func NewImportError(msg string) error {
+	return &ImportError{
+		InterpretationError: InterpretationError{
+			msg: msg,
+		},
+	}
+}
@@ -0,0 +1,261 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type InvalidArgument struct {
+	InterpretationError
+}
+
+// registers a host function in the global scope under the given name.
+// embedders use it to expose their own functionality to scripts.
+func (inter *Interpreter) DefineNative(name string, arity int, fn NativeFunc) {
+	inter.builtins.Define(name, &NativeCallable{name: name, arity: arity, fn: fn})
+}
+
+// the built-ins every interpreter starts with
+func (inter *Interpreter) defineCoreNatives() {
+	inter.DefineNative("clock", 0, nativeClock)
+	inter.DefineNative("len", 1, nativeLen)
+	inter.DefineNative("str", 1, nativeStr)
+	inter.DefineNative("num", 1, nativeNum)
+	inter.DefineNative("int", 1, nativeInt)
+	inter.DefineNative("float", 1, nativeFloat)
+	inter.DefineNative("type", 1, nativeType)
+	inter.DefineNative("input", 0, nativeInput)
+	inter.DefineNative("push", 2, nativePush)
+	inter.DefineNative("pop", 1, nativePop)
+	inter.DefineNative("keys", 1, nativeKeys)
+	inter.DefineNative("values", 1, nativeValues)
+	inter.DefineNative("has", 2, nativeHas)
+	inter.DefineNative("delete", 2, nativeDelete)
+	inter.DefineNative("stackTrace", 0, nativeStackTrace)
+}
+
+// seconds since the unix epoch
+func nativeClock(inter *Interpreter, args []interface{}) (interface{}, error) {
+	return float64(time.Now().UnixNano()) / float64(time.Second), nil
+}
+
+func nativeLen(inter *Interpreter, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case string:
+		// the number of characters (runes) not bytes
+		return int64(utf8.RuneCountInString(v)), nil
+	case *List:
+		return int64(v.Len()), nil
+	case *Map:
+		return int64(v.Len()), nil
+	}
+	return nil, &InvalidArgument{
+		InterpretationError: InterpretationError{
+			msg: fmt.Sprintf("Object of type '%s' has no length", typeName(args[0])),
+		},
+	}
+}
+
+// appends a value to the end of a list
+func nativePush(inter *Interpreter, args []interface{}) (interface{}, error) {
+	list, err := listArg("push", args[0])
+	if err != nil {
+		return nil, err
+	}
+	list.Push(args[1])
+	return nil, nil
+}
+
+// removes the last element of a list and returns it
+func nativePop(inter *Interpreter, args []interface{}) (interface{}, error) {
+	list, err := listArg("pop", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, ok := list.Pop()
+	if !ok {
+		return nil, &IndexOutOfRange{
+			InterpretationError: InterpretationError{
+				msg: "Can't pop from an empty list",
+			},
+		}
+	}
+	return value, nil
+}
+
+func listArg(name string, arg interface{}) (*List, error) {
+	list, ok := arg.(*List)
+	if !ok {
+		return nil, &InvalidArgument{
+			InterpretationError: InterpretationError{
+				msg: fmt.Sprintf("'%s' expects a list but got '%s'", name, typeName(arg)),
+			},
+		}
+	}
+	return list, nil
+}
+
+// the keys of a map as a new list in insertion order
+func nativeKeys(inter *Interpreter, args []interface{}) (interface{}, error) {
+	m, err := mapArg("keys", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewList(append([]interface{}{}, m.Keys()...)), nil
+}
+
+// the values of a map as a new list in insertion order
+func nativeValues(inter *Interpreter, args []interface{}) (interface{}, error) {
+	m, err := mapArg("values", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return NewList(append([]interface{}{}, m.Values()...)), nil
+}
+
+func nativeHas(inter *Interpreter, args []interface{}) (interface{}, error) {
+	m, err := mapArg("has", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return m.Has(args[1]), nil
+}
+
+// removes a key from a map and returns whether it was present
+func nativeDelete(inter *Interpreter, args []interface{}) (interface{}, error) {
+	m, err := mapArg("delete", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return m.Delete(args[1]), nil
+}
+
+func mapArg(name string, arg interface{}) (*Map, error) {
+	m, ok := arg.(*Map)
+	if !ok {
+		return nil, &InvalidArgument{
+			InterpretationError: InterpretationError{
+				msg: fmt.Sprintf("'%s' expects a map but got '%s'", name, typeName(arg)),
+			},
+		}
+	}
+	return m, nil
+}
+
+func nativeStr(inter *Interpreter, args []interface{}) (interface{}, error) {
+	return stringify(args[0]), nil
+}
+
+// converts a string (or a number) into a number,
+// strings without a fractional part become ints
+func nativeNum(inter *Interpreter, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case int64, float64:
+		return v, nil
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, nil
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, &InvalidArgument{
+				InterpretationError: InterpretationError{
+					msg: fmt.Sprintf("Can't convert '%s' to a number", v),
+				},
+			}
+		}
+		return value, nil
+	}
+	return nil, &InvalidArgument{
+		InterpretationError: InterpretationError{
+			msg: fmt.Sprintf("Can't convert object of type '%s' to a number", typeName(args[0])),
+		},
+	}
+}
+
+// converts a number or a numeric string to an int, floats are truncated toward zero
+func nativeInt(inter *Interpreter, args []interface{}) (interface{}, error) {
+	value, err := nativeNum(inter, args)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := value.(float64); ok {
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return nil, &IntegerOverflow{
+				InterpretationError: InterpretationError{
+					msg: fmt.Sprintf("Can't convert %s to an int", formatFloat(f)),
+				},
+			}
+		}
+		return int64(f), nil
+	}
+	return value, nil
+}
+
+// converts a number or a numeric string to a float
+func nativeFloat(inter *Interpreter, args []interface{}) (interface{}, error) {
+	value, err := nativeNum(inter, args)
+	if err != nil {
+		return nil, err
+	}
+	return toFloat(value), nil
+}
+
+func nativeType(inter *Interpreter, args []interface{}) (interface{}, error) {
+	return typeName(args[0]), nil
+}
+
+// reads a single line from the standard input without the trailing newline
+func nativeInput(inter *Interpreter, args []interface{}) (interface{}, error) {
+	if inter.stdin == nil {
+		inter.stdin = bufio.NewReader(os.Stdin)
+	}
+	line, err := inter.stdin.ReadString('\n')
+	if err != nil && line == "" {
+		// nil signals the end of the input
+		return nil, nil
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// the name of the runtime type of a value as seen by scripts
+func typeName(obj interface{}) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *Module:
+		return "module"
+	case *ClassCallable:
+		return "class"
+	case *Instance:
+		return "instance"
+	case TypeNamer:
+		return obj.TypeName()
+	case Callable:
+		return "function"
+	}
+	return "unknown"
+}
+
+// the stack trace of the stackTrace() call, the pending call site is the call of the built-in
+func nativeStackTrace(inter *Interpreter, args []interface{}) (interface{}, error) {
+	return strings.Join(StackTrace(inter.frames, inter.callSite), "\n"), nil
+}
@@ -0,0 +1,311 @@
+package interpreter
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+type IntegerOverflow struct {
+	InterpretationError
+}
+
+type DivisionByZero struct {
+	InterpretationError
+}
+
+// numbers are either int64 or float64.
+// arithmetic on two ints stays an int and fails on overflow,
+// mixing an int with a float promotes the int to a float.
+func arithmetic(operator expressions.Token, left interface{}, right interface{}) (interface{}, error) {
+	err := checkNumOperands(operator, left, right)
+	if err != nil {
+		return nil, err
+	}
+	l, lIsInt := left.(int64)
+	r, rIsInt := right.(int64)
+	if lIsInt && rIsInt {
+		return intArithmetic(operator, l, r)
+	}
+
+	lf, rf := toFloat(left), toFloat(right)
+	switch operator.Kind {
+	case scanner.PLUS:
+		return lf + rf, nil
+	case scanner.MINUS:
+		return lf - rf, nil
+	case scanner.STAR:
+		return lf * rf, nil
+	case scanner.SLASH:
+		return lf / rf, nil
+	// the remainder has the sign of the dividend like integer division truncates toward zero
+	case scanner.PERCENT:
+		return math.Mod(lf, rf), nil
+	case scanner.STAR_STAR:
+		return math.Pow(lf, rf), nil
+	}
+	return nil, &InterpretationError{token: operator}
+}
+
+// + adds numbers and concatenates strings
+func add(operator expressions.Token, left interface{}, right interface{}) (interface{}, error) {
+	if isNumber(left) && isNumber(right) {
+		return arithmetic(operator, left, right)
+	}
+	lStr, lIsStr := left.(string)
+	rStr, rIsStr := right.(string)
+	if lIsStr && rIsStr {
+		return lStr + rStr, nil
+	}
+	return nil, &ErrorOpNumMismatch{
+		InterpretationError{
+			token: operator,
+			msg:   "Operands must be two numbers or two strings",
+		},
+	}
+}
+
+func intArithmetic(operator expressions.Token, l int64, r int64) (interface{}, error) {
+	var result int64
+	overflow := false
+	switch operator.Kind {
+	case scanner.PLUS:
+		result = l + r
+		overflow = (result > l) != (r > 0)
+	case scanner.MINUS:
+		result = l - r
+		overflow = (result < l) != (r > 0)
+	case scanner.STAR:
+		result, overflow = multiply(l, r)
+	// a negative exponent gives a fraction
+	case scanner.STAR_STAR:
+		if r < 0 {
+			return math.Pow(float64(l), float64(r)), nil
+		}
+		result, overflow = power(l, r)
+	// integer division truncates toward zero
+	case scanner.SLASH:
+		if r == 0 {
+			return nil, &DivisionByZero{
+				InterpretationError{
+					token: operator,
+					msg:   "Integer division by zero.",
+				},
+			}
+		}
+		overflow = l == math.MinInt64 && r == -1
+		result = l / r
+	case scanner.PERCENT:
+		if r == 0 {
+			return nil, &DivisionByZero{
+				InterpretationError{
+					token: operator,
+					msg:   "Integer modulo by zero.",
+				},
+			}
+		}
+		result = l % r
+	default:
+		return nil, &InterpretationError{token: operator}
+	}
+	if overflow {
+		return nil, &IntegerOverflow{
+			InterpretationError{
+				token: operator,
+				msg:   "Integer overflow.",
+			},
+		}
+	}
+	return result, nil
+}
+
+// l * r, it reports whether the product overflows
+func multiply(l int64, r int64) (int64, bool) {
+	result := l * r
+	return result, l != 0 && (result/l != r || (l == -1 && r == math.MinInt64))
+}
+
+// exponentiation by squaring, it reports whether the result overflows
+func power(base int64, exp int64) (int64, bool) {
+	result := int64(1)
+	for {
+		var overflow bool
+		if exp&1 == 1 {
+			if result, overflow = multiply(result, base); overflow {
+				return 0, true
+			}
+		}
+		exp >>= 1
+		if exp == 0 {
+			return result, false
+		}
+		// the square is part of the result since a higher bit of the exponent is set
+		if base, overflow = multiply(base, base); overflow {
+			return 0, true
+		}
+	}
+}
+
+// the bitwise operators work on whole numbers and give ints.
+// shifting left drops the bits shifted out of the 64 bits
+func bitwise(operator expressions.Token, left interface{}, right interface{}) (interface{}, error) {
+	l, lIsWhole := wholeNumber(left)
+	r, rIsWhole := wholeNumber(right)
+	if !lIsWhole || !rIsWhole {
+		return nil, &ErrorOpNumMismatch{
+			InterpretationError{
+				token: operator,
+				msg:   "Operands must be whole numbers.",
+			},
+		}
+	}
+	switch operator.Kind {
+	case scanner.AMPERSAND:
+		return l & r, nil
+	case scanner.PIPE:
+		return l | r, nil
+	case scanner.CARET:
+		return l ^ r, nil
+	case scanner.LESS_LESS, scanner.GREATER_GREATER:
+		if r < 0 {
+			return nil, &ErrorOpNumMismatch{
+				InterpretationError{
+					token: operator,
+					msg:   "Shift count must not be negative.",
+				},
+			}
+		}
+		if operator.Kind == scanner.LESS_LESS {
+			return l << uint64(r), nil
+		}
+		return l >> uint64(r), nil
+	}
+	return nil, &InterpretationError{token: operator}
+}
+
+// ~x flips the bits of a whole number
+func complement(operator expressions.Token, operand interface{}) (interface{}, error) {
+	i, ok := wholeNumber(operand)
+	if !ok {
+		return nil, &ErrorOpNumMismatch{
+			InterpretationError{
+				token: operator,
+				msg:   "Operand must be a whole number.",
+			},
+		}
+	}
+	return ^i, nil
+}
+
+// ints and the floats without a fractional part that fit in an int
+func wholeNumber(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < -math.MinInt64 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// numeric comparison, ints and floats can be compared with each other
+func compare(operator expressions.Token, left interface{}, right interface{}) (bool, error) {
+	err := checkNumOperands(operator, left, right)
+	if err != nil {
+		return false, err
+	}
+	l, lIsInt := left.(int64)
+	r, rIsInt := right.(int64)
+	if lIsInt && rIsInt {
+		switch operator.Kind {
+		case scanner.GREATER:
+			return l > r, nil
+		case scanner.GREATER_EQUAL:
+			return l >= r, nil
+		case scanner.LESS:
+			return l < r, nil
+		case scanner.LESS_EQUAL:
+			return l <= r, nil
+		}
+	}
+	lf, rf := toFloat(left), toFloat(right)
+	switch operator.Kind {
+	case scanner.GREATER:
+		return lf > rf, nil
+	case scanner.GREATER_EQUAL:
+		return lf >= rf, nil
+	case scanner.LESS:
+		return lf < rf, nil
+	case scanner.LESS_EQUAL:
+		return lf <= rf, nil
+	}
+	return false, &InterpretationError{token: operator}
+}
+
+func negate(operator expressions.Token, operand interface{}) (interface{}, error) {
+	err := checkNumOperands(operator, operand)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := operand.(int64); ok {
+		if i == math.MinInt64 {
+			return nil, &IntegerOverflow{
+				InterpretationError{
+					token: operator,
+					msg:   "Integer overflow.",
+				},
+			}
+		}
+		return -i, nil
+	}
+	return -operand.(float64), nil
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// converts a number to a float, callers make sure v is a number
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+// ints and floats are equal when they hold the same numeric value
+func numbersEqual(left interface{}, right interface{}) bool {
+	l, lIsInt := left.(int64)
+	r, rIsInt := right.(int64)
+	if lIsInt && rIsInt {
+		return l == r
+	}
+	return toFloat(left) == toFloat(right)
+}
+
+func checkNumOperands(operator expressions.Token, operands ...interface{}) error {
+	for _, operand := range operands {
+		if !isNumber(operand) {
+			return &ErrorOpNumMismatch{
+				InterpretationError{
+					token: operator,
+					msg:   "Operand must be a number.",
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// floats are printed with the fewest digits that represent them exactly e.g. 1.5, 0.1
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
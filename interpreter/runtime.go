@@ -0,0 +1,92 @@
+package interpreter
+
+import (
+	"reflect"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// the operations below are shared with the other backends (e.g. the vm)
+// so values print, compare and fail the same way whichever one runs a script.
+// operator and bracket tokens are only used to locate errors and pick the operation.
+
+// implemented by runtime values defined outside this package to report their type name
+type TypeNamer interface {
+	TypeName() string
+}
+
+func Stringify(obj interface{}) string {
+	return stringify(obj)
+}
+
+func IsTruthy(obj interface{}) bool {
+	return isTruthy(reflect.ValueOf(obj))
+}
+
+func IsEqual(left interface{}, right interface{}) bool {
+	return isEqual(left, right)
+}
+
+func TypeName(obj interface{}) string {
+	return typeName(obj)
+}
+
+// the kind field of the Error instance a caught runtime error becomes
+func ErrorKind(err error) string {
+	return errorKind(err)
+}
+
+// applies the arithmetic and the bitwise operators on numbers, + also concatenates strings
+func Arithmetic(operator expressions.Token, left interface{}, right interface{}) (interface{}, error) {
+	switch operator.Kind {
+	case scanner.PLUS:
+		return add(operator, left, right)
+	case scanner.AMPERSAND, scanner.PIPE, scanner.CARET, scanner.LESS_LESS, scanner.GREATER_GREATER:
+		return bitwise(operator, left, right)
+	}
+	return arithmetic(operator, left, right)
+}
+
+func Compare(operator expressions.Token, left interface{}, right interface{}) (bool, error) {
+	return compare(operator, left, right)
+}
+
+func Negate(operator expressions.Token, operand interface{}) (interface{}, error) {
+	return negate(operator, operand)
+}
+
+func Complement(operator expressions.Token, operand interface{}) (interface{}, error) {
+	return complement(operator, operand)
+}
+
+func GetIndex(obj interface{}, index interface{}, bracket expressions.Token) (interface{}, error) {
+	return getIndex(obj, index, bracket)
+}
+
+func SetIndex(obj interface{}, index interface{}, value interface{}, bracket expressions.Token) error {
+	return setIndex(obj, index, value, bracket)
+}
+
+func Slice(obj interface{}, start interface{}, end interface{}, bracket expressions.Token) (interface{}, error) {
+	return slice(obj, start, end, bracket)
+}
+
+// reports whether a callable of the arity accepts the number of arguments, max is -1 when there's no upper bound
+func AcceptsArguments(min int, max int, argc int) bool {
+	return accepts(min, max, argc)
+}
+
+// describes the number of arguments a callable of the arity accepts e.g. "1 to 2 arguments"
+func DescribeArity(min int, max int) string {
+	return describeArity(min, max)
+}
+
+// the built-ins defined on the interpreter (core natives and the ones added with DefineNative)
+func (inter *Interpreter) Builtins() map[string]interface{} {
+	builtins := map[string]interface{}{}
+	for _, name := range inter.builtins.Names() {
+		builtins[name], _ = inter.builtins.Lookup(name)
+	}
+	return builtins
+}
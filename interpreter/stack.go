@@ -0,0 +1,118 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+// deepest call chain before a script fails with a stack overflow, shared with the vm
+// so a recursion overflows at the same depth whichever backend runs it
+const MaxFrames = 1 << 14
+
+// traces longer than this keep their innermost and outermost lines only
+const maxTraceLines = 40
+
+// raised by a call nested deeper than MaxFrames
+type StackOverflow struct {
+	InterpretationError
+}
+
+// a call running on the interpreter's call stack
+type Frame struct {
+	// the name of the called function, Class is set for methods
+	Function string
+	Class    string
+	// where the function was called from, the parenthesis of the call or the name of a getter
+	Call expressions.Token
+}
+
+// the name of the frame in the stack traces
+func (f Frame) Name() string {
+	name := f.Function
+	if name == "" {
+		name = "anonymous"
+	}
+	if f.Class != "" {
+		name = f.Class + "." + name
+	}
+	return name
+}
+
+// the frames of the running calls, the innermost is last
+func (inter *Interpreter) Stack() []Frame {
+	return append([]Frame(nil), inter.frames...)
+}
+
+// pushes the frame of a function called at the pending call site
+func (inter *Interpreter) pushFrame(f *FunctionCallable) error {
+	if len(inter.frames) == MaxFrames {
+		return &StackOverflow{InterpretationError: InterpretationError{token: inter.callSite, msg: "Stack overflow."}}
+	}
+	inter.frames = append(inter.frames, Frame{Function: f.Declaration.Name.Lexeme, Class: f.class, Call: inter.callSite})
+	inter.callSite = expressions.Token{}
+	return nil
+}
+
+func (inter *Interpreter) popFrame() {
+	inter.frames = inter.frames[:len(inter.frames)-1]
+}
+
+// keeps the stack an error unwound so it can be traced once it's uncaught.
+// the first frame the error leaves records it, the stack is still whole at that point
+func (inter *Interpreter) unwinding(err error) {
+	if inter.unwound == nil && !isControlFlow(err) {
+		inter.unwound = inter.Stack()
+	}
+}
+
+// the lines of the stack trace, the innermost call first.
+// at locates the innermost frame, the others are located by the call they made
+func StackTrace(frames []Frame, at expressions.Token) []string {
+	trace := make([]string, 0, len(frames)+1)
+	for i := len(frames) - 1; i >= 0; i-- {
+		trace = append(trace, TraceLine(frames[i].Name(), at.File, at.Line))
+		at = frames[i].Call
+	}
+	return CollapseTrace(append(trace, TraceLine("<script>", at.File, at.Line)))
+}
+
+// shortens the trace of a deep recursion: the runs of identical lines are collapsed
+// into a note, then the middle of a trace that's still too long is left out
+func CollapseTrace(trace []string) []string {
+	collapsed := []string{}
+	for i := 0; i < len(trace); {
+		j := i + 1
+		for j < len(trace) && trace[j] == trace[i] {
+			j++
+		}
+		switch repeated := j - i - 1; {
+		case repeated == 1:
+			collapsed = append(collapsed, trace[i], trace[i])
+		case repeated > 1:
+			collapsed = append(collapsed, trace[i], fmt.Sprintf("... repeated %d more times", repeated))
+		default:
+			collapsed = append(collapsed, trace[i])
+		}
+		i = j
+	}
+	if len(collapsed) <= maxTraceLines {
+		return collapsed
+	}
+	head, tail := maxTraceLines*3/4, maxTraceLines/4
+	omitted := len(collapsed) - head - tail
+	shortened := append([]string{}, collapsed[:head]...)
+	shortened = append(shortened, fmt.Sprintf("... %d more lines", omitted))
+	return append(shortened, collapsed[len(collapsed)-tail:]...)
+}
+
+// a line of a stack trace
+func TraceLine(name string, file string, line int) string {
+	switch {
+	case line == 0:
+		return "at " + name
+	case file == "":
+		return fmt.Sprintf("at %s (line %d)", name, line)
+	}
+	return fmt.Sprintf("at %s (%s:%d)", name, file, line)
+}
@@ -0,0 +1,47 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCollapseTrace(t *testing.T) {
+	repeat := func(line string, n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = line
+		}
+		return lines
+	}
+
+	recursion := append(repeat("at r (a.pl:1)", 1000), "at <script> (a.pl:2)")
+	want := []string{"at r (a.pl:1)", "... repeated 999 more times", "at <script> (a.pl:2)"}
+	if got := CollapseTrace(recursion); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// a single repeat is kept as is
+	twice := []string{"at f (a.pl:1)", "at f (a.pl:1)", "at <script> (a.pl:3)"}
+	if got := CollapseTrace(twice); !reflect.DeepEqual(got, twice) {
+		t.Errorf("got %q, want %q", got, twice)
+	}
+
+	// a mutual recursion doesn't repeat lines, the middle of the trace is left out
+	mutual := []string{}
+	for i := 0; i < 500; i++ {
+		mutual = append(mutual, "at a (a.pl:1)", "at b (a.pl:2)")
+	}
+	mutual = append(mutual, "at <script> (a.pl:3)")
+	got := CollapseTrace(mutual)
+	if len(got) != maxTraceLines+1 {
+		t.Fatalf("expected %d lines, got %d", maxTraceLines+1, len(got))
+	}
+	head := maxTraceLines * 3 / 4
+	if note := fmt.Sprintf("... %d more lines", len(mutual)-maxTraceLines); got[head] != note {
+		t.Errorf("got %q, want %q", got[head], note)
+	}
+	if got[0] != mutual[0] || got[len(got)-1] != "at <script> (a.pl:3)" {
+		t.Errorf("expected the innermost and outermost lines to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// the rule IDs, they name the issues in the output and in the suppression comments
+const (
+	UnusedVariable       = "unused-variable"
+	UnusedParameter      = "unused-parameter"
+	UnreachableCode      = "unreachable-code"
+	ShadowedVariable     = "shadowed-variable"
+	UndeclaredAssignment = "undeclared-assignment"
+	EmptyBlock           = "empty-block"
+	ConstantCondition    = "constant-condition"
+)
+
+// a comment starting with the directive suppresses the issues on its line, or on the next line
+// when it's on a line of its own. It's followed by the rules it suppresses, all of them when none is listed
+// e.g. // lint:ignore unused-variable, shadowed-variable
+const directive = "lint:ignore"
+
+// a problem found by the linter, unlike the compile errors it doesn't stop the program
+type Issue struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	// the span shown under the message
+	diagnostic reporting.Diagnostic
+}
+
+func newIssue(rule string, tok expressions.Token, msg string) Issue {
+	return Issue{
+		Rule:       rule,
+		File:       tok.File,
+		Line:       tok.Line,
+		Column:     tok.Column,
+		Message:    msg,
+		diagnostic: reporting.At(tok, "["+rule+"]", msg),
+	}
+}
+
+// the issue as a warning with the source line it points at
+func (i Issue) String() string {
+	return i.diagnostic.Warning()
+}
+
+// lints the source of the file, the issues are sorted by their position.
+// compile errors are reported like when the script runs and no issues are returned for a file that doesn't compile
+func Lint(source string, file string) []Issue {
+	s := scanner.New(source)
+	s.SetFile(file)
+	s.KeepComments()
+	tokens := s.ScanTokens()
+	if reporting.HadError() {
+		return nil
+	}
+	stmts := parser.New(tokens).Parse()
+	if reporting.HadError() {
+		return nil
+	}
+
+	inter := interpreter.New()
+	l := newLinter(inter.Builtins())
+	r := resolver.New(inter)
+	r.SetListener(l)
+	r.Resolve(stmts)
+	if reporting.HadError() {
+		return nil
+	}
+	l.finish()
+
+	suppressed := suppressions(s.Comments(), tokens)
+	issues := []Issue{}
+	for _, issue := range l.issues {
+		if rules, ok := suppressed[issue.Line]; ok && (len(rules) == 0 || rules[issue.Rule]) {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return issues
+}
+
+// the rules suppressed on each line by the directive comments, an empty set suppresses every rule
+func suppressions(comments []expressions.Token, tokens []expressions.Token) map[int]map[string]bool {
+	// the lines holding code, a comment on any other line applies to the next one
+	code := map[int]bool{}
+	for _, tok := range tokens {
+		code[tok.Line] = true
+	}
+	suppressed := map[int]map[string]bool{}
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Lexeme, "//"))
+		if !strings.HasPrefix(text, directive) {
+			continue
+		}
+		rules := map[string]bool{}
+		for _, rule := range strings.FieldsFunc(text[len(directive):], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			rules[rule] = true
+		}
+		line := comment.Line
+		if !code[line] {
+			line++
+		}
+		suppressed[line] = rules
+	}
+	return suppressed
+}
@@ -0,0 +1,141 @@
+package lint_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/lint"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+)
+
+// the exported fields of an issue, the ones printed by -format json
+type issue struct {
+	rule    string
+	line    int
+	column  int
+	message string
+}
+
+// lints the source of main.pl, the compile errors are collected instead of being logged
+func run(t *testing.T, source string) ([]lint.Issue, []reporting.Diagnostic) {
+	t.Helper()
+	var issues []lint.Issue
+	diagnostics := reporting.Collect(func() {
+		issues = lint.Lint(source, "main.pl")
+	})
+	for _, i := range issues {
+		if i.File != "main.pl" {
+			t.Errorf("issue %q is in %q, want main.pl", i.Message, i.File)
+		}
+	}
+	return issues, diagnostics
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []issue
+	}{
+		{"unused variable", "func f() {\n  let total = 1;\n}\nf();", []issue{
+			{lint.UnusedVariable, 2, 7, "'total' is declared but never used."},
+		}},
+		{"unused function and class", "func f() {\n  func g() {}\n  class C {}\n}\nf();", []issue{
+			{lint.UnusedVariable, 2, 8, "'g' is declared but never used."},
+			{lint.UnusedVariable, 3, 9, "'C' is declared but never used."},
+		}},
+		{"underscore names", "func f(_unit) {\n  let _skip = 1;\n}\nf(1);", []issue{}},
+		{"globals are used by importers", "let total = 1;", []issue{}},
+		{"unused parameter", "func area(w, h, unit) {\n  return w * h;\n}\narea(1, 2, 3);", []issue{
+			{lint.UnusedParameter, 1, 17, "Parameter 'unit' is never used."},
+		}},
+		{"unreachable code", "func f() {\n  return 1;\n  print 2;\n}\nf();", []issue{
+			{lint.UnreachableCode, 3, 0, "Unreachable code after 'return'."},
+		}},
+		{"unreachable after break", "while (1 < len(\"ab\")) {\n  break;\n  print 1;\n}", []issue{
+			{lint.UnreachableCode, 3, 0, "Unreachable code after 'break'."},
+		}},
+		{"shadowed global", "let x = 1;\nfunc f() {\n  let x = 2;\n  print x;\n}\nf();", []issue{
+			{lint.ShadowedVariable, 3, 7, "'x' shadows the global declared at line 1."},
+		}},
+		{"shadowed local", "func f() {\n  let x = 1;\n  {\n    let x = 2;\n    print x;\n  }\n  print x;\n}\nf();", []issue{
+			{lint.ShadowedVariable, 4, 9, "'x' shadows the variable declared at line 2."},
+		}},
+		{"undeclared assignment", "func f() {\n  y = 3;\n}\nf();", []issue{
+			{lint.UndeclaredAssignment, 2, 3, "Assignment to undeclared variable 'y'."},
+		}},
+		{"empty block", "if (len(\"a\") > 0) {}", []issue{
+			{lint.EmptyBlock, 1, 19, "Empty block."},
+		}},
+		{"constant condition", "if (1 < 2) print 1;\nwhile (false) print 2;\nwhile (true) break;", []issue{
+			{lint.ConstantCondition, 1, 1, "The condition is always the same."},
+			{lint.ConstantCondition, 2, 1, "The condition is always the same."},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues, diagnostics := run(t, test.source)
+			if len(diagnostics) != 0 {
+				t.Fatalf("unexpected compile error: %s", diagnostics[0].Msg)
+			}
+			got := []issue{}
+			for _, i := range issues {
+				got = append(got, issue{i.Rule, i.Line, i.Column, i.Message})
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"same line", "func f() {\n  let a = 1; // lint:ignore\n}\nf();", []string{}},
+		{"next line", "func f() {\n  // lint:ignore unused-variable\n  let a = 1;\n}\nf();", []string{}},
+		{"only the next line", "func f() {\n  // lint:ignore\n  let a = 1;\n  let b = 1;\n}\nf();", []string{lint.UnusedVariable}},
+		{"other rule", "func f() {\n  let a = 1; // lint:ignore empty-block\n}\nf();", []string{lint.UnusedVariable}},
+		{"listed rules", "func f() {\n  if (true) { let a = 1; } // lint:ignore empty-block, unused-variable\n}\nf();", []string{lint.ConstantCondition}},
+		{"other comments", "func f() {\n  let a = 1; // keep lint:ignore\n}\nf();", []string{lint.UnusedVariable}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues, _ := run(t, test.source)
+			got := []string{}
+			for _, i := range issues {
+				got = append(got, i.Rule)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// a file that doesn't compile has its errors reported and no issues
+func TestCompileError(t *testing.T) {
+	issues, diagnostics := run(t, "func f() {\n  let a = 1;\n  let = 2;\n}")
+	if len(issues) != 0 {
+		t.Errorf("got issues %v, want none", issues)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 3 {
+		t.Errorf("got %+v, want one error at line 3", diagnostics)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	issues, _ := run(t, "func area(w, h, unit) {\n  return w * h;\n}\narea(1, 2, 3);")
+	got, err := json.Marshal(issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"rule":"unused-parameter","file":"main.pl","line":1,"column":17,"message":"Parameter 'unit' is never used."}]`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
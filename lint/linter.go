@@ -0,0 +1,217 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+)
+
+// a name declared in a local scope
+type binding struct {
+	name expressions.Token
+	// the rule reporting the binding when it's never read, empty when it isn't checked
+	rule string
+	used bool
+}
+
+type scope struct {
+	// the scope of the parameters and the body of a function
+	function bool
+	bindings []*binding
+	names    map[string]*binding
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+func positionOf(tok expressions.Token) position {
+	return position{line: tok.Line, column: tok.Column}
+}
+
+// follows the resolver's walk, implements resolver.Listener and resolver.Inspector
+type linter struct {
+	scopes []*scope
+	// the local bindings by the position of their names
+	bindings map[position]*binding
+	// the names declared at the top level
+	globals  map[string]expressions.Token
+	builtins map[string]interface{}
+	// the assignments to names that aren't local, globals may be declared after them
+	unresolved []expressions.Token
+	issues     []Issue
+}
+
+func newLinter(builtins map[string]interface{}) *linter {
+	return &linter{
+		bindings: map[position]*binding{},
+		globals:  map[string]expressions.Token{},
+		builtins: builtins,
+	}
+}
+
+func (l *linter) report(rule string, tok expressions.Token, msg string) {
+	l.issues = append(l.issues, newIssue(rule, tok, msg))
+}
+
+func (l *linter) Declare(name expressions.Token, stmt statements.Statement) {
+	if len(l.scopes) == 0 {
+		if _, ok := l.globals[name.Lexeme]; !ok {
+			l.globals[name.Lexeme] = name
+		}
+		return
+	}
+	l.shadowing(name)
+
+	current := l.scopes[len(l.scopes)-1]
+	b := &binding{name: name}
+	switch stmt.(type) {
+	case statements.VarDecStatement, statements.FunctionStatement, statements.ClassStatement:
+		b.rule = UnusedVariable
+	case nil:
+		// catch clauses need a name so their variables aren't checked
+		if current.function {
+			b.rule = UnusedParameter
+		}
+	}
+	// names starting with an underscore are unused on purpose
+	if strings.HasPrefix(name.Lexeme, "_") {
+		b.rule = ""
+	}
+	current.bindings = append(current.bindings, b)
+	current.names[name.Lexeme] = b
+	l.bindings[positionOf(name)] = b
+}
+
+// reports the name if an enclosing scope or the top level declares it
+func (l *linter) shadowing(name expressions.Token) {
+	for i := len(l.scopes) - 2; i >= 0; i-- {
+		if outer, ok := l.scopes[i].names[name.Lexeme]; ok {
+			l.report(ShadowedVariable, name, fmt.Sprintf("'%s' shadows the variable declared at line %d.", name.Lexeme, outer.name.Line))
+			return
+		}
+	}
+	if global, ok := l.globals[name.Lexeme]; ok {
+		l.report(ShadowedVariable, name, fmt.Sprintf("'%s' shadows the global declared at line %d.", name.Lexeme, global.Line))
+	}
+}
+
+func (l *linter) Reference(name expressions.Token, declaration expressions.Token, local bool) {
+	if b, ok := l.bindings[positionOf(declaration)]; ok && local {
+		b.used = true
+	}
+}
+
+// assigning a variable isn't reading it
+func (l *linter) Assign(name expressions.Token, declaration expressions.Token, local bool) {
+	if !local {
+		l.unresolved = append(l.unresolved, name)
+	}
+}
+
+func (l *linter) BeginScope(function bool) {
+	l.scopes = append(l.scopes, &scope{function: function, names: map[string]*binding{}})
+}
+
+func (l *linter) EndScope() {
+	current := l.scopes[len(l.scopes)-1]
+	l.scopes = l.scopes[:len(l.scopes)-1]
+	for _, b := range current.bindings {
+		switch {
+		case b.used || b.rule == "":
+		case b.rule == UnusedParameter:
+			l.report(b.rule, b.name, fmt.Sprintf("Parameter '%s' is never used.", b.name.Lexeme))
+		default:
+			l.report(b.rule, b.name, fmt.Sprintf("'%s' is declared but never used.", b.name.Lexeme))
+		}
+	}
+}
+
+// checks the statements of the list, the lists nested in them are checked when the resolver reaches them
+func (l *linter) Statements(stmts []statements.Statement) {
+	reachable := true
+	for i, stmt := range stmts {
+		l.inspect(stmt)
+		if keyword, ok := terminator(stmt); ok && reachable && i+1 < len(stmts) {
+			// the statements after it are reported once
+			reachable = false
+			l.report(UnreachableCode, expressions.Token{File: keyword.File, Line: stmts[i+1].Line()}, fmt.Sprintf("Unreachable code after '%s'.", keyword.Lexeme))
+		}
+	}
+}
+
+// the keyword of the statements that never complete normally
+func terminator(stmt statements.Statement) (expressions.Token, bool) {
+	switch stmt := stmt.(type) {
+	case statements.ReturnStatement:
+		return stmt.Keyword, true
+	case statements.BreakStatement:
+		return stmt.Keyword, true
+	case statements.ContinueStatement:
+		return stmt.Keyword, true
+	case statements.ThrowStatement:
+		return stmt.Keyword, true
+	}
+	return expressions.Token{}, false
+}
+
+// checks the blocks and the conditions of the statement and of the branches that aren't blocks
+func (l *linter) inspect(stmt statements.Statement) {
+	switch stmt := stmt.(type) {
+	case statements.BlockStatement:
+		if len(stmt.Statements) == 0 {
+			l.report(EmptyBlock, stmt.Brace, "Empty block.")
+		}
+	case statements.IfStatement:
+		if constant(stmt.Condition) {
+			l.report(ConstantCondition, stmt.Keyword, "The condition is always the same.")
+		}
+		l.inspect(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			l.inspect(stmt.ElseBranch)
+		}
+	case statements.WhileStatement:
+		// while (true) and for (;;) are loops exited by a break or a return
+		if literal, ok := stmt.Condition.(expressions.Literal); !(ok && literal.Value == true) && constant(stmt.Condition) {
+			l.report(ConstantCondition, stmt.Keyword, "The condition is always the same.")
+		}
+		l.inspect(stmt.Body)
+	}
+}
+
+// reports whether the expression is made of literals only
+func constant(expr expressions.Experssion) bool {
+	switch expr := expr.(type) {
+	case expressions.Literal:
+		return true
+	case expressions.Grouping:
+		return constant(expr.Expr)
+	case expressions.Unary:
+		return constant(expr.Right)
+	case expressions.Binary:
+		return constant(expr.Left) && constant(expr.Right)
+	case expressions.Logical:
+		return constant(expr.Left) && constant(expr.Right)
+	case expressions.Coalesce:
+		return constant(expr.Left) && constant(expr.Right)
+	case expressions.Conditional:
+		return constant(expr.Condition) && constant(expr.Then) && constant(expr.Else)
+	}
+	return false
+}
+
+// reports the assignments to names no one declares, they fail when they run
+func (l *linter) finish() {
+	for _, name := range l.unresolved {
+		if _, ok := l.globals[name.Lexeme]; ok {
+			continue
+		}
+		if _, ok := l.builtins[name.Lexeme]; ok {
+			continue
+		}
+		l.report(UndeclaredAssignment, name, fmt.Sprintf("Assignment to undeclared variable '%s'.", name.Lexeme))
+	}
+}
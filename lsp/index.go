@@ -0,0 +1,441 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// 1-based position in the source as the scanner reports it, the column is in bytes
+type pos struct {
+	line   int
+	column int
+}
+
+func tokenPos(tok expressions.Token) pos {
+	return pos{line: tok.Line, column: tok.Column}
+}
+
+// the position right after the token
+func tokenEnd(tok expressions.Token) pos {
+	return pos{line: tok.Line, column: tok.Column + len(tok.Lexeme)}
+}
+
+func (p pos) before(other pos) bool {
+	return p.line < other.line || (p.line == other.line && p.column < other.column)
+}
+
+// region of the source, both ends included
+type span struct {
+	start pos
+	end   pos
+}
+
+func (s span) contains(p pos) bool {
+	return !p.before(s.start) && !s.end.before(p)
+}
+
+// a name bound by a declaration, a parameter or a catch clause
+type declaration struct {
+	name expressions.Token
+	// nil for parameters and catch variables
+	stmt statements.Statement
+	// where the name is visible
+	scope span
+	// declared in the top level scope
+	global bool
+}
+
+// functions and classes can be used before their declaration by the functions declared before them
+func (d *declaration) hoisted() bool {
+	switch d.stmt.(type) {
+	case statements.FunctionStatement, statements.ClassStatement:
+		return d.global
+	}
+	return false
+}
+
+func (d *declaration) visibleAt(p pos) bool {
+	return d.scope.contains(p) && (d.hoisted() || !p.before(tokenPos(d.name)))
+}
+
+// synthesized names (e.g. the name of an import without alias) are not in the source
+func (d *declaration) located() bool {
+	return d.name.Column != 0
+}
+
+type reference struct {
+	name expressions.Token
+	// nil for built-ins and undefined globals
+	declaration *declaration
+}
+
+// the names of a document linked to their declarations.
+// built by listening to the resolver which knows the scope each variable resolves to.
+// implements resolver.Listener
+type index struct {
+	lines  []string
+	tokens []expressions.Token
+	// index of each token in tokens by its position
+	positions map[pos]int
+	// index of the closing brace matching each opening brace
+	braces       map[int]int
+	declarations []*declaration
+	// declarations by the position of their name
+	declared   map[pos]*declaration
+	references []*reference
+	// references by the position of their name
+	referenced map[pos]*reference
+	// references to globals are linked once all the declarations are known
+	// since functions can use the globals declared after them
+	unresolved []*reference
+}
+
+// scans, parses and resolves the source. The index is nil if the source has syntax errors
+func analyze(source string) (*index, []reporting.Diagnostic) {
+	var tokens []expressions.Token
+	var stmts []statements.Statement
+	diagnostics := reporting.Collect(func() {
+		tokens = scanner.New(source).ScanTokens()
+		stmts = parser.New(tokens).Parse()
+	})
+	// the resolver can't walk a partial syntax tree
+	if len(diagnostics) != 0 {
+		return nil, diagnostics
+	}
+
+	idx := &index{
+		lines:      strings.Split(source, "\n"),
+		tokens:     tokens,
+		positions:  map[pos]int{},
+		braces:     map[int]int{},
+		declared:   map[pos]*declaration{},
+		referenced: map[pos]*reference{},
+	}
+	opened := []int{}
+	for i, tok := range tokens {
+		idx.positions[tokenPos(tok)] = i
+		switch tok.Kind {
+		case scanner.LEFT_BRACE:
+			opened = append(opened, i)
+		case scanner.RIGHT_BRACE:
+			if len(opened) != 0 {
+				idx.braces[opened[len(opened)-1]] = i
+				opened = opened[:len(opened)-1]
+			}
+		}
+	}
+
+	diagnostics = reporting.Collect(func() {
+		r := resolver.New(interpreter.New())
+		r.SetListener(idx)
+		r.Resolve(stmts)
+	})
+	idx.link()
+	return idx, diagnostics
+}
+
+func (idx *index) Declare(name expressions.Token, stmt statements.Statement) {
+	decl := &declaration{name: name, stmt: stmt}
+	switch {
+	case name.Column == 0:
+		decl.scope, decl.global = idx.file(), true
+	case stmt == nil:
+		decl.scope = idx.followingScope(name)
+	default:
+		decl.scope, decl.global = idx.enclosingScope(name)
+	}
+	idx.declarations = append(idx.declarations, decl)
+	if decl.located() {
+		idx.declared[tokenPos(name)] = decl
+	}
+}
+
+func (idx *index) Reference(name expressions.Token, declaration expressions.Token, local bool) {
+	ref := &reference{name: name}
+	if local {
+		ref.declaration = idx.declared[tokenPos(declaration)]
+	} else {
+		idx.unresolved = append(idx.unresolved, ref)
+	}
+	idx.references = append(idx.references, ref)
+	idx.referenced[tokenPos(name)] = ref
+}
+
+// links the references to globals with the first global declaration of their name
+func (idx *index) link() {
+	globals := map[string]*declaration{}
+	for _, decl := range idx.declarations {
+		if _, ok := globals[decl.name.Lexeme]; decl.global && !ok {
+			globals[decl.name.Lexeme] = decl
+		}
+	}
+	for _, ref := range idx.unresolved {
+		ref.declaration = globals[ref.name.Lexeme]
+	}
+	idx.unresolved = nil
+}
+
+// the whole source
+func (idx *index) file() span {
+	return span{start: pos{line: 1, column: 1}, end: tokenPos(idx.tokens[len(idx.tokens)-1])}
+}
+
+// the innermost braces around the token, the whole source if there are none
+func (idx *index) enclosingScope(tok expressions.Token) (span, bool) {
+	i, ok := idx.positions[tokenPos(tok)]
+	if !ok {
+		return idx.file(), true
+	}
+	open := -1
+	for o, c := range idx.braces {
+		if o < i && i < c && o > open {
+			open = o
+		}
+	}
+	if open == -1 {
+		return idx.file(), true
+	}
+	return span{start: tokenPos(idx.tokens[open]), end: tokenPos(idx.tokens[idx.braces[open]])}, false
+}
+
+// parameters and catch variables are visible in the block that follows them,
+// parameters of arrow functions until the end of the enclosing block
+func (idx *index) followingScope(tok expressions.Token) span {
+	i, ok := idx.positions[tokenPos(tok)]
+	if !ok {
+		return idx.file()
+	}
+	for j := i + 1; j < len(idx.tokens); j++ {
+		switch idx.tokens[j].Kind {
+		case scanner.LEFT_BRACE:
+			return span{start: tokenPos(idx.tokens[j]), end: tokenPos(idx.tokens[idx.braces[j]])}
+		case scanner.ARROW:
+			enclosing, _ := idx.enclosingScope(tok)
+			return span{start: tokenPos(idx.tokens[j]), end: enclosing.end}
+		}
+	}
+	enclosing, _ := idx.enclosingScope(tok)
+	return enclosing
+}
+
+// the closing brace of the first block after the token, used as the end of function and class declarations
+func (idx *index) blockEnd(tok expressions.Token) pos {
+	i, ok := idx.positions[tokenPos(tok)]
+	if !ok {
+		return tokenEnd(tok)
+	}
+	for j := i + 1; j < len(idx.tokens); j++ {
+		if idx.tokens[j].Kind == scanner.LEFT_BRACE {
+			return tokenEnd(idx.tokens[idx.braces[j]])
+		}
+	}
+	return tokenEnd(tok)
+}
+
+// the name under the position and its declaration, which is nil for built-ins and undefined globals
+func (idx *index) nameAt(p pos) (expressions.Token, *declaration, bool) {
+	for _, decl := range idx.declarations {
+		if decl.located() && covers(decl.name, p) {
+			return decl.name, decl, true
+		}
+	}
+	for _, ref := range idx.references {
+		if covers(ref.name, p) {
+			return ref.name, ref.declaration, true
+		}
+	}
+	return expressions.Token{}, nil, false
+}
+
+// the cursor can be right after the name
+func covers(tok expressions.Token, p pos) bool {
+	return tok.Line == p.line && tok.Column <= p.column && p.column <= tok.Column+len(tok.Lexeme)
+}
+
+func (idx *index) referencesTo(decl *declaration) []*reference {
+	refs := []*reference{}
+	for _, ref := range idx.references {
+		if ref.declaration == decl {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// the declarations visible at the position, inner declarations shadow the outer ones
+func (idx *index) visibleAt(p pos) map[string]*declaration {
+	visible := map[string]*declaration{}
+	for _, decl := range idx.declarations {
+		if !decl.visibleAt(p) {
+			continue
+		}
+		if shadowed, ok := visible[decl.name.Lexeme]; ok && decl.scope.start.before(shadowed.scope.start) {
+			continue
+		}
+		visible[decl.name.Lexeme] = decl
+	}
+	return visible
+}
+
+// the declaration of a class's superclass
+func (idx *index) superclass(class statements.ClassStatement) *declaration {
+	if class.Superclass.Token.Lexeme == "" {
+		return nil
+	}
+	if ref, ok := idx.referenced[tokenPos(class.Superclass.Token)]; ok {
+		return ref.declaration
+	}
+	return nil
+}
+
+// the arguments a class takes, the ones of its initializer which can be inherited
+func (idx *index) classArity(class statements.ClassStatement) (int, int) {
+	// guards against classes extending each other
+	seen := map[string]bool{}
+	for !seen[class.Name.Lexeme] {
+		seen[class.Name.Lexeme] = true
+		for _, method := range class.Methods {
+			if method.Name.Lexeme == "init" {
+				return method.Arity()
+			}
+		}
+		super := idx.superclass(class)
+		if super == nil {
+			return 0, 0
+		}
+		superclass, ok := super.stmt.(statements.ClassStatement)
+		if !ok {
+			return 0, 0
+		}
+		class = superclass
+	}
+	return 0, 0
+}
+
+// the text shown when hovering the declaration
+func (idx *index) describe(decl *declaration) string {
+	name := decl.name.Lexeme
+	switch stmt := decl.stmt.(type) {
+	case statements.FunctionStatement:
+		min, max := stmt.Arity()
+		return describeFunction(signature(name, stmt), min, max)
+	case statements.ClassStatement:
+		header := "class " + name
+		if stmt.Superclass.Token.Lexeme != "" {
+			header += " extends " + stmt.Superclass.Token.Lexeme
+		}
+		min, max := idx.classArity(stmt)
+		return describeFunction(header, min, max)
+	case statements.VarDecStatement:
+		keyword := "let "
+		if stmt.Constant {
+			keyword = "const "
+		}
+		// variables holding a function literal are described as the function
+		if lambda, ok := stmt.Initializer.(expressions.Lambda); ok {
+			function := lambda.Function.(statements.FunctionStatement)
+			min, max := function.Arity()
+			return describeFunction(keyword+name+" = "+signature("", function), min, max)
+		}
+		return code(keyword + name)
+	case statements.ImportStatement:
+		return code(fmt.Sprintf("import %q", stmt.Path.Literal.Value))
+	}
+	return code("parameter " + name)
+}
+
+// the text shown when hovering a built-in
+func describeBuiltin(name string, value interface{}) string {
+	if callable, ok := value.(interpreter.Callable); ok {
+		min, max := callable.Arity()
+		return describeFunction("built-in func "+name, min, max)
+	}
+	return code("built-in " + name)
+}
+
+func signature(name string, function statements.FunctionStatement) string {
+	params := make([]string, 0, len(function.Args))
+	for _, arg := range function.Args {
+		params = append(params, arg.Lexeme)
+	}
+	if function.Variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	if name == "" {
+		return fmt.Sprintf("func(%s)", strings.Join(params, ", "))
+	}
+	return fmt.Sprintf("func %s(%s)", name, strings.Join(params, ", "))
+}
+
+func describeFunction(header string, min int, max int) string {
+	return fmt.Sprintf("%s\ntakes %s", code(header), interpreter.DescribeArity(min, max))
+}
+
+func code(s string) string {
+	return "```prolang\n" + s + "\n```"
+}
+
+// converts a protocol position into a source position
+func (idx *index) pos(p Position) pos {
+	line := p.Line + 1
+	return pos{line: line, column: column(idx.line(line), p.Character)}
+}
+
+// converts a source position into a protocol position
+func (idx *index) position(p pos) Position {
+	return Position{Line: p.line - 1, Character: character(idx.line(p.line), p.column)}
+}
+
+func (idx *index) line(n int) string {
+	if n < 1 || n > len(idx.lines) {
+		return ""
+	}
+	return idx.lines[n-1]
+}
+
+func (idx *index) tokenRange(tok expressions.Token) Range {
+	return Range{Start: idx.position(tokenPos(tok)), End: idx.position(tokenEnd(tok))}
+}
+
+// symbol spanning from the name to the given end
+func (idx *index) symbol(name expressions.Token, kind int, end pos) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           name.Lexeme,
+		Kind:           kind,
+		Range:          Range{Start: idx.position(tokenPos(name)), End: idx.position(end)},
+		SelectionRange: idx.tokenRange(name),
+	}
+}
+
+// the UTF-16 offset of the 1-based byte column in the line
+func character(line string, column int) int {
+	units := 0
+	for i, r := range line {
+		if i >= column-1 {
+			break
+		}
+		units += utf16.RuneLen(r)
+	}
+	return units
+}
+
+// the 1-based byte column of the UTF-16 offset in the line
+func column(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i + 1
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line) + 1
+}
@@ -0,0 +1,430 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// returned by Serve when the client exits without asking the server to shut down first
+var ErrorExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+// the largest message body the server accepts
+const MAX_CONTENT_LENGTH = 64 << 20
+
+// text synchronization kinds
+const FULL_SYNC = 1
+
+// an open document
+type document struct {
+	// nil while the document has syntax errors
+	index *index
+	// the index of the last version without syntax errors.
+	// completion falls back to it while the user is in the middle of typing a statement
+	lastIndex *index
+}
+
+// language server speaking the protocol over a pair of streams (stdio for editors, pipes for tests).
+// requests are handled one at a time in the order they are received
+type Server struct {
+	in          *bufio.Reader
+	out         io.Writer
+	documents   map[string]*document
+	builtins    map[string]interface{}
+	initialized bool
+	shutdown    bool
+}
+
+func New(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: map[string]*document{},
+		builtins:  interpreter.New().Builtins(),
+	}
+}
+
+// handles messages until the client sends exit or closes the input
+func (s *Server) Serve() error {
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{Code: PARSE_ERROR, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrorExitWithoutShutdown
+			}
+			return nil
+		}
+		result, rerr := s.handle(req)
+		// notifications don't get a response
+		if req.ID != nil {
+			s.reply(req.ID, result, rerr)
+		}
+	}
+}
+
+// reads the body of the next message
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	if length < 0 || length > MAX_CONTENT_LENGTH {
+		return nil, fmt.Errorf("invalid Content-Length header: %d is out of range", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(msg interface{}) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) {
+	if rerr != nil {
+		result = nil
+	} else if result == nil {
+		result = json.RawMessage("null")
+	}
+	s.write(response{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req request) (interface{}, *responseError) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &responseError{Code: SERVER_NOT_INITIALIZED, Message: "server not initialized"}
+	}
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       FULL_SYNC,
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]interface{}{},
+			},
+			"serverInfo": map[string]interface{}{"name": "prolang"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		if len(params.ContentChanges) != 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		delete(s.documents, params.TextDocument.URI)
+		// clear the diagnostics of the closed document
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		return nil, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.definition(params), nil
+	case "textDocument/references":
+		var params ReferenceParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.references(params), nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.hover(params), nil
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.documentSymbols(params), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if rerr := decode(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		return s.completion(params), nil
+	}
+	// notifications the server doesn't handle are ignored
+	if req.ID == nil {
+		return nil, nil
+	}
+	return nil, &responseError{Code: METHOD_NOT_FOUND, Message: "method not found: " + req.Method}
+}
+
+func decode(params json.RawMessage, v interface{}) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: INVALID_PARAMS, Message: err.Error()}
+	}
+	return nil
+}
+
+// analyzes the new text of the document and publishes its diagnostics
+func (s *Server) update(uri string, text string) {
+	doc, ok := s.documents[uri]
+	if !ok {
+		doc = &document{}
+		s.documents[uri] = doc
+	}
+	idx, errs := analyze(text)
+	doc.index = idx
+	if idx != nil {
+		doc.lastIndex = idx
+	}
+
+	lines := strings.Split(text, "\n")
+	diagnostics := make([]Diagnostic, 0, len(errs))
+	for _, e := range errs {
+		diagnostics = append(diagnostics, diagnostic(lines, e))
+	}
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// errors spanning a token mark it, the whole line is marked for the errors only known by their line
+func diagnostic(lines []string, e reporting.Diagnostic) Diagnostic {
+	line := e.Line
+	if line > len(lines) {
+		line = len(lines)
+	}
+	if line < 1 {
+		line = 1
+	}
+	text := lines[line-1]
+	start, end := 1, len(text)+1
+	if e.Column != 0 && line == e.Line {
+		start, end = e.Column, e.Column+e.Length
+		// at least a character is marked, e.g. for errors at the end of the input
+		if e.Length == 0 {
+			end++
+		}
+	}
+	return Diagnostic{
+		Range: Range{
+			Start: Position{Line: line - 1, Character: character(text, start)},
+			End:   Position{Line: line - 1, Character: character(text, end)},
+		},
+		Severity: SEVERITY_ERROR,
+		Source:   "prolang",
+		Message:  strings.TrimSpace(e.Where + " " + strings.TrimSpace(e.Msg)),
+	}
+}
+
+// the index of the document and the position in it, false if the document isn't open or has syntax errors
+func (s *Server) locate(params TextDocumentPositionParams) (*index, pos, bool) {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok || doc.index == nil {
+		return nil, pos{}, false
+	}
+	return doc.index, doc.index.pos(params.Position), true
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) *Location {
+	idx, p, ok := s.locate(params)
+	if !ok {
+		return nil
+	}
+	_, decl, ok := idx.nameAt(p)
+	if !ok || decl == nil || !decl.located() {
+		return nil
+	}
+	return &Location{URI: params.TextDocument.URI, Range: idx.tokenRange(decl.name)}
+}
+
+func (s *Server) references(params ReferenceParams) []Location {
+	locations := []Location{}
+	idx, p, ok := s.locate(params.TextDocumentPositionParams)
+	if !ok {
+		return locations
+	}
+	_, decl, ok := idx.nameAt(p)
+	if !ok || decl == nil {
+		return locations
+	}
+	uri := params.TextDocument.URI
+	if params.Context.IncludeDeclaration && decl.located() {
+		locations = append(locations, Location{URI: uri, Range: idx.tokenRange(decl.name)})
+	}
+	for _, ref := range idx.referencesTo(decl) {
+		locations = append(locations, Location{URI: uri, Range: idx.tokenRange(ref.name)})
+	}
+	return locations
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	idx, p, ok := s.locate(params)
+	if !ok {
+		return nil
+	}
+	name, decl, ok := idx.nameAt(p)
+	if !ok {
+		return nil
+	}
+	var text string
+	if decl != nil {
+		text = idx.describe(decl)
+	} else if builtin, ok := s.builtins[name.Lexeme]; ok {
+		text = describeBuiltin(name.Lexeme, builtin)
+	} else {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: idx.tokenRange(name)}
+}
+
+// classes with their members, functions and global variables
+func (s *Server) documentSymbols(params DocumentSymbolParams) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok || doc.index == nil {
+		return symbols
+	}
+	idx := doc.index
+	for _, decl := range idx.declarations {
+		switch stmt := decl.stmt.(type) {
+		case statements.ClassStatement:
+			class := idx.symbol(stmt.Name, SYMBOL_CLASS, idx.blockEnd(stmt.Name))
+			class.Children = []DocumentSymbol{}
+			for _, field := range stmt.StaticFields {
+				member := idx.symbol(field.Token, SYMBOL_FIELD, tokenEnd(field.Token))
+				member.Detail = "static"
+				class.Children = append(class.Children, member)
+			}
+			for _, method := range stmt.StaticMethods {
+				member := idx.symbol(method.Name, SYMBOL_METHOD, idx.blockEnd(method.Name))
+				member.Detail = "static " + signature(method.Name.Lexeme, method)
+				class.Children = append(class.Children, member)
+			}
+			for _, method := range stmt.Methods {
+				member := idx.symbol(method.Name, SYMBOL_METHOD, idx.blockEnd(method.Name))
+				member.Detail = signature(method.Name.Lexeme, method)
+				class.Children = append(class.Children, member)
+			}
+			for _, getter := range stmt.Getters {
+				class.Children = append(class.Children, idx.symbol(getter.Name, SYMBOL_PROPERTY, idx.blockEnd(getter.Name)))
+			}
+			// members in source order
+			sort.SliceStable(class.Children, func(i, j int) bool {
+				return class.Children[i].SelectionRange.Start.Line < class.Children[j].SelectionRange.Start.Line
+			})
+			symbols = append(symbols, class)
+		case statements.FunctionStatement:
+			symbol := idx.symbol(stmt.Name, SYMBOL_FUNCTION, idx.blockEnd(stmt.Name))
+			symbol.Detail = signature(stmt.Name.Lexeme, stmt)
+			symbols = append(symbols, symbol)
+		case statements.VarDecStatement:
+			if decl.global {
+				symbols = append(symbols, idx.symbol(stmt.Token, SYMBOL_VARIABLE, tokenEnd(stmt.Token)))
+			}
+		}
+	}
+	return symbols
+}
+
+// names in scope at the position, keywords and built-ins
+func (s *Server) completion(params TextDocumentPositionParams) CompletionList {
+	items := []CompletionItem{}
+	if doc, ok := s.documents[params.TextDocument.URI]; ok && doc.lastIndex != nil {
+		idx := doc.lastIndex
+		for name, decl := range idx.visibleAt(idx.pos(params.Position)) {
+			items = append(items, CompletionItem{Label: name, Kind: completionKind(decl), Detail: detail(decl)})
+		}
+	}
+	for name, builtin := range s.builtins {
+		item := CompletionItem{Label: name, Kind: COMPLETION_VARIABLE, Detail: "built-in"}
+		if _, ok := builtin.(interpreter.Callable); ok {
+			item.Kind = COMPLETION_FUNCTION
+		}
+		items = append(items, item)
+	}
+	for _, keyword := range scanner.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: COMPLETION_KEYWORD})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return CompletionList{Items: items}
+}
+
+func completionKind(decl *declaration) int {
+	switch decl.stmt.(type) {
+	case statements.FunctionStatement:
+		return COMPLETION_FUNCTION
+	case statements.ClassStatement:
+		return COMPLETION_CLASS
+	case statements.ImportStatement:
+		return COMPLETION_MODULE
+	}
+	return COMPLETION_VARIABLE
+}
+
+func detail(decl *declaration) string {
+	switch stmt := decl.stmt.(type) {
+	case statements.FunctionStatement:
+		return signature(decl.name.Lexeme, stmt)
+	case statements.ClassStatement:
+		return "class"
+	case statements.ImportStatement:
+		return "module"
+	case nil:
+		return "parameter"
+	}
+	return "variable"
+}
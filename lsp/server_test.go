@@ -0,0 +1,252 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// drives a server through a pair of in-memory pipes
+type client struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	nextID int
+	done   chan error
+}
+
+func start(t *testing.T) *client {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &client{t: t, in: inWriter, out: bufio.NewReader(outReader), done: make(chan error, 1)}
+	go func() {
+		err := New(inReader, outWriter).Serve()
+		outWriter.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *client) send(msg map[string]interface{}) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.sendRaw(string(body))
+}
+
+func (c *client) sendRaw(body string) {
+	c.t.Helper()
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// reads the next message the server wrote
+func (c *client) receive() map[string]json.RawMessage {
+	c.t.Helper()
+	header, err := textproto.NewReader(c.out).ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.out, body); err != nil {
+		c.t.Fatal(err)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// sends a request and returns its response
+func (c *client) request(method string, params interface{}) map[string]json.RawMessage {
+	c.t.Helper()
+	c.nextID++
+	c.send(map[string]interface{}{"id": c.nextID, "method": method, "params": params})
+	return c.receive()
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"method": method, "params": params})
+}
+
+func (c *client) close() error {
+	c.in.Close()
+	return <-c.done
+}
+
+func decodeField(t *testing.T, msg map[string]json.RawMessage, field string, v interface{}) {
+	t.Helper()
+	raw, ok := msg[field]
+	if !ok {
+		t.Fatalf("message has no %q: %v", field, msg)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a response carries either a result or an error, never both
+func checkResponse(t *testing.T, msg map[string]json.RawMessage, wantError bool) {
+	t.Helper()
+	_, hasResult := msg["result"]
+	_, hasError := msg["error"]
+	if hasResult == hasError || hasError != wantError {
+		t.Fatalf("expected exactly one of result and error (error: %t), got %v", wantError, msg)
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	c := start(t)
+	resp := c.request("initialize", map[string]interface{}{})
+	checkResponse(t, resp, false)
+	var result struct {
+		Capabilities struct {
+			TextDocumentSync   int  `json:"textDocumentSync"`
+			DefinitionProvider bool `json:"definitionProvider"`
+		} `json:"capabilities"`
+	}
+	decodeField(t, resp, "result", &result)
+	if result.Capabilities.TextDocumentSync != FULL_SYNC || !result.Capabilities.DefinitionProvider {
+		t.Errorf("unexpected capabilities %+v", result.Capabilities)
+	}
+
+	// a successful response without a result still has a null result
+	resp = c.request("shutdown", nil)
+	checkResponse(t, resp, false)
+	if string(resp["result"]) != "null" {
+		t.Errorf("expected a null result, got %s", resp["result"])
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := start(t)
+	c.request("initialize", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.pl", "version": 1, "text": "let x = 1;\nlet = 2;\n"},
+	})
+	var params PublishDiagnosticsParams
+	decodeField(t, c.receive(), "params", &params)
+	if params.URI != "file:///a.pl" || len(params.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic for the document, got %+v", params)
+	}
+	d := params.Diagnostics[0]
+	if d.Range.Start.Line != 1 || !strings.Contains(d.Message, "Expect variable name") {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+
+	// fixing the error clears the diagnostics
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": "file:///a.pl"},
+		"contentChanges": []map[string]interface{}{{"text": "let x = 1;\n"}},
+	})
+	decodeField(t, c.receive(), "params", &params)
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", params.Diagnostics)
+	}
+	if err := c.close(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := start(t)
+	c.request("initialize", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.pl", "version": 1, "text": "func f() {\n  let xs = [1];\n  print xs;\n}\n"},
+	})
+	c.receive()
+
+	resp := c.request("textDocument/definition", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.pl"},
+		"position":     map[string]interface{}{"line": 2, "character": 9},
+	})
+	checkResponse(t, resp, false)
+	var location Location
+	decodeField(t, resp, "result", &location)
+	want := Range{Start: Position{Line: 1, Character: 6}, End: Position{Line: 1, Character: 8}}
+	if location.URI != "file:///a.pl" || location.Range != want {
+		t.Errorf("got %+v, want %+v", location, want)
+	}
+
+	// nothing is declared at the position
+	resp = c.request("textDocument/definition", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.pl"},
+		"position":     map[string]interface{}{"line": 3, "character": 0},
+	})
+	checkResponse(t, resp, false)
+	if string(resp["result"]) != "null" {
+		t.Errorf("expected a null result, got %s", resp["result"])
+	}
+	if err := c.close(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	c := start(t)
+	resp := c.request("textDocument/hover", map[string]interface{}{})
+	checkResponse(t, resp, true)
+	var rerr responseError
+	decodeField(t, resp, "error", &rerr)
+	if rerr.Code != SERVER_NOT_INITIALIZED {
+		t.Errorf("expected code %d, got %d", SERVER_NOT_INITIALIZED, rerr.Code)
+	}
+
+	c.request("initialize", map[string]interface{}{})
+	resp = c.request("unknown/method", nil)
+	checkResponse(t, resp, true)
+	decodeField(t, resp, "error", &rerr)
+	if rerr.Code != METHOD_NOT_FOUND {
+		t.Errorf("expected code %d, got %d", METHOD_NOT_FOUND, rerr.Code)
+	}
+
+	resp = c.request("textDocument/definition", "not an object")
+	checkResponse(t, resp, true)
+	decodeField(t, resp, "error", &rerr)
+	if rerr.Code != INVALID_PARAMS {
+		t.Errorf("expected code %d, got %d", INVALID_PARAMS, rerr.Code)
+	}
+
+	c.sendRaw("{not json")
+	resp = c.receive()
+	checkResponse(t, resp, true)
+	decodeField(t, resp, "error", &rerr)
+	if rerr.Code != PARSE_ERROR {
+		t.Errorf("expected code %d, got %d", PARSE_ERROR, rerr.Code)
+	}
+
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrorExitWithoutShutdown {
+		t.Errorf("expected %s, got %v", ErrorExitWithoutShutdown, err)
+	}
+}
+
+func TestInvalidContentLength(t *testing.T) {
+	for _, length := range []string{"-1", "nope", strconv.Itoa(MAX_CONTENT_LENGTH + 1)} {
+		input := strings.NewReader("Content-Length: " + length + "\r\n\r\n")
+		var out strings.Builder
+		err := New(input, &out).Serve()
+		if err == nil || !strings.Contains(err.Error(), "Content-Length") {
+			t.Errorf("Content-Length %s: expected an invalid header error, got %v", length, err)
+		}
+	}
+}
@@ -1,25 +1,85 @@
 package main
 
 import (
-	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 
+	"github.com/Ahmed-Sermani/prolang/checker"
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/debugger"
+	"github.com/Ahmed-Sermani/prolang/formatter"
+	"github.com/Ahmed-Sermani/prolang/golden"
 	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/lint"
+	"github.com/Ahmed-Sermani/prolang/lsp"
+	"github.com/Ahmed-Sermani/prolang/modules"
 	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/repl"
 	"github.com/Ahmed-Sermani/prolang/reporting"
 	"github.com/Ahmed-Sermani/prolang/resolver"
 	"github.com/Ahmed-Sermani/prolang/scanner"
+	"github.com/Ahmed-Sermani/prolang/vm"
 )
 
+// runs scripts on the bytecode vm instead of the tree-walking interpreter
+var useVM = flag.Bool("vm", false, "run on the bytecode vm")
+
 func main() {
-	if len(os.Args) > 2 {
-		log.Println("Usage: code [script]")
+	// the global flags come before the subcommand, e.g. prolang --vm test dir/
+	flag.Parse()
+	switch flag.Arg(0) {
+	case "debug", "fmt", "check", "lint", "lsp":
+		if *useVM {
+			log.Printf("--vm only applies to running scripts and tests, not to %s", flag.Arg(0))
+			os.Exit(64)
+		}
+	}
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "debug" {
+		if len(args) != 2 {
+			log.Println("Usage: code debug [script]")
+			os.Exit(64)
+		}
+		debugFile(args[1])
+		return
+	}
+	if len(args) > 0 && args[0] == "fmt" {
+		formatFiles(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "test" {
+		runTests(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "check" {
+		checkFiles(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "lint" {
+		lintFiles(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "lsp" {
+		// the language server speaks over stdio
+		if err := lsp.New(os.Stdin, os.Stdout).Serve(); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) > 1 {
+		log.Println("Usage: code [--vm] [script]")
 		os.Exit(64)
-	} else if len(os.Args) == 2 {
-		runFile(os.Args[1])
+	} else if len(args) == 1 {
+		runFile(args[0])
 	} else {
 		runPrompt()
 	}
@@ -34,47 +94,249 @@ func check(e error) {
 func runFile(path string) {
 	bytes, err := ioutil.ReadFile(path)
 	check(err)
-	run(string(bytes))
+	run(string(bytes), path)
 	if reporting.HadError() {
 		os.Exit(65)
 	}
 }
 
 func runPrompt() {
-	reader := bufio.NewReader(os.Stdin)
+	if err := repl.New(os.Stdin, os.Stdout).Run(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
+
+// runs the script under the interactive debugger
+func debugFile(path string) {
+	bytes, err := ioutil.ReadFile(path)
+	check(err)
+	inter, stmts := load(string(bytes), path)
+	if reporting.HadError() {
+		os.Exit(65)
+	}
+	debugger.New(inter, string(bytes), os.Stdin, os.Stdout).Run(stmts)
+}
 
-	for {
-		fmt.Print("> ")
-		line, err := reader.ReadString('\n')
+// prints the files in the canonical style or rewrites them with -w, the standard input is formatted when no files are given
+func formatFiles(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the files instead of printing it")
+	flags.Usage = func() {
+		log.Println("Usage: code fmt [-w] [files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		bytes, err := ioutil.ReadAll(os.Stdin)
+		check(err)
+		formatted, err := formatter.Format(string(bytes), "")
 		if err != nil {
-			log.Println(err)
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(65)
+		}
+		fmt.Print(formatted)
+		return
+	}
+
+	failed := false
+	for _, path := range flags.Args() {
+		bytes, err := ioutil.ReadFile(path)
+		check(err)
+		formatted, err := formatter.Format(string(bytes), path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		if !*write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted != string(bytes) {
+			check(ioutil.WriteFile(path, []byte(formatted), 0644))
+		}
+	}
+	if failed {
+		os.Exit(65)
+	}
+}
+
+// type checks the files without running them, the exit status is 65 when any of them has an error
+func checkFiles(paths []string) {
+	if len(paths) == 0 {
+		log.Println("Usage: code check [files...]")
+		os.Exit(64)
+	}
+	failed := false
+	for _, path := range paths {
+		bytes, err := ioutil.ReadFile(path)
+		check(err)
+		reporting.UnsetError()
+		_, stmts := load(string(bytes), path)
+		if !reporting.HadError() {
+			checker.New().Check(stmts)
 		}
-		run(line)
-		// reset the flag in the interactive loop. If the user makes a mistake, it shouldn’t kill their entire session.
+		failed = failed || reporting.HadError()
+	}
+	if failed {
+		os.Exit(65)
+	}
+}
+
+// reports the issues the linter finds in the files, the exit status is 1 when there are any
+// and 65 when a file doesn't compile
+func lintFiles(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := flags.String("format", "human", "the output format, human or json")
+	flags.Usage = func() {
+		log.Println("Usage: code lint [-format human|json] files...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 || (*format != "human" && *format != "json") {
+		flags.Usage()
+		os.Exit(64)
+	}
+
+	issues := []lint.Issue{}
+	failed := false
+	for _, path := range flags.Args() {
+		bytes, err := ioutil.ReadFile(path)
+		check(err)
 		reporting.UnsetError()
+		issues = append(issues, lint.Lint(string(bytes), path)...)
+		failed = failed || reporting.HadError()
+	}
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(issues, "", "  ")
+		check(err)
+		fmt.Println(string(out))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+	switch {
+	case failed:
+		os.Exit(65)
+	case len(issues) != 0:
+		os.Exit(1)
+	}
+}
+
+// runs the golden tests in the files and the .pl files under the directories,
+// on the vm when -vm is given to the subcommand or --vm before it
+func runTests(args []string) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "the time each test is allowed to run")
+	workers := flags.Int("j", runtime.NumCPU(), "the number of tests run in parallel")
+	testVM := flags.Bool("vm", false, "run the tests on the bytecode vm")
+	flags.Usage = func() {
+		log.Println("Usage: code test [-timeout d] [-j n] [-vm] [dirs or files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 || *workers < 1 {
+		flags.Usage()
+		os.Exit(64)
+	}
+
+	paths := []string{}
+	for _, arg := range flags.Args() {
+		info, err := os.Stat(arg)
+		check(err)
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		check(filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && filepath.Ext(path) == ".pl" {
+				paths = append(paths, path)
+			}
+			return err
+		}))
 	}
 
+	passed, failed, skipped := 0, 0, 0
+	results, err := golden.Run(paths, *workers, *timeout, *testVM || *useVM)
+	for _, result := range results {
+		if result.Interrupted {
+			skipped++
+			continue
+		}
+		if result.Passed() {
+			passed++
+			fmt.Println("PASS", result.Path)
+			continue
+		}
+		failed++
+		fmt.Println("FAIL", result.Path)
+		for _, failure := range result.Failures {
+			fmt.Println("    " + failure)
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if err != nil {
+		fmt.Printf("interrupted, %d not run\n", skipped)
+		os.Exit(130)
+	}
+	if failed != 0 {
+		os.Exit(1)
+	}
 }
-func run(source string) {
+
+// scans, parses and resolves the source. Callers check reporting.HadError before running the statements.
+func load(source string, path string) (*interpreter.Interpreter, []statements.Statement) {
 	scanner := scanner.New(source)
+	scanner.SetFile(path)
 	tokens := scanner.ScanTokens()
 	p := parser.New(tokens)
 	stmts := p.Parse()
 	// stop if there is a syntax error
 	if reporting.HadError() {
-		return
+		return nil, nil
 	}
 	inter := interpreter.New()
+	inter.SetPath(path)
+	loader := modules.New()
+	if path != "" {
+		loader.SetMain(path)
+	}
+	inter.SetImporter(loader)
 
 	// running the resolver (static analysis)
 	resolver := resolver.New(inter)
 	resolver.Resolve(stmts)
+	return inter, stmts
+}
 
-	// stop if there is a resolver error
+// path is the file the source was read from
+func run(source string, path string) {
+	inter, stmts := load(source, path)
+	// stop if there is a syntax or resolver error
 	if reporting.HadError() {
 		return
 	}
 
+	if *useVM {
+		script := compiler.New().Compile(stmts)
+		if reporting.HadError() {
+			return
+		}
+		machine := vm.New()
+		machine.SetFile(path)
+		loader := modules.New()
+		if path != "" {
+			loader.SetMain(path)
+		}
+		machine.SetImporter(loader)
+		machine.Interpret(script)
+		return
+	}
+
 	// running the interpreter
 	inter.Interpret(stmts)
 
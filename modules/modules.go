@@ -0,0 +1,173 @@
+package modules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+	"github.com/Ahmed-Sermani/prolang/vm"
+)
+
+// environment variable holding extra directories searched for imports
+const SearchPathEnv = "PROLANG_PATH"
+
+// implements interpreter.Importer and vm.Importer.
+// it locates module files, runs them through the scanner, parser and resolver
+// and executes each file once caching the resulting module.
+// a loader serves a single backend, the cached modules are the ones of that backend
+type Loader struct {
+	// modules already executed keyed by their absolute path
+	loaded map[string]interface{}
+	// absolute paths of the modules being executed, in import order.
+	// importing one of them again is a cycle
+	loading []string
+	// directories searched after the importing file's directory
+	searchPath []string
+}
+
+func New() *Loader {
+	searchPath := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv(SearchPathEnv)) {
+		if dir != "" {
+			searchPath = append(searchPath, dir)
+		}
+	}
+	return &Loader{
+		loaded:     map[string]interface{}{},
+		searchPath: searchPath,
+	}
+}
+
+// registers the entry script so importing it back is reported as a cycle
+func (l *Loader) SetMain(path string) {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		l.loading = append(l.loading, abs)
+	}
+}
+
+func (l *Loader) Import(inter *interpreter.Interpreter, from string, path string) (*interpreter.Module, error) {
+	m, err := l.load(from, path, func(abs string, stmts []statements.Statement) (interface{}, error) {
+		m := inter.NewModule(abs)
+		err := inter.InModule(m, func() error {
+			// running the resolver (static analysis) for the module's own scope
+			resolver.New(inter).Resolve(stmts)
+			if reporting.HadError() {
+				return interpreter.NewImportError(fmt.Sprintf("Resolution error in module '%s'", path))
+			}
+			return inter.Execute(stmts)
+		})
+		return m, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.(*interpreter.Module), nil
+}
+
+func (l *Loader) ImportCompiled(machine *vm.VM, from string, path string) (*vm.Module, error) {
+	m, err := l.load(from, path, func(abs string, stmts []statements.Statement) (interface{}, error) {
+		// the resolver reports the static errors, its resolution information isn't used by the compiler
+		inter := interpreter.New()
+		inter.SetPath(abs)
+		resolver.New(inter).Resolve(stmts)
+		if reporting.HadError() {
+			return nil, interpreter.NewImportError(fmt.Sprintf("Resolution error in module '%s'", path))
+		}
+		script := compiler.New().Compile(stmts)
+		if reporting.HadError() {
+			return nil, interpreter.NewImportError(fmt.Sprintf("Compile error in module '%s'", path))
+		}
+		return machine.RunModule(abs, script)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.(*vm.Module), nil
+}
+
+// locates and parses the module then runs it with exec, unless it was loaded already.
+// exec is given the absolute path of the module and returns the module value of the backend
+func (l *Loader) load(from string, path string, exec func(abs string, stmts []statements.Statement) (interface{}, error)) (interface{}, error) {
+	abs, err := l.locate(from, path)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := l.loaded[abs]; ok {
+		return m, nil
+	}
+	for i, p := range l.loading {
+		if p == abs {
+			return nil, interpreter.NewImportError(fmt.Sprintf("Circular import %s", l.cycle(l.loading[i:], abs)))
+		}
+	}
+
+	bytes, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return nil, interpreter.NewImportError(fmt.Sprintf("Can't read module '%s'", path))
+	}
+
+	l.loading = append(l.loading, abs)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	s := scanner.New(string(bytes))
+	s.SetFile(abs)
+	stmts := parser.New(s.ScanTokens()).Parse()
+	if reporting.HadError() {
+		return nil, interpreter.NewImportError(fmt.Sprintf("Syntax error in module '%s'", path))
+	}
+
+	m, err := exec(abs, stmts)
+	if err != nil {
+		return nil, err
+	}
+	l.loaded[abs] = m
+	return m, nil
+}
+
+// finds the module file, relative paths are tried against the importing file's directory
+// then against each directory of the search path
+func (l *Loader) locate(from string, path string) (string, error) {
+	candidates := []string{}
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		dir := "."
+		if from != "" {
+			dir = filepath.Dir(from)
+		}
+		candidates = append(candidates, filepath.Join(dir, path))
+		for _, searchDir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		return filepath.Abs(candidate)
+	}
+	return "", interpreter.NewImportError(fmt.Sprintf("Module '%s' not found", path))
+}
+
+// formats the import chain of a cycle e.g. a.pl -> b.pl -> a.pl
+func (l *Loader) cycle(chain []string, abs string) string {
+	names := []string{}
+	for _, p := range append(chain, abs) {
+		names = append(names, filepath.Base(p))
+	}
+	return strings.Join(names, " -> ")
+}
@@ -11,6 +11,12 @@ type Token struct {
 	Lexeme  string
 	Literal Literal
 	Line    int
+	// 1-based byte offset of the token in its line, zero for tokens that are not in the source
+	Column int
+	// byte offset of the token in the source
+	Offset int
+	// the file the token was scanned from, empty when the source isn't a file
+	File string
 }
 
 func (tok Token) String() string {
@@ -34,6 +40,15 @@ type ExpressionVisitor interface {
 	VisitPropertyAssignment(PropertyAssignment) (interface{}, error)
 	VisitThis(This) (interface{}, error)
 	VisitSuper(Super) (interface{}, error)
+	VisitListLiteral(ListLiteral) (interface{}, error)
+	VisitIndex(Index) (interface{}, error)
+	VisitIndexAssignment(IndexAssignment) (interface{}, error)
+	VisitSlice(Slice) (interface{}, error)
+	VisitMapLiteral(MapLiteral) (interface{}, error)
+	VisitLambda(Lambda) (interface{}, error)
+	VisitConditional(Conditional) (interface{}, error)
+	VisitCoalesce(Coalesce) (interface{}, error)
+	VisitOptionalChain(OptionalChain) (interface{}, error)
 }
 
 type Binary struct {
@@ -65,9 +80,17 @@ type Variable struct {
 }
 
 // has variable being assigned to, and an expression for the new value
+// like Variable its unique id is the key of its resolution information.
+// compound assignments (x += v) and increments (x++, --x) combine the current value with Value
+// using Operator, an arithmetic operator token whose lexeme is the one in the source e.g. '+=' or '++'.
+// Operator is the zero token for plain assignments
 type Assgin struct {
-	Token Token
-	Value Experssion
+	Token    Token
+	Value    Experssion
+	Operator Token
+	// x++ and x-- evaluate to the value before the update
+	Postfix bool
+	Uuid    int
 }
 
 // represent 'and', 'or' operators
@@ -86,24 +109,98 @@ type Call struct {
 	Args    []Experssion
 }
 
+// Optional is set for obj?.name, it's always part of an OptionalChain
 type PropertyAccess struct {
-	Name Token
-	Obj  Experssion
+	Name     Token
+	Obj      Experssion
+	Optional bool
 }
 
+// Operator and Postfix are the same as Assgin's, Obj is evaluated once for compound assignments
 type PropertyAssignment struct {
-	Name  Token
-	Obj   Experssion
-	Value Experssion
+	Name     Token
+	Obj      Experssion
+	Value    Experssion
+	Operator Token
+	Postfix  bool
 }
 
 type This struct {
 	Keywork Token
+	Uuid    int
 }
 
 type Super struct {
 	Keyword Token
 	Method  Token
+	Uuid    int
+}
+
+// list literal e.g. [1, 2, 3]
+// It stores the opening bracket token to report runtime errors at its location.
+type ListLiteral struct {
+	Bracket  Token
+	Elements []Experssion
+}
+
+// subscript read e.g. xs[i]
+type Index struct {
+	Obj     Experssion
+	Bracket Token
+	Index   Experssion
+}
+
+// subscript write e.g. xs[i] = v
+type IndexAssignment struct {
+	Obj     Experssion
+	Bracket Token
+	Index   Experssion
+	Value   Experssion
+}
+
+// xs[start:end] both bounds are optional and nil when omitted
+type Slice struct {
+	Obj     Experssion
+	Bracket Token
+	Start   Experssion
+	End     Experssion
+}
+
+// map literal e.g. {"k": v}
+// keys and values are kept in two parallel slices to preserve the source order.
+type MapLiteral struct {
+	Brace  Token
+	Keys   []Experssion
+	Values []Experssion
+}
+
+// anonymous function e.g. func (a, b) { ... } or (a, b) => a + b
+// Function holds a statements.FunctionStatement with an empty name, it's typed as interface{}
+// because the statements package depends on this one.
+type Lambda struct {
+	Keyword  Token
+	Function interface{}
+}
+
+// cond ? then : else, only the branch picked by the condition is evaluated
+type Conditional struct {
+	Condition Experssion
+	Question  Token
+	Then      Experssion
+	Else      Experssion
+}
+
+// left ?? right, right is evaluated only when left is nil
+type Coalesce struct {
+	Left     Experssion
+	Operator Token
+	Right    Experssion
+}
+
+// the accesses and calls following an optional property access e.g. a?.b.c(),
+// the whole chain evaluates to nil as soon as an optional access is made on nil
+type OptionalChain struct {
+	Expr Experssion
 }
 
 func (g Grouping) Accept(visitor ExpressionVisitor) (interface{}, error) {
@@ -153,3 +250,39 @@ func (t This) Accept(visitor ExpressionVisitor) (interface{}, error) {
 func (s Super) Accept(visitor ExpressionVisitor) (interface{}, error) {
 	return visitor.VisitSuper(s)
 }
+
+func (l ListLiteral) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitListLiteral(l)
+}
+
+func (i Index) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitIndex(i)
+}
+
+func (i IndexAssignment) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitIndexAssignment(i)
+}
+
+func (s Slice) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitSlice(s)
+}
+
+func (m MapLiteral) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitMapLiteral(m)
+}
+
+func (l Lambda) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitLambda(l)
+}
+
+func (c Conditional) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitConditional(c)
+}
+
+func (c Coalesce) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitCoalesce(c)
+}
+
+func (o OptionalChain) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitOptionalChain(o)
+}
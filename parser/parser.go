@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/Ahmed-Sermani/prolang/parser/expressions"
 	"github.com/Ahmed-Sermani/prolang/parser/statements"
@@ -28,17 +30,13 @@ func New(tokens []expressions.Token) *Parser {
 	}
 }
 
+// safe for parsers running concurrently (e.g. the test runner)
 type varUuidGen struct {
-	current int
-}
-
-func (g *varUuidGen) inc() {
-	g.current++
+	current int64
 }
 
 func (g *varUuidGen) gen() int {
-	defer g.inc()
-	return g.current
+	return int(atomic.AddInt64(&g.current, 1) - 1)
 }
 
 var varUuid = varUuidGen{}
@@ -54,6 +52,19 @@ func (p *Parser) Parse() []statements.Statement {
 	return statements
 }
 
+// parses a single expression spanning all the tokens, for expressions typed in by users (e.g. in the debugger)
+func (p *Parser) ParseExpression() (expressions.Experssion, error) {
+	expr, err := p.experssion()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		p.error(p.peek(), "Expect end of expression.", "")
+		return nil, ErrorParsing
+	}
+	return expr, nil
+}
+
 func (p *Parser) block() ([]statements.Statement, error) {
 	stmts := []statements.Statement{}
 
@@ -72,6 +83,16 @@ func (p *Parser) block() ([]statements.Statement, error) {
 // declaration     → varDeclaration | statement | funcDeclaration | classDeclaration ;
 func (p *Parser) declaration() statements.Statement {
 	var err error
+	// 'const' before a function or a class makes its name non-reassignable
+	if p.check(scanner.CONST) && (p.checkNext(scanner.FUNC) || p.checkNext(scanner.CLASS)) {
+		p.advance()
+		stmt, err := p.constantDeclaration()
+		if err != nil {
+			p.synchronize()
+			return nil
+		}
+		return stmt
+	}
 	if p.match(scanner.CLASS) {
 		stmt, err := p.classDeclaration()
 		if err != nil {
@@ -80,7 +101,9 @@ func (p *Parser) declaration() statements.Statement {
 		}
 		return stmt
 	}
-	if p.match(scanner.FUNC) {
+	// 'func' not followed by a name starts an anonymous function expression
+	if p.check(scanner.FUNC) && p.checkNext(scanner.IDENTIFIER) {
+		p.advance()
 		stmt, err := p.function("function")
 		if err != nil {
 			p.synchronize()
@@ -88,7 +111,7 @@ func (p *Parser) declaration() statements.Statement {
 		}
 		return stmt
 	}
-	if p.match(scanner.LET) {
+	if p.match(scanner.LET, scanner.CONST) {
 		stmt, err := p.varDeclaration()
 		// recovery
 		if err != nil {
@@ -108,7 +131,29 @@ func (p *Parser) declaration() statements.Statement {
 
 }
 
-// classDeclaration → "class" IDENTIFIER ( "extends" IDENTIFIER )? "{" function* "}" ;
+// constDeclaration → "const" ( funcDeclaration | classDeclaration ) ;
+func (p *Parser) constantDeclaration() (statements.Statement, error) {
+	if p.match(scanner.CLASS) {
+		stmt, err := p.classDeclaration()
+		if err != nil {
+			return nil, err
+		}
+		class := stmt.(statements.ClassStatement)
+		class.Constant = true
+		return class, nil
+	}
+	p.advance()
+	stmt, err := p.function("function")
+	if err != nil {
+		return nil, err
+	}
+	function := stmt.(statements.FunctionStatement)
+	function.Constant = true
+	return function, nil
+}
+
+// classDeclaration → "class" IDENTIFIER ( "extends" IDENTIFIER )? "{" classMember* "}" ;
+// classMember      → function | getter | "static" function | "static" varDeclaration ;
 func (p *Parser) classDeclaration() (statements.Statement, error) {
 	name, err := p.consume(scanner.IDENTIFIER, "Expect class name")
 	if err != nil {
@@ -129,14 +174,40 @@ func (p *Parser) classDeclaration() (statements.Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	methods := []statements.FunctionStatement{}
+	class := statements.ClassStatement{Name: name, Superclass: superclass}
 
 	for !p.check(scanner.RIGHT_BRACE) && !p.isAtEnd() {
+		if p.match(scanner.STATIC) {
+			if p.match(scanner.LET) {
+				field, err := p.varDeclaration()
+				if err != nil {
+					return nil, err
+				}
+				class.StaticFields = append(class.StaticFields, field.(statements.VarDecStatement))
+				continue
+			}
+			method, err := p.function("static method")
+			if err != nil {
+				return nil, err
+			}
+			class.StaticMethods = append(class.StaticMethods, method.(statements.FunctionStatement))
+			continue
+		}
+		// 'get' is only a keyword when it's followed by the getter name
+		if p.check(scanner.IDENTIFIER) && p.peek().Lexeme == "get" && p.checkNext(scanner.IDENTIFIER) {
+			p.advance()
+			getter, err := p.getter()
+			if err != nil {
+				return nil, err
+			}
+			class.Getters = append(class.Getters, getter)
+			continue
+		}
 		method, err := p.function("method")
 		if err != nil {
 			return nil, err
 		}
-		methods = append(methods, method.(statements.FunctionStatement))
+		class.Methods = append(class.Methods, method.(statements.FunctionStatement))
 	}
 
 	_, err = p.consume(scanner.RIGHT_BRACE, "Expect '}' after class body")
@@ -144,12 +215,32 @@ func (p *Parser) classDeclaration() (statements.Statement, error) {
 		return nil, err
 	}
 
-	return statements.ClassStatement{
-		Name:       name,
-		Methods:    methods,
-		Superclass: superclass,
-	}, nil
+	return class, nil
+
+}
 
+// getter           → "get" IDENTIFIER ( ":" type )? block ;
+func (p *Parser) getter() (statements.FunctionStatement, error) {
+	name, err := p.consume(scanner.IDENTIFIER, "Expect getter name.")
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	var returnType expressions.Token
+	if p.match(scanner.COLON) {
+		returnType, err = p.typeAnnotation()
+		if err != nil {
+			return statements.FunctionStatement{}, err
+		}
+	}
+	_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' before getter body.")
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	return statements.FunctionStatement{Name: name, Args: []expressions.Token{}, ReturnType: returnType, Body: body}, nil
 }
 
 func (p *Parser) function(kind string) (statements.Statement, error) {
@@ -161,48 +252,115 @@ func (p *Parser) function(kind string) (statements.Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	paramenters := []expressions.Token{}
+	return p.functionRest(kind, name)
+}
 
+// parses the parameters and the body of a function after its opening parenthesis
+func (p *Parser) functionRest(kind string, name expressions.Token) (statements.FunctionStatement, error) {
+	function, err := p.parameters()
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	function.Name = name
+	if p.match(scanner.COLON) {
+		function.ReturnType, err = p.typeAnnotation()
+		if err != nil {
+			return statements.FunctionStatement{}, err
+		}
+	}
+	_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	function.Body, err = p.block()
+	if err != nil {
+		return statements.FunctionStatement{}, err
+	}
+	return function, nil
+}
+
+// parses the parameters up to the closing parenthesis, the returned function has no name nor body yet
+// parameters       → parameter ( "," parameter )* ( "," rest )? | rest ;
+// parameter        → IDENTIFIER ( ":" type )? ( "=" expression )? ;
+// rest             → "..." IDENTIFIER ( ":" type )? ;
+func (p *Parser) parameters() (statements.FunctionStatement, error) {
+	function := statements.FunctionStatement{Args: []expressions.Token{}}
 	if !p.check(scanner.RIGHT_PAREN) {
 		for {
+			if function.Variadic {
+				p.error(p.peek(), "The rest parameter must be the last one.", "")
+			}
+			function.Variadic = p.match(scanner.ELLIPSIS)
 			param, err := p.consume(scanner.IDENTIFIER, "Expect parameter name.")
 			if err != nil {
-				return nil, err
+				return statements.FunctionStatement{}, err
+			}
+			var typ expressions.Token
+			if p.match(scanner.COLON) {
+				typ, err = p.typeAnnotation()
+				if err != nil {
+					return statements.FunctionStatement{}, err
+				}
+			}
+			var def expressions.Experssion
+			if p.match(scanner.EQUAL) {
+				equals := p.previous()
+				def, err = p.experssion()
+				if err != nil {
+					return statements.FunctionStatement{}, err
+				}
+				if function.Variadic {
+					p.error(equals, "The rest parameter can't have a default value.", "")
+				}
+			} else if !function.Variadic && len(function.Defaults) > 0 && function.Defaults[len(function.Defaults)-1] != nil {
+				p.error(param, "Parameters without a default value can't follow the ones with one.", "")
 			}
-			paramenters = append(paramenters, param)
+			function.Args = append(function.Args, param)
+			function.Types = append(function.Types, typ)
+			function.Defaults = append(function.Defaults, def)
 			if !p.match(scanner.COMMA) {
 				break
 			}
 		}
 	}
-	_, err = p.consume(scanner.RIGHT_PAREN, "Expect ')' after parameters")
+	_, err := p.consume(scanner.RIGHT_PAREN, "Expect ')' after parameters")
 	if err != nil {
-		return nil, err
-	}
-	_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' before "+kind+" body.")
-	if err != nil {
-		return nil, err
-	}
-	body, err := p.block()
-	if err != nil {
-		return nil, err
+		return statements.FunctionStatement{}, err
 	}
+	return function, nil
+}
 
-	return statements.FunctionStatement{
-		Name: name,
-		Args: paramenters,
-		Body: body,
-	}, nil
+// type             → IDENTIFIER | "nil" ;
+// called after the ':' of an annotation, the name is checked by the type checker
+func (p *Parser) typeAnnotation() (expressions.Token, error) {
+	if p.match(scanner.NIL) {
+		return p.previous(), nil
+	}
+	return p.consume(scanner.IDENTIFIER, "Expect type name.")
 }
 
-// varDeclaration   → "let" IDENTIFIER ( "=" expression )? ";" ;
+// varDeclaration   → "let" IDENTIFIER ( ":" type )? ( "=" expression )? ";" | "const" IDENTIFIER ( ":" type )? "=" expression ";" ;
 func (p *Parser) varDeclaration() (statements.Statement, error) {
+	constant := p.previous().Kind == scanner.CONST
 	name, err := p.consume(scanner.IDENTIFIER, "Expect variable name.")
 	if err != nil {
 		return nil, err
 	}
+	var typ expressions.Token
+	if p.match(scanner.COLON) {
+		typ, err = p.typeAnnotation()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if constant {
+		_, err = p.consume(scanner.EQUAL, "Expect '=' after constant name.")
+		if err != nil {
+			return nil, err
+		}
+	}
 	var initializer expressions.Experssion
-	if p.match(scanner.EQUAL) {
+	if constant || p.match(scanner.EQUAL) {
 		initializer, err = p.experssion()
 		if err != nil {
 			return nil, err
@@ -214,15 +372,41 @@ func (p *Parser) varDeclaration() (statements.Statement, error) {
 	}
 	return statements.VarDecStatement{
 		Token:       name,
+		Type:        typ,
 		Initializer: initializer,
+		Constant:    constant,
 	}, nil
 
 }
 
-// statement       → exprStatement | printStatement | block | ifStatement | whileStatement | forStatement | returnStatement;
+// statement       → exprStatement | printStatement | block | ifStatement | whileStatement | forStatement | returnStatement | breakStatement | continueStatement | labeledStatement | throwStatement | tryStatement | importStatement | switchStatement ;
 func (p *Parser) statement() (statements.Statement, error) {
+	if p.check(scanner.IDENTIFIER) && p.checkNext(scanner.COLON) {
+		return p.labeledStatement()
+	}
 	if p.match(scanner.FOR) {
-		return p.forStatement()
+		return p.forStatement(expressions.Token{})
+	}
+	if p.match(scanner.BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(scanner.CONTINUE) {
+		return p.continueStatement()
+	}
+	if p.match(scanner.THROW) {
+		return p.throwStatement()
+	}
+	if p.match(scanner.TRY) {
+		return p.tryStatement()
+	}
+	if p.match(scanner.SWITCH) {
+		return p.switchStatement()
+	}
+	if p.match(scanner.IMPORT) {
+		return p.importStatement()
+	}
+	if p.match(scanner.FROM) {
+		return p.fromImportStatement()
 	}
 	if p.match(scanner.IF) {
 		return p.ifStatement()
@@ -234,16 +418,251 @@ func (p *Parser) statement() (statements.Statement, error) {
 		return p.returnStatement()
 	}
 	if p.match(scanner.WHILE) {
-		return p.whileStatement()
+		return p.whileStatement(expressions.Token{})
 	}
 	if p.match(scanner.LEFT_BRACE) {
+		brace := p.previous()
 		stmts, err := p.block()
-		return statements.BlockStatement{Statements: stmts}, err
+		return statements.BlockStatement{Brace: brace, Statements: stmts}, err
 	}
 
 	return p.experssionStatement()
 }
 
+// labeledStatement → IDENTIFIER ":" ( whileStatement | forStatement ) ;
+func (p *Parser) labeledStatement() (statements.Statement, error) {
+	label := p.advance()
+	// consume ':'
+	p.advance()
+	if p.match(scanner.FOR) {
+		return p.forStatement(label)
+	}
+	if p.match(scanner.WHILE) {
+		return p.whileStatement(label)
+	}
+	p.error(label, "Expect a loop after label.", "")
+	return nil, ErrorParsing
+}
+
+// breakStatement  → "break" IDENTIFIER? ";" ;
+func (p *Parser) breakStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	var label expressions.Token
+	if p.match(scanner.IDENTIFIER) {
+		label = p.previous()
+	}
+	_, err := p.consume(scanner.SEMICOLON, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+	return statements.BreakStatement{Keyword: keyword, Label: label}, nil
+}
+
+// continueStatement → "continue" IDENTIFIER? ";" ;
+func (p *Parser) continueStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	var label expressions.Token
+	if p.match(scanner.IDENTIFIER) {
+		label = p.previous()
+	}
+	_, err := p.consume(scanner.SEMICOLON, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+	return statements.ContinueStatement{Keyword: keyword, Label: label}, nil
+}
+
+// throwStatement  → "throw" expression ";" ;
+func (p *Parser) throwStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	value, err := p.experssion()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(scanner.SEMICOLON, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+	return statements.ThrowStatement{Keyword: keyword, Value: value}, nil
+}
+
+// switchStatement → "switch" "(" expression ")" "{" switchCase* ( "default" ":" declaration* )? "}" ;
+// switchCase      → "case" expression ( "," expression )* ":" declaration* ;
+func (p *Parser) switchStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	_, err := p.consume(scanner.LEFT_PAREN, "Expect '(' after 'switch'.")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.experssion()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(scanner.RIGHT_PAREN, "Expect ')' after switch value.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' before switch cases.")
+	if err != nil {
+		return nil, err
+	}
+	stmt := statements.SwitchStatement{Keyword: keyword, Value: value}
+
+	for p.check(scanner.CASE) || p.check(scanner.DEFAULT) {
+		// the clauses after a misplaced default are still parsed to report their own errors
+		if stmt.Default != nil {
+			p.error(p.peek(), "The default clause must be the last one.", "")
+		}
+		if p.match(scanner.DEFAULT) {
+			stmt.DefaultKeyword = p.previous()
+			_, err = p.consume(scanner.COLON, "Expect ':' after 'default'.")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Default = p.caseBody()
+			continue
+		}
+		clause := statements.SwitchCase{Keyword: p.advance()}
+		for {
+			value, err := p.experssion()
+			if err != nil {
+				return nil, err
+			}
+			clause.Values = append(clause.Values, value)
+			if !p.match(scanner.COMMA) {
+				break
+			}
+		}
+		_, err = p.consume(scanner.COLON, "Expect ':' after case values.")
+		if err != nil {
+			return nil, err
+		}
+		clause.Body = p.caseBody()
+		stmt.Cases = append(stmt.Cases, clause)
+	}
+	_, err = p.consume(scanner.RIGHT_BRACE, "Expect '}' after switch cases.")
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// the statements of a switch clause up to the next clause or the end of the switch.
+// the slice is never nil so an empty default clause is still flagged
+func (p *Parser) caseBody() []statements.Statement {
+	body := []statements.Statement{}
+	for !p.check(scanner.CASE) && !p.check(scanner.DEFAULT) && !p.check(scanner.RIGHT_BRACE) && !p.isAtEnd() {
+		body = append(body, p.declaration())
+	}
+	return body
+}
+
+// tryStatement    → "try" block ( "catch" "(" IDENTIFIER ")" block )? ( "finally" block )? ;
+// at least one of catch or finally is required
+func (p *Parser) tryStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	_, err := p.consume(scanner.LEFT_BRACE, "Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	stmt := statements.TryStatement{Keyword: keyword, Body: body}
+
+	if p.match(scanner.CATCH) {
+		_, err = p.consume(scanner.LEFT_PAREN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+		stmt.CatchName, err = p.consume(scanner.IDENTIFIER, "Expect error variable name.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(scanner.RIGHT_PAREN, "Expect ')' after error variable name.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+		// block never returns a nil slice so an empty catch body still flags the clause
+		stmt.CatchBody, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.match(scanner.FINALLY) {
+		_, err = p.consume(scanner.LEFT_BRACE, "Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+		stmt.FinallyBody, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.CatchBody == nil && stmt.FinallyBody == nil {
+		reporting.ReportToken(keyword, "", "Expect 'catch' or 'finally' after try block.")
+		return nil, ErrorParsing
+	}
+	return stmt, nil
+}
+
+// importStatement → "import" STRING ( "as" IDENTIFIER )? ";" ;
+func (p *Parser) importStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	path, err := p.consume(scanner.STRING, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+	var alias expressions.Token
+	if p.match(scanner.AS) {
+		alias, err = p.consume(scanner.IDENTIFIER, "Expect module alias after 'as'.")
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = p.consume(scanner.SEMICOLON, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+	return statements.ImportStatement{Keyword: keyword, Path: path, Alias: alias}, nil
+}
+
+// fromImportStatement → "from" STRING "import" IDENTIFIER ( "," IDENTIFIER )* ";" ;
+func (p *Parser) fromImportStatement() (statements.Statement, error) {
+	keyword := p.previous()
+	path, err := p.consume(scanner.STRING, "Expect module path after 'from'.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(scanner.IMPORT, "Expect 'import' after module path.")
+	if err != nil {
+		return nil, err
+	}
+	names := []expressions.Token{}
+	for {
+		name, err := p.consume(scanner.IDENTIFIER, "Expect imported name.")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.match(scanner.COMMA) {
+			break
+		}
+	}
+	_, err = p.consume(scanner.SEMICOLON, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+	return statements.ImportStatement{Keyword: keyword, Path: path, Names: names}, nil
+}
+
 // returnStatement → "return" expression? ";" ;
 func (p *Parser) returnStatement() (statements.Statement, error) {
 	keyword := p.previous()
@@ -266,15 +685,19 @@ func (p *Parser) returnStatement() (statements.Statement, error) {
 }
 
 // forStatement   → "for" "(" ( varDecl | exprStmt | ";" ) expression? ";" expression? ")" statement ;
-func (p *Parser) forStatement() (statements.Statement, error) {
+func (p *Parser) forStatement(label expressions.Token) (statements.Statement, error) {
+	keyword := p.previous()
 	_, err := p.consume(scanner.LEFT_PAREN, "Expect '(' after 'for'")
 	if err != nil {
 		return nil, err
 	}
 	var initializer statements.Statement
 
-	// variable declaration
-	if p.match(scanner.LET) {
+	// the initializer is omitted
+	if p.match(scanner.SEMICOLON) {
+		initializer = nil
+		// variable declaration
+	} else if p.match(scanner.LET) {
 		dec, err := p.varDeclaration()
 		initializer = dec
 		if err != nil {
@@ -321,31 +744,25 @@ func (p *Parser) forStatement() (statements.Statement, error) {
 		return nil, err
 	}
 
-	if increment != nil {
-		// replacing the body with Block statement that has the increment appended at the end
-		body = statements.BlockStatement{
-			Statements: []statements.Statement{
-				body,
-				statements.ExperssionStatement{Expr: increment},
-			},
-		}
-
-	}
-
 	if condition == nil {
 		// if the condition is not set. set it as true literal
 		condition = expressions.Literal{Value: true}
 	}
 
-	// implement for loop as syntactic sugar of while loop
+	// implement for loop as syntactic sugar of while loop.
+	// the increment is not appended to the body so 'continue' doesn't skip it
 	body = statements.WhileStatement{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
+		Label:     label,
 	}
 
 	// if the initializer is set. using Block statement. set the initializer as the first statement
 	if initializer != nil {
 		body = statements.BlockStatement{
+			Brace:      keyword,
 			Statements: []statements.Statement{initializer, body},
 		}
 	}
@@ -354,7 +771,8 @@ func (p *Parser) forStatement() (statements.Statement, error) {
 }
 
 // whileStmt      → "while" "(" expression ")" statement ;
-func (p *Parser) whileStatement() (statements.Statement, error) {
+func (p *Parser) whileStatement(label expressions.Token) (statements.Statement, error) {
+	keyword := p.previous()
 	_, err := p.consume(scanner.LEFT_PAREN, "Expect '(' after 'while'")
 	if err != nil {
 		return nil, err
@@ -373,13 +791,16 @@ func (p *Parser) whileStatement() (statements.Statement, error) {
 	}
 
 	return statements.WhileStatement{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
+		Label:     label,
 	}, nil
 }
 
 // ifStatement    → "if" "(" expression ")" statement ( "else" statement )? ;
 func (p *Parser) ifStatement() (statements.Statement, error) {
+	keyword := p.previous()
 	_, err := p.consume(scanner.LEFT_PAREN, "Expected '(' after 'if'")
 	if err != nil {
 		return nil, err
@@ -408,6 +829,7 @@ func (p *Parser) ifStatement() (statements.Statement, error) {
 	}
 
 	return statements.IfStatement{
+		Keyword:    keyword,
 		Condition:  condition,
 		ThenBranch: thenBranch,
 		ElseBranch: elseBranch,
@@ -417,6 +839,7 @@ func (p *Parser) ifStatement() (statements.Statement, error) {
 
 // exprStatement  → expression ";" ;
 func (p *Parser) printStatement() (statements.Statement, error) {
+	keyword := p.previous()
 	val, err := p.experssion()
 	if err != nil {
 		return nil, err
@@ -425,11 +848,12 @@ func (p *Parser) printStatement() (statements.Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	return statements.PrintStatement{Expr: val}, nil
+	return statements.PrintStatement{Keyword: keyword, Expr: val}, nil
 }
 
 // exprStatement  → expression ";" ;
 func (p *Parser) experssionStatement() (statements.Statement, error) {
+	line := p.peek().Line
 	val, err := p.experssion()
 	if err != nil {
 		return nil, err
@@ -438,7 +862,7 @@ func (p *Parser) experssionStatement() (statements.Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	return statements.ExperssionStatement{Expr: val}, nil
+	return statements.ExperssionStatement{Expr: val, StartsAt: line}, nil
 }
 
 // expression     → assignment ;
@@ -446,13 +870,13 @@ func (p *Parser) experssion() (expressions.Experssion, error) {
 	return p.assignment()
 }
 
-// assignment     → ( call "." )? IDENTIFIER "=" assignment | logicalOr ;
+// assignment     → ( call "." )? IDENTIFIER ( "=" | "+=" | "-=" | "*=" | "/=" | "%=" ) assignment | call "[" expression "]" "=" assignment | conditional ;
 func (p *Parser) assignment() (expressions.Experssion, error) {
-	expr, err := p.logicalOr()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
-	if p.match(scanner.EQUAL) {
+	if p.match(scanner.EQUAL, scanner.PLUS_EQUAL, scanner.MINUS_EQUAL, scanner.STAR_EQUAL, scanner.SLASH_EQUAL, scanner.PERCENT_EQUAL) {
 		equals := p.previous()
 
 		// since assignment is right associative, then recursively call assignment() to parse the r-value.
@@ -460,22 +884,102 @@ func (p *Parser) assignment() (expressions.Experssion, error) {
 		if err != nil {
 			return nil, err
 		}
+		if equals.Kind != scanner.EQUAL {
+			return p.update(expr, equals, val, false)
+		}
 
 		// look at the left-hand side expression and figure out what kind of assignment target it is
 		// convert the r-value expression node into an l-value representation
 		if varExpr, ok := expr.(expressions.Variable); ok {
-			return expressions.Assgin{Token: varExpr.Token, Value: val}, nil
+			return expressions.Assgin{Token: varExpr.Token, Value: val, Uuid: varUuid.gen()}, nil
 			// handle turning an PropertyAccess expression on the left into the corresponding PropertyAssignment.
 		} else if access, ok := expr.(expressions.PropertyAccess); ok {
 			return expressions.PropertyAssignment{Name: access.Name, Obj: access.Obj, Value: val}, nil
+			// same for subscripts, turning an Index expression into an IndexAssignment
+		} else if index, ok := expr.(expressions.Index); ok {
+			return expressions.IndexAssignment{Obj: index.Obj, Bracket: index.Bracket, Index: index.Index, Value: val}, nil
+		}
+
+		reporting.ReportTokenHint(equals, "", ErrorInvalidAssginTarget.Error(), "only variables, properties and subscripts can be assigned")
+		return nil, ErrorInvalidAssginTarget
+	}
+
+	return expr, nil
+
+}
+
+// the arithmetic operators applied by the compound assignments, increments and decrements
+var updateOperators = map[expressions.TokenType]expressions.TokenType{
+	scanner.PLUS_EQUAL:    scanner.PLUS,
+	scanner.MINUS_EQUAL:   scanner.MINUS,
+	scanner.STAR_EQUAL:    scanner.STAR,
+	scanner.SLASH_EQUAL:   scanner.SLASH,
+	scanner.PERCENT_EQUAL: scanner.PERCENT,
+	scanner.PLUS_PLUS:     scanner.PLUS,
+	scanner.MINUS_MINUS:   scanner.MINUS,
+}
+
+// turns the target into an assignment combining its current value with the operand,
+// unlike plain assignments subscripts can't be updated in place
+func (p *Parser) update(target expressions.Experssion, operator expressions.Token, operand expressions.Experssion, postfix bool) (expressions.Experssion, error) {
+	// the lexeme is kept so errors point at the operator as written
+	op := operator
+	op.Kind = updateOperators[operator.Kind]
+	if varExpr, ok := target.(expressions.Variable); ok {
+		return expressions.Assgin{Token: varExpr.Token, Value: operand, Operator: op, Postfix: postfix, Uuid: varUuid.gen()}, nil
+	} else if access, ok := target.(expressions.PropertyAccess); ok {
+		return expressions.PropertyAssignment{Name: access.Name, Obj: access.Obj, Value: operand, Operator: op, Postfix: postfix}, nil
+	}
+
+	reporting.ReportTokenHint(operator, "", ErrorInvalidAssginTarget.Error(), fmt.Sprintf("only variables and properties can be updated with '%s'", operator.Lexeme))
+	return nil, ErrorInvalidAssginTarget
+}
+
+// the else branch is a conditional so they nest to the right, a ? b : c ? d : e is a ? b : (c ? d : e)
+// conditional    → coalesce ( "?" expression ":" conditional )? ;
+func (p *Parser) conditional() (expressions.Experssion, error) {
+	expr, err := p.coalesce()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(scanner.QUESTION) {
+		question := p.previous()
+		then, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(scanner.COLON, "Expect ':' after the then branch of the conditional expression.")
+		if err != nil {
+			return nil, err
+		}
+		els, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		return expressions.Conditional{Condition: expr, Question: question, Then: then, Else: els}, nil
+	}
+	return expr, nil
+}
+
+// coalesce       → logicalOr ( "??" logicalOr )* ;
+func (p *Parser) coalesce() (expressions.Experssion, error) {
+	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.match(scanner.QUESTION_QUESTION) {
+		op := p.previous()
+		right, err := p.logicalOr()
+		if err != nil {
+			return nil, err
+		}
+		expr = expressions.Coalesce{
+			Left:     expr,
+			Operator: op,
+			Right:    right,
 		}
-
-		reporting.ReportError(equals.Line, ErrorInvalidAssginTarget.Error())
-		return nil, ErrorInvalidAssginTarget
 	}
-
 	return expr, nil
-
 }
 
 // logicalOr      → logicalAnd ( "or" logicalAnd )* ;
@@ -545,14 +1049,102 @@ func (p *Parser) equality() (expressions.Experssion, error) {
 
 }
 
-// comparison     → term ( ( ">" | ">=" | "<" | "<=" ) term )* ;
+// comparison     → bitOr ( ( ">" | ">=" | "<" | "<=" ) bitOr )* ;
 func (p *Parser) comparison() (expressions.Experssion, error) {
-	expr, err := p.term()
+	expr, err := p.bitOr()
 	if err != nil {
 		return expressions.Binary{}, err
 	}
 
 	for p.match(scanner.GREATER, scanner.GREATER_EQUAL, scanner.LESS, scanner.LESS_EQUAL) {
+		operator := p.previous()
+		right, err := p.bitOr()
+		if err != nil {
+			return expressions.Binary{}, err
+		}
+		expr = expressions.Binary{
+			Left:     expr,
+			Right:    right,
+			Operator: operator,
+		}
+	}
+	return expr, err
+}
+
+// bitOr          → bitXor ( "|" bitXor )* ;
+func (p *Parser) bitOr() (expressions.Experssion, error) {
+	expr, err := p.bitXor()
+	if err != nil {
+		return expressions.Binary{}, err
+	}
+
+	for p.match(scanner.PIPE) {
+		operator := p.previous()
+		right, err := p.bitXor()
+		if err != nil {
+			return expressions.Binary{}, err
+		}
+		expr = expressions.Binary{
+			Left:     expr,
+			Right:    right,
+			Operator: operator,
+		}
+	}
+	return expr, err
+}
+
+// bitXor         → bitAnd ( "^" bitAnd )* ;
+func (p *Parser) bitXor() (expressions.Experssion, error) {
+	expr, err := p.bitAnd()
+	if err != nil {
+		return expressions.Binary{}, err
+	}
+
+	for p.match(scanner.CARET) {
+		operator := p.previous()
+		right, err := p.bitAnd()
+		if err != nil {
+			return expressions.Binary{}, err
+		}
+		expr = expressions.Binary{
+			Left:     expr,
+			Right:    right,
+			Operator: operator,
+		}
+	}
+	return expr, err
+}
+
+// bitAnd         → shift ( "&" shift )* ;
+func (p *Parser) bitAnd() (expressions.Experssion, error) {
+	expr, err := p.shift()
+	if err != nil {
+		return expressions.Binary{}, err
+	}
+
+	for p.match(scanner.AMPERSAND) {
+		operator := p.previous()
+		right, err := p.shift()
+		if err != nil {
+			return expressions.Binary{}, err
+		}
+		expr = expressions.Binary{
+			Left:     expr,
+			Right:    right,
+			Operator: operator,
+		}
+	}
+	return expr, err
+}
+
+// shift          → term ( ( "<<" | ">>" ) term )* ;
+func (p *Parser) shift() (expressions.Experssion, error) {
+	expr, err := p.term()
+	if err != nil {
+		return expressions.Binary{}, err
+	}
+
+	for p.match(scanner.LESS_LESS, scanner.GREATER_GREATER) {
 		operator := p.previous()
 		right, err := p.term()
 		if err != nil {
@@ -589,14 +1181,14 @@ func (p *Parser) term() (expressions.Experssion, error) {
 	return expr, err
 }
 
-// factor         → unary ( ( "/" | "*" ) unary )* ;
+// factor         → unary ( ( "/" | "*" | "%" ) unary )* ;
 func (p *Parser) factor() (expressions.Experssion, error) {
 	expr, err := p.unary()
 	if err != nil {
 		return expressions.Binary{}, err
 	}
 
-	for p.match(scanner.SLASH, scanner.STAR) {
+	for p.match(scanner.SLASH, scanner.STAR, scanner.PERCENT) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -611,9 +1203,9 @@ func (p *Parser) factor() (expressions.Experssion, error) {
 	return expr, err
 }
 
-// unary          → ( "!" | "-" ) unary | call ;
+// unary          → ( "!" | "-" | "~" | "++" | "--" ) unary | power ;
 func (p *Parser) unary() (expressions.Experssion, error) {
-	if p.match(scanner.BANG, scanner.MINUS) {
+	if p.match(scanner.BANG, scanner.MINUS, scanner.TILDE) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -624,15 +1216,61 @@ func (p *Parser) unary() (expressions.Experssion, error) {
 			Right:    right,
 		}, nil
 	}
-	return p.call()
+	// ++x and --x are x += 1 and x -= 1
+	if p.match(scanner.PLUS_PLUS, scanner.MINUS_MINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return p.update(target, operator, expressions.Literal{Value: int64(1)}, false)
+	}
+	return p.power()
+}
+
+// the exponent is a unary so ** is right associative and binds tighter than
+// a minus on its left, -2 ** 2 is -(2 ** 2)
+// power          → postfix ( "**" unary )? ;
+func (p *Parser) power() (expressions.Experssion, error) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(scanner.STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return expressions.Binary{
+			Left:     expr,
+			Right:    right,
+			Operator: operator,
+		}, nil
+	}
+	return expr, nil
+}
+
+// postfix        → call ( "++" | "--" )? ;
+func (p *Parser) postfix() (expressions.Experssion, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(scanner.PLUS_PLUS, scanner.MINUS_MINUS) {
+		return p.update(expr, p.previous(), expressions.Literal{Value: int64(1)}, true)
+	}
+	return expr, nil
 }
 
-// call           → primary ( "(" arguments? ")" )* ;
+// a chain holding an optional access is wrapped into an OptionalChain
+// call           → primary ( "(" arguments? ")" | ( "." | "?." ) IDENTIFIER | "[" subscript "]" )* ;
 func (p *Parser) call() (expressions.Experssion, error) {
 	expr, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
+	optional := false
 
 	for {
 		if p.match(scanner.LEFT_PAREN) {
@@ -647,11 +1285,27 @@ func (p *Parser) call() (expressions.Experssion, error) {
 				return nil, err
 			}
 			expr = expressions.PropertyAccess{Name: name, Obj: expr}
+		} else if p.match(scanner.QUESTION_DOT) {
+			name, err := p.consume(scanner.IDENTIFIER, "Expect property name after '?.'")
+			if err != nil {
+				return nil, err
+			}
+			expr = expressions.PropertyAccess{Name: name, Obj: expr, Optional: true}
+			optional = true
+		} else if p.match(scanner.LEFT_BRACKET) {
+			expr1, err := p.subscript(expr)
+			expr = expr1
+			if err != nil {
+				return nil, err
+			}
 		} else {
 			break
 		}
 	}
 
+	if optional {
+		return expressions.OptionalChain{Expr: expr}, nil
+	}
 	return expr, nil
 }
 
@@ -682,7 +1336,148 @@ func (p *Parser) followCall(callee expressions.Experssion) (expressions.Experssi
 	}, nil
 }
 
-// primary        → NUMBER | STRING | "true" | "false" | "nil" | "(" expression ")" | "super" "." IDENTIFIER ;
+// subscript      → expression | expression? ":" expression? ;
+func (p *Parser) subscript(obj expressions.Experssion) (expressions.Experssion, error) {
+	bracket := p.previous()
+	var start expressions.Experssion
+	if !p.check(scanner.COLON) {
+		expr, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		start = expr
+	}
+
+	if p.match(scanner.COLON) {
+		var end expressions.Experssion
+		if !p.check(scanner.RIGHT_BRACKET) {
+			expr, err := p.experssion()
+			if err != nil {
+				return nil, err
+			}
+			end = expr
+		}
+		_, err := p.consume(scanner.RIGHT_BRACKET, "Expect ']' after slice")
+		if err != nil {
+			return nil, err
+		}
+		return expressions.Slice{Obj: obj, Bracket: bracket, Start: start, End: end}, nil
+	}
+
+	_, err := p.consume(scanner.RIGHT_BRACKET, "Expect ']' after index")
+	if err != nil {
+		return nil, err
+	}
+	return expressions.Index{Obj: obj, Bracket: bracket, Index: start}, nil
+}
+
+// list           → "[" ( expression ( "," expression )* ","? )? "]" ;
+func (p *Parser) list() (expressions.Experssion, error) {
+	bracket := p.previous()
+	elements := []expressions.Experssion{}
+	for !p.check(scanner.RIGHT_BRACKET) {
+		expr, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, expr)
+		if !p.match(scanner.COMMA) {
+			break
+		}
+	}
+	_, err := p.consume(scanner.RIGHT_BRACKET, "Expect ']' after list elements")
+	if err != nil {
+		return nil, err
+	}
+	return expressions.ListLiteral{Bracket: bracket, Elements: elements}, nil
+}
+
+// map            → "{" ( expression ":" expression ( "," expression ":" expression )* ","? )? "}" ;
+func (p *Parser) mapLiteral() (expressions.Experssion, error) {
+	brace := p.previous()
+	keys := []expressions.Experssion{}
+	values := []expressions.Experssion{}
+	for !p.check(scanner.RIGHT_BRACE) {
+		key, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(scanner.COLON, "Expect ':' after map key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		if !p.match(scanner.COMMA) {
+			break
+		}
+	}
+	_, err := p.consume(scanner.RIGHT_BRACE, "Expect '}' after map entries")
+	if err != nil {
+		return nil, err
+	}
+	return expressions.MapLiteral{Brace: brace, Keys: keys, Values: values}, nil
+}
+
+// lambda         → "func" "(" parameters? ")" block ;
+func (p *Parser) lambda() (expressions.Experssion, error) {
+	keyword := p.previous()
+	_, err := p.consume(scanner.LEFT_PAREN, "Expect '(' after 'func'.")
+	if err != nil {
+		return nil, err
+	}
+	function, err := p.functionRest("function", expressions.Token{})
+	if err != nil {
+		return nil, err
+	}
+	return expressions.Lambda{Keyword: keyword, Function: function}, nil
+}
+
+// arrow          → ( IDENTIFIER | "(" parameters? ")" ) "=>" ( expression | block ) ;
+// called after the parameters and the '=>' were consumed, an expression body is sugar for a block that returns it
+func (p *Parser) arrow(function statements.FunctionStatement) (expressions.Experssion, error) {
+	arrow := p.previous()
+	var body []statements.Statement
+	if p.match(scanner.LEFT_BRACE) {
+		stmts, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		body = stmts
+	} else {
+		value, err := p.experssion()
+		if err != nil {
+			return nil, err
+		}
+		body = []statements.Statement{statements.ReturnStatement{Keyword: arrow, Value: value}}
+	}
+	function.Body = body
+	return expressions.Lambda{Keyword: arrow, Function: function}, nil
+}
+
+// looks ahead after an opening parenthesis for the closing one followed by '=>'
+// to tell an arrow function apart from a grouping
+func (p *Parser) isArrowAhead() bool {
+	depth := 1
+	for i := p.current; p.tokens[i].Kind != scanner.EOF; i++ {
+		switch p.tokens[i].Kind {
+		case scanner.LEFT_PAREN:
+			depth++
+		case scanner.RIGHT_PAREN:
+			depth--
+			if depth == 0 {
+				return p.tokens[i+1].Kind == scanner.ARROW
+			}
+		}
+	}
+	return false
+}
+
+// primary        → NUMBER | STRING | "true" | "false" | "nil" | "(" expression ")" | "super" "." IDENTIFIER | list | map | lambda | arrow ;
 func (p *Parser) primary() (expressions.Experssion, error) {
 	switch {
 	case p.match(scanner.FALSE):
@@ -693,8 +1488,21 @@ func (p *Parser) primary() (expressions.Experssion, error) {
 		return expressions.Literal{Value: nil}, nil
 	case p.match(scanner.NUMBER, scanner.STRING):
 		return p.previous().Literal, nil
+	case p.check(scanner.IDENTIFIER) && p.checkNext(scanner.ARROW):
+		param := p.advance()
+		p.advance()
+		return p.arrow(statements.FunctionStatement{Args: []expressions.Token{param}})
 	case p.match(scanner.LEFT_PAREN):
 		{
+			if p.isArrowAhead() {
+				function, err := p.parameters()
+				if err != nil {
+					return nil, err
+				}
+				// consume '=>'
+				p.advance()
+				return p.arrow(function)
+			}
 			expr, err1 := p.experssion()
 			if err1 != nil {
 				return expressions.Grouping{}, err1
@@ -706,7 +1514,7 @@ func (p *Parser) primary() (expressions.Experssion, error) {
 			return expressions.Grouping{Expr: expr}, nil
 		}
 	case p.match(scanner.THIS):
-		return expressions.This{Keywork: p.previous()}, nil
+		return expressions.This{Keywork: p.previous(), Uuid: varUuid.gen()}, nil
 	case p.match(scanner.IDENTIFIER):
 		return expressions.Variable{Token: p.previous(), Uuid: varUuid.gen()}, nil
 	case p.match(scanner.SUPER):
@@ -722,8 +1530,17 @@ func (p *Parser) primary() (expressions.Experssion, error) {
 		return expressions.Super{
 			Keyword: keyword,
 			Method:  method,
+			Uuid:    varUuid.gen(),
 		}, nil
-	}
+	case p.match(scanner.LEFT_BRACKET):
+		return p.list()
+	case p.match(scanner.FUNC):
+		return p.lambda()
+	// a brace at the start of a statement is a block, so this is only reached in an expression
+	case p.match(scanner.LEFT_BRACE):
+		return p.mapLiteral()
+	}
+	p.error(p.peek(), "Expect expression.", "")
 	return expressions.Grouping{}, ErrorParsing
 }
 
@@ -733,17 +1550,24 @@ func (p *Parser) consume(tokenType expressions.TokenType, msg string) (expressio
 	if p.check(tokenType) {
 		return p.advance(), nil
 	}
-	if p.peek().Kind == scanner.EOF {
-		msg = fmt.Sprintf(" at end %s", msg)
-		reporting.ReportError(p.peek().Line, msg)
-	} else {
-		msg = fmt.Sprintf(" at '%s' %s", p.peek().Lexeme, msg)
-		reporting.ReportError(p.peek().Line, msg)
+	hint := ""
+	// a missing semicolon is noticed on the token after it, usually on the next line
+	if tokenType == scanner.SEMICOLON && p.current > 0 && p.previous().Line < p.peek().Line {
+		hint = fmt.Sprintf("add ';' after '%s' at the end of line %d", p.previous().Lexeme, p.previous().Line)
 	}
-
+	p.error(p.peek(), msg, hint)
 	return expressions.Token{}, ErrorParsing
 }
 
+// reports a syntax error at the token
+func (p *Parser) error(tok expressions.Token, msg string, hint string) {
+	where := fmt.Sprintf("at '%s'", tok.Lexeme)
+	if tok.Kind == scanner.EOF {
+		where = "at end"
+	}
+	reporting.ReportTokenHint(tok, where, msg, hint)
+}
+
 // discard tokens until the beginning of the next statement
 func (p *Parser) synchronize() {
 	p.advance()
@@ -758,7 +1582,7 @@ func (p *Parser) synchronize() {
 			return
 		case scanner.FUNC:
 			return
-		case scanner.LET:
+		case scanner.LET, scanner.CONST:
 			return
 		case scanner.FOR:
 			return
@@ -770,6 +1594,16 @@ func (p *Parser) synchronize() {
 			return
 		case scanner.RETURN:
 			return
+		case scanner.TRY:
+			return
+		case scanner.SWITCH, scanner.CASE, scanner.DEFAULT:
+			return
+		case scanner.THROW:
+			return
+		case scanner.IMPORT:
+			return
+		case scanner.FROM:
+			return
 		}
 		p.advance()
 	}
@@ -795,6 +1629,14 @@ func (p *Parser) check(tokenType expressions.TokenType) bool {
 	return p.peek().Kind == tokenType
 }
 
+// checks the token after the current one without consuming anything
+func (p *Parser) checkNext(tokenType expressions.TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].Kind == scanner.EOF {
+		return false
+	}
+	return p.tokens[p.current+1].Kind == tokenType
+}
+
 // consume the current token and return it
 func (p *Parser) advance() expressions.Token {
 	if !p.isAtEnd() {
@@ -827,13 +1669,7 @@ func (pv PrintVisitor) Print(expr expressions.Experssion) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	refVal := reflect.ValueOf(v)
-	if refVal.Kind() != reflect.String {
-		return "", ErrorPrinterVisitor
-	}
-
-	return refVal.String(), nil
-
+	return pv.format(v), nil
 }
 
 // visit binary expression
@@ -846,11 +1682,14 @@ func (pv PrintVisitor) VisitGrouping(expr expressions.Grouping) (interface{}, er
 	return pv.parenthesize("group", expr.Expr)
 }
 
-// visit literal and reflect its value
+// visit literal and reflect its value, strings are quoted to tell them apart from names
 func (pv PrintVisitor) VisitLiteral(expr expressions.Literal) (interface{}, error) {
 	if expr.Value == nil {
 		return "nil", nil
 	}
+	if s, ok := expr.Value.(string); ok {
+		return strconv.Quote(s), nil
+	}
 	return expr.Value, nil
 
 }
@@ -860,71 +1699,157 @@ func (pv PrintVisitor) VisitUnary(expr expressions.Unary) (interface{}, error) {
 	return pv.parenthesize(expr.Operator.Lexeme, expr.Right)
 }
 
-// not implemented
 func (pv PrintVisitor) VisitVairable(expr expressions.Variable) (interface{}, error) {
-	return nil, nil
+	return expr.Token.Lexeme, nil
 }
 
-// not implemented
 func (pv PrintVisitor) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
-	return nil, nil
+	return pv.form(assignmentForm(expr.Operator, expr.Postfix), expr.Token.Lexeme, expr.Value)
+}
+
+// the name of the form printed for an assignment e.g. "=", "+=", "++" or "post++" for x++
+func assignmentForm(operator expressions.Token, postfix bool) string {
+	if operator.Lexeme == "" {
+		return "="
+	}
+	if postfix {
+		return "post" + operator.Lexeme
+	}
+	return operator.Lexeme
 }
 
-// not implemented
 func (pv PrintVisitor) VisitLogical(expr expressions.Logical) (interface{}, error) {
-	return nil, nil
+	return pv.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right)
 }
 
-// not implemented
 func (pv PrintVisitor) VisitCall(expr expressions.Call) (interface{}, error) {
-	return nil, nil
+	return pv.parenthesize("call", append([]expressions.Experssion{expr.Callee}, expr.Args...)...)
 }
 
-// not implemented
 func (pv PrintVisitor) VisitPropertyAccess(expr expressions.PropertyAccess) (interface{}, error) {
-	return nil, nil
+	if expr.Optional {
+		return pv.form("?.", expr.Obj, expr.Name.Lexeme)
+	}
+	return pv.form(".", expr.Obj, expr.Name.Lexeme)
+}
+
+func (pv PrintVisitor) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	return pv.parenthesize("?:", expr.Condition, expr.Then, expr.Else)
+}
+
+func (pv PrintVisitor) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	return pv.parenthesize("??", expr.Left, expr.Right)
+}
+
+func (pv PrintVisitor) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	return pv.parenthesize("chain", expr.Expr)
 }
 
-// not implemented
 func (pv PrintVisitor) VisitPropertyAssignment(expr expressions.PropertyAssignment) (interface{}, error) {
-	return nil, nil
+	return pv.form("."+assignmentForm(expr.Operator, expr.Postfix), expr.Obj, expr.Name.Lexeme, expr.Value)
 }
 
-// not implemented
 func (pv PrintVisitor) VisitThis(expr expressions.This) (interface{}, error) {
-	return nil, nil
+	return "this", nil
 }
 
-// not implemented
 func (pv PrintVisitor) VisitSuper(expr expressions.Super) (interface{}, error) {
-	return nil, nil
+	return pv.form("super", expr.Method.Lexeme)
+}
+
+func (pv PrintVisitor) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	return pv.parenthesize("list", expr.Elements...)
+}
+
+func (pv PrintVisitor) VisitIndex(expr expressions.Index) (interface{}, error) {
+	return pv.parenthesize("index", expr.Obj, expr.Index)
+}
+
+func (pv PrintVisitor) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	return pv.parenthesize("index=", expr.Obj, expr.Index, expr.Value)
+}
+
+// omitted bounds are printed as _
+func (pv PrintVisitor) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	parts := []interface{}{expr.Obj, "_", "_"}
+	if expr.Start != nil {
+		parts[1] = expr.Start
+	}
+	if expr.End != nil {
+		parts[2] = expr.End
+	}
+	return pv.form("slice", parts...)
+}
+
+// the keys and values are printed in pairs
+func (pv PrintVisitor) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	parts := []interface{}{}
+	for i := range expr.Keys {
+		entry, err := pv.parenthesize(":", expr.Keys[i], expr.Values[i])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, entry)
+	}
+	return pv.form("map", parts...)
+}
+
+// the body is statements so only the parameters are printed
+func (pv PrintVisitor) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	params := []string{}
+	if function, ok := expr.Function.(statements.FunctionStatement); ok {
+		for _, param := range function.Args {
+			params = append(params, param.Lexeme)
+		}
+		if function.Variadic {
+			params[len(params)-1] = "..." + params[len(params)-1]
+		}
+	}
+	return pv.form("lambda", "("+strings.Join(params, " ")+")")
 }
 
 // stringify the expressions into single string builder and return its accumulated string.
-// uses reflection to reflect the expressions value:
 // output e.g. (+ 2 3)
 func (pv PrintVisitor) parenthesize(name string, expr ...expressions.Experssion) (string, error) {
+	parts := make([]interface{}, 0, len(expr))
+	for _, e := range expr {
+		parts = append(parts, e)
+	}
+	return pv.form(name, parts...)
+}
+
+// like parenthesize, parts are expressions or strings printed as they are (e.g. names)
+func (pv PrintVisitor) form(name string, parts ...interface{}) (string, error) {
 	var sb strings.Builder
 	sb.WriteString("(")
 	sb.WriteString(name)
 
-	for _, v := range expr {
+	for _, part := range parts {
 		sb.WriteString(" ")
-		v, err := v.Accept(pv)
+		if s, ok := part.(string); ok {
+			sb.WriteString(s)
+			continue
+		}
+		v, err := part.(expressions.Experssion).Accept(pv)
 		if err != nil {
 			return "", ErrorPrinterVisitor
 		}
-		refValue := reflect.ValueOf(v)
-		if refValue.Kind() == reflect.Float64 {
-			val := refValue.Float()
-			s := fmt.Sprintf("%f", val)
-			sb.WriteString(s)
-		} else {
-			sb.WriteString(refValue.String())
-		}
-
+		sb.WriteString(pv.format(v))
 	}
 	sb.WriteString(")")
 
 	return sb.String(), nil
 }
+
+// uses reflection to reflect the expressions value
+func (pv PrintVisitor) format(v interface{}) string {
+	refValue := reflect.ValueOf(v)
+	if refValue.Kind() == reflect.Int64 {
+		return fmt.Sprintf("%d", refValue.Int())
+	} else if refValue.Kind() == reflect.Float64 {
+		return fmt.Sprintf("%f", refValue.Float())
+	} else if refValue.Kind() == reflect.Bool {
+		return fmt.Sprintf("%t", refValue.Bool())
+	}
+	return refValue.String()
+}
@@ -4,6 +4,8 @@ import "github.com/Ahmed-Sermani/prolang/parser/expressions"
 
 type Statement interface {
 	Accept(StatementVisitor) error
+	// the source line the statement starts at
+	Line() int
 }
 
 type StatementVisitor interface {
@@ -16,36 +18,63 @@ type StatementVisitor interface {
 	VisitFunctionStmt(FunctionStatement) error
 	VisitReturnStmt(ReturnStatement) error
 	VisitClassStmt(ClassStatement) error
+	VisitBreakStmt(BreakStatement) error
+	VisitContinueStmt(ContinueStatement) error
+	VisitThrowStmt(ThrowStatement) error
+	VisitTryStmt(TryStatement) error
+	VisitImportStmt(ImportStatement) error
+	VisitSwitchStmt(SwitchStatement) error
 }
 
 type PrintStatement struct {
 	Statement
-	Expr expressions.Experssion
+	Keyword expressions.Token
+	Expr    expressions.Experssion
 }
 
 func (p PrintStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitPrintStmt(p)
 }
 
+func (p PrintStatement) Line() int {
+	return p.Keyword.Line
+}
+
+// expressions don't share a token type so the line of the first token is kept
 type ExperssionStatement struct {
 	Statement
-	Expr expressions.Experssion
+	Expr     expressions.Experssion
+	StartsAt int
 }
 
 func (e ExperssionStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitExprStmt(e)
 }
 
+func (e ExperssionStatement) Line() int {
+	return e.StartsAt
+}
+
 type VarDecStatement struct {
-	Token       expressions.Token
+	Token expressions.Token
+	// the type annotation, the zero token when the variable isn't annotated
+	Type        expressions.Token
 	Initializer expressions.Experssion
+	// declared with 'const', the variable can't be assigned and always has an initializer
+	Constant bool
 }
 
 func (v VarDecStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitVarDecStmt(v)
 }
 
+func (v VarDecStatement) Line() int {
+	return v.Token.Line
+}
+
+// Brace is the opening brace, desugared for loops use the 'for' keyword instead
 type BlockStatement struct {
+	Brace      expressions.Token
 	Statements []Statement
 }
 
@@ -53,7 +82,12 @@ func (s BlockStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitBlockStmt(s)
 }
 
+func (s BlockStatement) Line() int {
+	return s.Brace.Line
+}
+
 type IfStatement struct {
+	Keyword    expressions.Token
 	Condition  expressions.Experssion
 	ThenBranch Statement
 	ElseBranch Statement
@@ -63,25 +97,82 @@ func (i IfStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitIfStmt(i)
 }
 
+func (i IfStatement) Line() int {
+	return i.Keyword.Line
+}
+
+// for loops are desugared into while loops, the increment is kept apart from the body
+// so 'continue' can still run it. Label is empty for unlabeled loops.
+// Keyword is either the 'while' or the 'for' keyword.
 type WhileStatement struct {
+	Keyword   expressions.Token
 	Condition expressions.Experssion
 	Body      Statement
+	Increment expressions.Experssion
+	Label     expressions.Token
 }
 
 func (w WhileStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitWhileStmt(w)
 }
 
+func (w WhileStatement) Line() int {
+	return w.Keyword.Line
+}
+
 type FunctionStatement struct {
 	Name expressions.Token
 	Args []expressions.Token
-	Body []Statement
+	// the type annotations of the parameters and of the return value,
+	// the zero token for the ones that aren't annotated. Types may be shorter than Args
+	Types      []expressions.Token
+	ReturnType expressions.Token
+	Body       []Statement
+	// the default values of the parameters, nil for the ones without a default.
+	// Defaults may be shorter than Args, the defaults are evaluated on each call
+	Defaults []expressions.Experssion
+	// the last parameter collects the extra arguments
+	Variadic bool
+	// declared with 'const', the name of the function can't be assigned
+	Constant bool
 }
 
 func (f FunctionStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitFunctionStmt(f)
 }
 
+func (f FunctionStatement) Line() int {
+	return f.Name.Line
+}
+
+// the number of arguments the function accepts, max is -1 when it accepts any number of extra arguments
+func (f FunctionStatement) Arity() (min int, max int) {
+	fixed := len(f.Args)
+	if f.Variadic {
+		fixed--
+	}
+	// the parameters with a default come after the ones without one
+	min = fixed
+	for i, def := range f.Defaults {
+		if def != nil {
+			min = i
+			break
+		}
+	}
+	if f.Variadic {
+		return min, -1
+	}
+	return min, fixed
+}
+
+// the default value of the parameter, nil when it has none
+func (f FunctionStatement) Default(i int) expressions.Experssion {
+	if i < len(f.Defaults) {
+		return f.Defaults[i]
+	}
+	return nil
+}
+
 // It stores the return keyword token for error reporting if needed, and the value being returned
 type ReturnStatement struct {
 	Keyword expressions.Token
@@ -92,12 +183,127 @@ func (r ReturnStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitReturnStmt(r)
 }
 
+func (r ReturnStatement) Line() int {
+	return r.Keyword.Line
+}
+
+// getters are parameterless methods invoked on property access.
+// static methods and fields belong to the class itself
 type ClassStatement struct {
-	Name       expressions.Token
-	Methods    []FunctionStatement
-	Superclass expressions.Variable
+	Name          expressions.Token
+	Methods       []FunctionStatement
+	Getters       []FunctionStatement
+	StaticMethods []FunctionStatement
+	StaticFields  []VarDecStatement
+	Superclass    expressions.Variable
+	// declared with 'const', the name of the class can't be assigned
+	Constant bool
 }
 
 func (c ClassStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitClassStmt(c)
 }
+
+func (c ClassStatement) Line() int {
+	return c.Name.Line
+}
+
+// Label is empty when the innermost loop is targeted
+type BreakStatement struct {
+	Keyword expressions.Token
+	Label   expressions.Token
+}
+
+func (b BreakStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitBreakStmt(b)
+}
+
+func (b BreakStatement) Line() int {
+	return b.Keyword.Line
+}
+
+type ContinueStatement struct {
+	Keyword expressions.Token
+	Label   expressions.Token
+}
+
+func (c ContinueStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitContinueStmt(c)
+}
+
+func (c ContinueStatement) Line() int {
+	return c.Keyword.Line
+}
+
+// It stores the throw keyword token to locate uncaught errors
+type ThrowStatement struct {
+	Keyword expressions.Token
+	Value   expressions.Experssion
+}
+
+func (t ThrowStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitThrowStmt(t)
+}
+
+func (t ThrowStatement) Line() int {
+	return t.Keyword.Line
+}
+
+// CatchBody is nil when there's no catch clause, FinallyBody is nil when there's no finally clause
+type TryStatement struct {
+	Keyword     expressions.Token
+	Body        []Statement
+	CatchName   expressions.Token
+	CatchBody   []Statement
+	FinallyBody []Statement
+}
+
+func (t TryStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitTryStmt(t)
+}
+
+func (t TryStatement) Line() int {
+	return t.Keyword.Line
+}
+
+// a clause of a switch statement, it runs when one of its values equals the switch value
+type SwitchCase struct {
+	Keyword expressions.Token
+	Values  []expressions.Experssion
+	Body    []Statement
+}
+
+// only the first matching case runs, there's no fallthrough. each case body is a scope of its own.
+// Default is nil when there's no default clause, DefaultKeyword is the zero token then
+type SwitchStatement struct {
+	Keyword        expressions.Token
+	Value          expressions.Experssion
+	Cases          []SwitchCase
+	DefaultKeyword expressions.Token
+	Default        []Statement
+}
+
+func (s SwitchStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitSwitchStmt(s)
+}
+
+func (s SwitchStatement) Line() int {
+	return s.Keyword.Line
+}
+
+// import "path" as alias; binds the whole module, Alias is empty when omitted.
+// from "path" import a, b; binds each name in Names.
+type ImportStatement struct {
+	Keyword expressions.Token
+	Path    expressions.Token
+	Alias   expressions.Token
+	Names   []expressions.Token
+}
+
+func (i ImportStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitImportStmt(i)
+}
+
+func (i ImportStatement) Line() int {
+	return i.Keyword.Line
+}
@@ -0,0 +1,255 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/modules"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/parser/statements"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+const (
+	prompt       = "> "
+	continuation = "... "
+)
+
+const help = `commands:
+  :reset           forget every definition
+  :env             print the globals defined in the session
+  :load FILE       run the file in the session
+  :ast EXPR        print the syntax tree of an expression
+  :quit            leave the prompt (or Ctrl-D)
+  :help            show this help`
+
+// interactive prompt keeping its definitions between inputs.
+// An input is read until its parentheses, brackets and braces are balanced,
+// the value of an expression typed on its own (with or without a semicolon) is printed.
+type REPL struct {
+	inter    *interpreter.Interpreter
+	resolver *resolver.Resolver
+	in       *bufio.Scanner
+	out      io.Writer
+}
+
+func New(in io.Reader, out io.Writer) *REPL {
+	r := &REPL{
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
+	r.reset()
+	return r
+}
+
+// starts a new session, the definitions of the previous one are dropped
+func (r *REPL) reset() {
+	r.inter = interpreter.New()
+	r.inter.SetOutput(r.out)
+	r.inter.SetImporter(modules.New())
+	r.resolver = resolver.New(r.inter)
+}
+
+// reads and runs inputs until the end of the input or :quit
+func (r *REPL) Run() error {
+	for {
+		source, ok := r.read()
+		if !ok {
+			// leaves the cursor on a new line after Ctrl-D
+			fmt.Fprintln(r.out)
+			return r.in.Err()
+		}
+		if strings.HasPrefix(strings.TrimSpace(source), ":") {
+			if quit := r.command(strings.TrimSpace(source)); quit {
+				return nil
+			}
+			continue
+		}
+		r.eval(source, "")
+	}
+}
+
+// reads an input, lines are added while it's incomplete. false at the end of the input
+func (r *REPL) read() (string, bool) {
+	fmt.Fprint(r.out, prompt)
+	lines := []string{}
+	for r.in.Scan() {
+		lines = append(lines, r.in.Text())
+		source := strings.Join(lines, "\n")
+		// commands are a single line
+		if strings.HasPrefix(strings.TrimSpace(source), ":") || complete(source) {
+			return source, true
+		}
+		fmt.Fprint(r.out, continuation)
+	}
+	return "", false
+}
+
+// reports whether the source is a whole input: its strings are terminated
+// and its parentheses, brackets and braces are closed
+func complete(source string) bool {
+	depth := 0
+	diagnostics := reporting.Collect(func() {
+		for _, tok := range scanner.New(source).ScanTokens() {
+			switch tok.Kind {
+			case scanner.LEFT_PAREN, scanner.LEFT_BRACKET, scanner.LEFT_BRACE:
+				depth++
+			case scanner.RIGHT_PAREN, scanner.RIGHT_BRACKET, scanner.RIGHT_BRACE:
+				depth--
+			}
+		}
+	})
+	for _, d := range diagnostics {
+		if d.Msg == "Unterminated string." {
+			return false
+		}
+	}
+	// extra closing brackets are left to the parser to report
+	return depth <= 0
+}
+
+// runs the source in the session, the value of a trailing expression statement is printed
+func (r *REPL) eval(source string, file string) {
+	// a mistake shouldn't end the session
+	defer reporting.UnsetError()
+
+	s := scanner.New(source)
+	// the input typed at the prompt has no file, its lines are still shown with the errors
+	s.SetFile(file)
+	tokens := s.ScanTokens()
+	if reporting.HadError() {
+		return
+	}
+	// an expression typed without a semicolon isn't a statement, it's only evaluated
+	if file == "" {
+		var expr expressions.Experssion
+		diagnostics := reporting.Collect(func() {
+			expr, _ = parser.New(tokens).ParseExpression()
+		})
+		if len(diagnostics) == 0 && expr != nil {
+			r.resolver.Resolve([]statements.Statement{statements.ExperssionStatement{Expr: expr}})
+			if !reporting.HadError() {
+				r.show(expr)
+			}
+			return
+		}
+	}
+
+	stmts := parser.New(tokens).Parse()
+	if reporting.HadError() {
+		return
+	}
+	r.resolver.Resolve(stmts)
+	if reporting.HadError() {
+		return
+	}
+
+	// loaded files run like scripts
+	if len(stmts) == 0 || file != "" {
+		r.inter.Interpret(stmts)
+		return
+	}
+	last, ok := stmts[len(stmts)-1].(statements.ExperssionStatement)
+	if !ok {
+		r.inter.Interpret(stmts)
+		return
+	}
+	if err := r.inter.Interpret(stmts[:len(stmts)-1]); err != nil {
+		return
+	}
+	r.show(last.Expr)
+}
+
+// evaluates the expression and prints its value
+func (r *REPL) show(expr expressions.Experssion) {
+	value, err := r.inter.Evaluate(expr)
+	if err != nil {
+		r.inter.Report(err)
+		return
+	}
+	// calls of functions without a return value print nothing
+	if value != nil {
+		fmt.Fprintln(r.out, repr(value))
+	}
+}
+
+// runs a meta command, reports whether the session ends
+func (r *REPL) command(line string) bool {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i != -1 {
+		name, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+	switch name {
+	case ":quit", ":q":
+		return true
+	case ":reset":
+		r.reset()
+		fmt.Fprintln(r.out, "session reset")
+	case ":env":
+		r.printGlobals()
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(r.out, "usage: :load FILE")
+			break
+		}
+		bytes, err := ioutil.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintf(r.out, "can't read %s: %s\n", arg, err)
+			break
+		}
+		r.eval(string(bytes), arg)
+	case ":ast":
+		r.printTree(arg)
+	case ":help", ":h":
+		fmt.Fprintln(r.out, help)
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, type :help for the commands\n", name)
+	}
+	return false
+}
+
+// the globals are the top level scope, built-ins are left out
+func (r *REPL) printGlobals() {
+	globals := r.inter.Environment()
+	names := globals.Names()
+	if len(names) == 0 {
+		fmt.Fprintln(r.out, "(empty)")
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, _ := globals.Lookup(name)
+		fmt.Fprintf(r.out, "%s = %s\n", name, repr(value))
+	}
+}
+
+func (r *REPL) printTree(source string) {
+	defer reporting.UnsetError()
+	expr, err := parser.New(scanner.New(source).ScanTokens()).ParseExpression()
+	if err != nil || reporting.HadError() {
+		return
+	}
+	tree, err := parser.PrintVisitor{}.Print(expr)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	fmt.Fprintln(r.out, tree)
+}
+
+// strings are quoted so they can be told apart from the other values
+func repr(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return interpreter.Stringify(value)
+}
@@ -0,0 +1,70 @@
+package repl_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/repl"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+)
+
+// runs a session reading the input, it returns what was printed and the reported errors
+func session(t *testing.T, input string) (string, string) {
+	t.Helper()
+	var out, errs bytes.Buffer
+	reporting.SetOutput(&errs)
+	defer reporting.SetOutput(os.Stderr)
+	if err := repl.New(strings.NewReader(input), &out).Run(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return out.String(), errs.String()
+}
+
+func TestMultiLineInput(t *testing.T) {
+	input := "func add(a, b) {\n  return a + b;\n}\nadd(1, 2)\nlet xs = [\n  1,\n  \"two\"\n];\nxs\n\"multi\nline\"\n"
+	out, errs := session(t, input)
+	want := "> ... ... > 3\n" +
+		"> ... ... ... > [1, \"two\"]\n" +
+		"> ... \"multi\\nline\"\n" +
+		"> \n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if errs != "" {
+		t.Errorf("unexpected errors:\n%s", errs)
+	}
+}
+
+// errors are reported and the session goes on with its definitions
+func TestRecoversFromErrors(t *testing.T) {
+	input := "let x = 1;\nprint nope;\nlet = 1;\nfunc f() { return x + \"a\"; }\nf();\nx + 1\n"
+	out, errs := session(t, input)
+	if want := "> > > > > > 2\n> \n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	for _, msg := range []string{
+		"Undefined Variable 'nope'.",
+		"Expect variable name.",
+		"Operands must be two numbers or two strings",
+	} {
+		if !strings.Contains(errs, msg) {
+			t.Errorf("the error %q wasn't reported, got:\n%s", msg, errs)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	input := "let x = \"a\";\n:env\n:reset\n:env\n:ast 1 + 2\n:nope\n:quit\nprint 1;\n"
+	out, _ := session(t, input)
+	want := "> > x = \"a\"\n" +
+		"> session reset\n" +
+		"> (empty)\n" +
+		"> (+ 1 2)\n" +
+		"> unknown command :nope, type :help for the commands\n" +
+		"> "
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,143 @@
+package reporting
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+// a compile error, reported errors are rendered with the source line they point at
+// when the source of their file was registered with AddSource
+type Diagnostic struct {
+	File string
+	Line int
+	// 1-based byte column of the span, zero when only the line is known
+	Column int
+	// byte offset and length of the span in the source
+	Offset int
+	Length int
+	Where  string
+	Msg    string
+	// optional advice on fixing the error
+	Hint string
+	// the text of the span, the snippet is dropped if the registered source doesn't have it at the offset
+	// (e.g. a function defined by an earlier line of the interactive prompt)
+	lexeme string
+}
+
+// a diagnostic spanning the token
+func At(tok expressions.Token, where string, msg string) Diagnostic {
+	return Diagnostic{
+		File:   tok.File,
+		Line:   tok.Line,
+		Column: tok.Column,
+		Offset: tok.Offset,
+		Length: len(tok.Lexeme),
+		Where:  where,
+		Msg:    msg,
+		lexeme: tok.Lexeme,
+	}
+}
+
+// the source of each file by its name
+var sources = map[string]string{}
+
+// registers the source of the file so the diagnostics pointing into it show their line
+func AddSource(file string, source string) {
+	mu.Lock()
+	sources[file] = source
+	mu.Unlock()
+}
+
+// the diagnostic as it's reported: the location and the message followed by the source line
+// with the span underlined and the hint
+func (d Diagnostic) String() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return render(d, "Error")
+}
+
+// the diagnostic rendered as a warning, used by the tools reporting issues that don't stop the program (e.g. the linter)
+func (d Diagnostic) Warning() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return render(d, "Warning")
+}
+
+// the caller holds mu
+func render(d Diagnostic, kind string) string {
+	var b strings.Builder
+	switch {
+	case d.Line == 0:
+	case d.File != "" && d.Column != 0:
+		fmt.Fprintf(&b, "%s:%d:%d: ", d.File, d.Line, d.Column)
+	case d.File != "":
+		fmt.Fprintf(&b, "%s:%d: ", d.File, d.Line)
+	default:
+		fmt.Fprintf(&b, "[line %d] ", d.Line)
+	}
+	b.WriteString(kind)
+	if where := strings.TrimSpace(d.Where); where != "" {
+		b.WriteString(" " + where)
+	}
+	b.WriteString(": " + strings.TrimSpace(d.Msg))
+
+	// the gutter is as wide as the line number
+	gutter := strings.Repeat(" ", len(fmt.Sprint(d.Line))+1)
+	if line, ok := d.sourceLine(); ok {
+		fmt.Fprintf(&b, "\n %d | %s", d.Line, line)
+		if d.Column != 0 {
+			fmt.Fprintf(&b, "\n%s | %s", gutter, d.underline(line))
+		}
+	}
+	if d.Hint != "" {
+		fmt.Fprintf(&b, "\n%s = hint: %s", gutter, d.Hint)
+	}
+	return b.String()
+}
+
+// the source line the diagnostic points at, false if its source isn't registered
+func (d Diagnostic) sourceLine() (string, bool) {
+	source, ok := sources[d.File]
+	if !ok || d.Line == 0 {
+		return "", false
+	}
+	if d.Column != 0 && (d.Offset+len(d.lexeme) > len(source) || source[d.Offset:d.Offset+len(d.lexeme)] != d.lexeme) {
+		return "", false
+	}
+	lines := strings.Split(source, "\n")
+	if d.Line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[d.Line-1], "\r"), true
+}
+
+// the marker line under the source line, tabs are kept so the carets line up with the span
+func (d Diagnostic) underline(line string) string {
+	start := d.Column - 1
+	if start > len(line) {
+		start = len(line)
+	}
+	var b strings.Builder
+	for _, c := range line[:start] {
+		if c == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	// spans running over multiple lines (e.g. strings) are underlined up to the end of the first one
+	end := start + d.Length
+	if end > len(line) {
+		end = len(line)
+	}
+	width := utf8.RuneCountInString(line[start:end])
+	if width == 0 {
+		// the end of the input or an empty token
+		width = 1
+	}
+	b.WriteString(strings.Repeat("^", width))
+	return b.String()
+}
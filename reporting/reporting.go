@@ -1,8 +1,12 @@
 package reporting
 
 import (
-	"log"
+	"fmt"
+	"io"
+	"os"
 	"sync"
+
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
 )
 
 // safe
@@ -10,18 +14,89 @@ var hadError = false
 var hadRuntimeError = false
 var mu sync.Mutex
 
+// where the diagnostics are written
+var output io.Writer = os.Stderr
+
+// set while Collect runs, errors are appended to collected instead of being logged
+var collecting = false
+var collected []Diagnostic
+
+// serializes the callers of Collect
+var collectMu sync.Mutex
+
+func SetOutput(out io.Writer) {
+	mu.Lock()
+	output = out
+	mu.Unlock()
+}
+
+// reports an error only known by its line, prefer ReportToken when the token is at hand
 func ReportError(line int, msg string) {
 	Report(line, "", msg)
 }
 
 func Report(line int, where string, msg string) {
-	log.Printf("[line %d] Error %s: %s", line, where, msg)
-	SetError()
+	report(Diagnostic{Line: line, Where: where, Msg: msg})
 }
 
+// reports an error spanning the token, where locates it in words (e.g. "at 'x'")
+func ReportToken(tok expressions.Token, where string, msg string) {
+	report(At(tok, where, msg))
+}
+
+// reports an error spanning the token with an advice on fixing it
+func ReportTokenHint(tok expressions.Token, where string, msg string, hint string) {
+	d := At(tok, where, msg)
+	d.Hint = hint
+	report(d)
+}
+
+func report(d Diagnostic) {
+	mu.Lock()
+	defer mu.Unlock()
+	hadError = true
+	if collecting {
+		collected = append(collected, d)
+		return
+	}
+	fmt.Fprintln(output, render(d, "Error"))
+}
+
+// runtime errors that know the token they were raised at are shown with the source around it
 func ReportRuntimeError(err error) {
-	log.Println("Runtime Error: " + err.Error())
-	SetRuntimeError()
+	ReportRuntimeErrorTrace(err, nil)
+}
+
+// reports the runtime error followed by the stack trace, its lines go from the innermost call outwards
+func ReportRuntimeErrorTrace(err error, trace []string) {
+	d := Diagnostic{Msg: err.Error()}
+	if located, ok := err.(Located); ok {
+		d = At(located.Token(), "", located.Message())
+		if hinted, ok := err.(Hinted); ok {
+			d.Hint = hinted.Hint()
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	hadRuntimeError = true
+	fmt.Fprintln(output, render(d, "Runtime Error"))
+	if len(trace) != 0 {
+		fmt.Fprintln(output, "Stack trace:")
+		for _, line := range trace {
+			fmt.Fprintln(output, "    "+line)
+		}
+	}
+}
+
+// implemented by the runtime errors that know where they were raised
+type Located interface {
+	Message() string
+	Token() expressions.Token
+}
+
+// implemented by the runtime errors that can suggest a fix
+type Hinted interface {
+	Hint() string
 }
 
 func SetError() {
@@ -55,3 +130,26 @@ func HadRuntimeError() bool {
 	mu.Unlock()
 	return f
 }
+
+// runs f and returns the compile errors it reported instead of logging them.
+// the error flag is restored afterwards so tools analyzing sources (e.g. the language server) don't affect the program state
+func Collect(f func()) []Diagnostic {
+	collectMu.Lock()
+	defer collectMu.Unlock()
+
+	mu.Lock()
+	enclosing := hadError
+	hadError = false
+	collecting = true
+	mu.Unlock()
+
+	f()
+
+	mu.Lock()
+	diagnostics := collected
+	collected = nil
+	collecting = false
+	hadError = enclosing
+	mu.Unlock()
+	return diagnostics
+}
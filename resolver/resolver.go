@@ -28,6 +28,36 @@ type Resolver struct {
 	curft int
 	// track if it's in a method in class
 	curcls int
+	// labels of the enclosing loops in the current function, empty string for unlabeled loops
+	loops []string
+	// the tokens declaring the names of each scope in scopes
+	declarations []map[string]expressions.Token
+	// the names of each scope that can't be assigned, and the ones of the top level.
+	// a global assigned before it's declared is checked when the assignment runs
+	constants []map[string]bool
+	globals   map[string]bool
+	listener  Listener
+}
+
+// notified of the names the resolver declares and the variables referencing them.
+// used by editor tooling to link names to their declarations.
+type Listener interface {
+	// stmt is the declaring statement, nil for function parameters and catch variables
+	Declare(name expressions.Token, stmt statements.Statement)
+	// local is false for variables that are not resolved statically (globals and built-ins),
+	// declaration is only set for local variables
+	Reference(name expressions.Token, declaration expressions.Token, local bool)
+}
+
+// optional interface of listeners that also follow the structure the resolver walks, used by the linter
+type Inspector interface {
+	// function is set for the scope holding the parameters of a function
+	BeginScope(function bool)
+	EndScope()
+	// each list of statements the resolver walks: the program, blocks, function bodies and try clauses
+	Statements(stmts []statements.Statement)
+	// called instead of Reference for the variables being assigned to
+	Assign(name expressions.Token, declaration expressions.Token, local bool)
 }
 
 func New(inter *interpreter.Interpreter) *Resolver {
@@ -38,12 +68,17 @@ func New(inter *interpreter.Interpreter) *Resolver {
 		// Keys are variable names. The values are Booleans.
 		// the boolean value to track the definition.
 		// false if the variable declared but not yet defined, true if both
-		scopes: stack{},
+		scopes:  stack{},
+		globals: map[string]bool{},
 		// set default start scope type as global scope
 		curft: callableenum.NONE,
 	}
 }
 
+func (resolver *Resolver) SetListener(listener Listener) {
+	resolver.listener = listener
+}
+
 // resolving blocks
 func (resolver *Resolver) VisitBlockStmt(stmt statements.BlockStatement) error {
 	resolver.beginScope()
@@ -54,6 +89,9 @@ func (resolver *Resolver) VisitBlockStmt(stmt statements.BlockStatement) error {
 
 // walks the statements and resolves each one.
 func (resolver *Resolver) Resolve(stmts []statements.Statement) {
+	if inspector, ok := resolver.listener.(Inspector); ok {
+		inspector.Statements(stmts)
+	}
 	for _, stmt := range stmts {
 		resolver.resolveStmt(stmt)
 	}
@@ -71,7 +109,7 @@ func (resolver *Resolver) resolveExpr(expr expressions.Experssion) {
 }
 
 func (resolver *Resolver) VisitVarDecStmt(stmt statements.VarDecStatement) error {
-	resolver.declare(stmt.Token)
+	resolver.declare(stmt.Token, stmt)
 	if stmt.Initializer != nil {
 		resolver.resolveExpr(stmt.Initializer)
 	}
@@ -87,11 +125,11 @@ func (resolver *Resolver) VisitVairable(expr expressions.Variable) (interface{},
 			// Make it an error if reference a variable in its initializer
 			// e.g. let a = 8;
 			// let a = a;
-			reporting.ReportError(expr.Token.Line, fmt.Sprintf("Can't read local variable %s in its own initializer", expr.Token.Lexeme))
+			reporting.ReportToken(expr.Token, "", fmt.Sprintf("Can't read local variable %s in its own initializer", expr.Token.Lexeme))
 			return nil, nil
 		}
 	}
-	resolver.resolveLocalVar(expr, expr.Token.Lexeme)
+	resolver.reference(expr.Token, resolver.resolveLocalVar(expr, expr.Token.Lexeme))
 	return nil, nil
 }
 
@@ -99,7 +137,19 @@ func (resolver *Resolver) VisitVairable(expr expressions.Variable) (interface{},
 // Then it resolve the variable that’s being assigned to.
 func (resolver *Resolver) VisitAssgin(expr expressions.Assgin) (interface{}, error) {
 	resolver.resolveExpr(expr.Value)
-	resolver.resolveLocalVar(expr, expr.Token.Lexeme)
+	scope := resolver.resolveLocalVar(expr, expr.Token.Lexeme)
+	if (scope == -1 && resolver.globals[expr.Token.Lexeme]) || (scope != -1 && resolver.constants[scope][expr.Token.Lexeme]) {
+		reporting.ReportToken(expr.Token, "", fmt.Sprintf("Can't assign to constant '%s'", expr.Token.Lexeme))
+	}
+	if inspector, ok := resolver.listener.(Inspector); ok {
+		var declaration expressions.Token
+		if scope != -1 {
+			declaration = resolver.declarations[scope][expr.Token.Lexeme]
+		}
+		inspector.Assign(expr.Token, declaration, scope != -1)
+		return nil, nil
+	}
+	resolver.reference(expr.Token, scope)
 	return nil, nil
 }
 
@@ -107,7 +157,11 @@ func (resolver *Resolver) VisitThis(expr expressions.This) (interface{}, error)
 
 	// check if 'this' is used outside of a method body
 	if resolver.curcls == classenum.NONE {
-		reporting.ReportError(expr.Keywork.Line, "Can't use 'this' keyword outside of a class")
+		reporting.ReportToken(expr.Keywork, "", "Can't use 'this' keyword outside of a class")
+		return nil, nil
+	}
+	if resolver.curcls == classenum.STATIC {
+		reporting.ReportToken(expr.Keywork, "", "Can't use 'this' keyword in a static member")
 		return nil, nil
 	}
 	resolver.resolveLocalVar(expr, expr.Keywork.Lexeme)
@@ -115,7 +169,7 @@ func (resolver *Resolver) VisitThis(expr expressions.This) (interface{}, error)
 }
 func (resolver *Resolver) VisitFunctionStmt(stmt statements.FunctionStatement) error {
 	// define the declare the function before resolving to let the function refer to itself
-	resolver.declare(stmt.Name)
+	resolver.declare(stmt.Name, stmt)
 	resolver.define(stmt.Name)
 	resolver.resolveFunction(stmt, callableenum.FUNCTION)
 	return nil
@@ -143,9 +197,9 @@ func (resolver *Resolver) VisitPrintStmt(stmt statements.PrintStatement) error {
 func (resolver *Resolver) VisitReturnStmt(stmt statements.ReturnStatement) error {
 	// disallow return out side function scope type
 	if resolver.curft == callableenum.NONE {
-		reporting.ReportError(stmt.Keyword.Line, "Return is not allowed outside of a function")
+		reporting.ReportToken(stmt.Keyword, "", "Return is not allowed outside of a function")
 	} else if resolver.curft == callableenum.INITIALIZER {
-		reporting.ReportError(stmt.Keyword.Line, "Can't use 'return' in an initializer")
+		reporting.ReportToken(stmt.Keyword, "", "Can't use 'return' in an initializer")
 	}
 	if stmt.Value != nil {
 		resolver.resolveExpr(stmt.Value)
@@ -154,11 +208,112 @@ func (resolver *Resolver) VisitReturnStmt(stmt statements.ReturnStatement) error
 }
 
 func (resolver *Resolver) VisitWhileStmt(stmt statements.WhileStatement) error {
+	label := stmt.Label.Lexeme
+	if label != "" && resolver.hasLoopLabel(label) {
+		reporting.ReportToken(stmt.Label, "", fmt.Sprintf("Label '%s' is already used by an enclosing loop", label))
+	}
+	resolver.loops = append(resolver.loops, label)
 	resolver.resolveExpr(stmt.Condition)
 	resolver.resolveStmt(stmt.Body)
+	if stmt.Increment != nil {
+		resolver.resolveExpr(stmt.Increment)
+	}
+	resolver.loops = resolver.loops[:len(resolver.loops)-1]
+	return nil
+}
+
+// each case body is a scope of its own. switches aren't loops so break and continue still target the enclosing loop
+func (resolver *Resolver) VisitSwitchStmt(stmt statements.SwitchStatement) error {
+	resolver.resolveExpr(stmt.Value)
+	for _, clause := range stmt.Cases {
+		for _, value := range clause.Values {
+			resolver.resolveExpr(value)
+		}
+		resolver.beginScope()
+		resolver.Resolve(clause.Body)
+		resolver.endScope()
+	}
+	if stmt.Default != nil {
+		resolver.beginScope()
+		resolver.Resolve(stmt.Default)
+		resolver.endScope()
+	}
 	return nil
 }
 
+func (resolver *Resolver) VisitThrowStmt(stmt statements.ThrowStatement) error {
+	resolver.resolveExpr(stmt.Value)
+	return nil
+}
+
+// each clause gets its own scope, the catch scope holds the error variable
+func (resolver *Resolver) VisitTryStmt(stmt statements.TryStatement) error {
+	resolver.beginScope()
+	resolver.Resolve(stmt.Body)
+	resolver.endScope()
+	if stmt.CatchBody != nil {
+		resolver.beginScope()
+		resolver.declare(stmt.CatchName, nil)
+		resolver.define(stmt.CatchName)
+		resolver.Resolve(stmt.CatchBody)
+		resolver.endScope()
+	}
+	if stmt.FinallyBody != nil {
+		resolver.beginScope()
+		resolver.Resolve(stmt.FinallyBody)
+		resolver.endScope()
+	}
+	return nil
+}
+
+// imports bind names like variable declarations do
+func (resolver *Resolver) VisitImportStmt(stmt statements.ImportStatement) error {
+	if len(stmt.Names) != 0 {
+		for _, name := range stmt.Names {
+			resolver.declare(name, stmt)
+			resolver.define(name)
+		}
+		return nil
+	}
+	name := expressions.Token{Lexeme: interpreter.ImportName(stmt), Line: stmt.Keyword.Line}
+	if stmt.Alias.Lexeme != "" {
+		name = stmt.Alias
+	}
+	resolver.declare(name, stmt)
+	resolver.define(name)
+	return nil
+}
+
+func (resolver *Resolver) VisitBreakStmt(stmt statements.BreakStatement) error {
+	resolver.resolveLoopJump(stmt.Keyword, stmt.Label)
+	return nil
+}
+
+func (resolver *Resolver) VisitContinueStmt(stmt statements.ContinueStatement) error {
+	resolver.resolveLoopJump(stmt.Keyword, stmt.Label)
+	return nil
+}
+
+// disallow break and continue outside of a loop or targeting an unknown label
+func (resolver *Resolver) resolveLoopJump(keyword expressions.Token, label expressions.Token) {
+	if len(resolver.loops) == 0 {
+		reporting.ReportToken(keyword, "", fmt.Sprintf("Can't use '%s' outside of a loop", keyword.Lexeme))
+		return
+	}
+	if label.Lexeme != "" && !resolver.hasLoopLabel(label.Lexeme) {
+		reporting.ReportToken(label, "", fmt.Sprintf("Undefined loop label '%s'", label.Lexeme))
+	}
+}
+
+func (resolver *Resolver) hasLoopLabel(label string) bool {
+	for _, l := range resolver.loops {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
 func (resolver *Resolver) VisitClassStmt(stmt statements.ClassStatement) error {
 	curcls := resolver.curcls
 	resolver.curcls = classenum.CLASS
@@ -166,12 +321,12 @@ func (resolver *Resolver) VisitClassStmt(stmt statements.ClassStatement) error {
 		resolver.curcls = curcls
 	}()
 	// defining class directly to allow the methods to reference it's class
-	resolver.declare(stmt.Name)
+	resolver.declare(stmt.Name, stmt)
 	resolver.define(stmt.Name)
 
 	// detect if the class try to extends itself
 	if stmt.Superclass.Token.Lexeme == stmt.Name.Lexeme {
-		reporting.ReportError(stmt.Name.Line, "Class can't extends itself")
+		reporting.ReportToken(stmt.Name, "", "Class can't extends itself")
 	}
 
 	// resolve the superclass if exists
@@ -190,6 +345,14 @@ func (resolver *Resolver) VisitClassStmt(stmt statements.ClassStatement) error {
 		resolver.scopes = append(resolver.scopes, scope)
 	}
 
+	// static methods are not bound to an instance so they are resolved outside of the 'this' scope
+	classctx := resolver.curcls
+	resolver.curcls = classenum.STATIC
+	for _, method := range stmt.StaticMethods {
+		resolver.resolveFunction(method, callableenum.METHOD)
+	}
+	resolver.curcls = classctx
+
 	// define “this”
 	resolver.beginScope()
 	scope := resolver.scopes[len(resolver.scopes)-1]
@@ -205,11 +368,22 @@ func (resolver *Resolver) VisitClassStmt(stmt statements.ClassStatement) error {
 		}
 		resolver.resolveFunction(method, ft)
 	}
+	for _, getter := range stmt.Getters {
+		resolver.resolveFunction(getter, callableenum.METHOD)
+	}
 	resolver.endScope()
 	// discard super scope
 	if stmt.Superclass.Token.Lexeme != "" {
 		resolver.endScope()
 	}
+
+	// static fields are initialized in the scope enclosing the class
+	resolver.curcls = classenum.STATIC
+	for _, field := range stmt.StaticFields {
+		if field.Initializer != nil {
+			resolver.resolveExpr(field.Initializer)
+		}
+	}
 	return nil
 }
 
@@ -237,6 +411,24 @@ func (resolver *Resolver) VisitLogical(expr expressions.Logical) (interface{}, e
 	resolver.resolveExpr(expr.Right)
 	return nil, nil
 }
+
+func (resolver *Resolver) VisitConditional(expr expressions.Conditional) (interface{}, error) {
+	resolver.resolveExpr(expr.Condition)
+	resolver.resolveExpr(expr.Then)
+	resolver.resolveExpr(expr.Else)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitCoalesce(expr expressions.Coalesce) (interface{}, error) {
+	resolver.resolveExpr(expr.Left)
+	resolver.resolveExpr(expr.Right)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitOptionalChain(expr expressions.OptionalChain) (interface{}, error) {
+	resolver.resolveExpr(expr.Expr)
+	return nil, nil
+}
 func (resolver *Resolver) VisitUnary(expr expressions.Unary) (interface{}, error) {
 	resolver.resolveExpr(expr.Right)
 	return nil, nil
@@ -259,19 +451,80 @@ func (resolver *Resolver) VisitPropertyAssignment(expr expressions.PropertyAssig
 	return nil, nil
 }
 
+func (resolver *Resolver) VisitLambda(expr expressions.Lambda) (interface{}, error) {
+	resolver.resolveFunction(expr.Function.(statements.FunctionStatement), callableenum.FUNCTION)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitListLiteral(expr expressions.ListLiteral) (interface{}, error) {
+	for _, element := range expr.Elements {
+		resolver.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitIndex(expr expressions.Index) (interface{}, error) {
+	resolver.resolveExpr(expr.Obj)
+	resolver.resolveExpr(expr.Index)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitIndexAssignment(expr expressions.IndexAssignment) (interface{}, error) {
+	resolver.resolveExpr(expr.Value)
+	resolver.resolveExpr(expr.Obj)
+	resolver.resolveExpr(expr.Index)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitSlice(expr expressions.Slice) (interface{}, error) {
+	resolver.resolveExpr(expr.Obj)
+	if expr.Start != nil {
+		resolver.resolveExpr(expr.Start)
+	}
+	if expr.End != nil {
+		resolver.resolveExpr(expr.End)
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitMapLiteral(expr expressions.MapLiteral) (interface{}, error) {
+	for i := range expr.Keys {
+		resolver.resolveExpr(expr.Keys[i])
+		resolver.resolveExpr(expr.Values[i])
+	}
+	return nil, nil
+}
+
 // initialize the scope
 func (resolver *Resolver) beginScope() {
+	resolver.openScope(false)
+}
+
+// function is set for the scope of the parameters and the body of a function
+func (resolver *Resolver) openScope(function bool) {
 	resolver.scopes = append(resolver.scopes, scope{})
+	resolver.declarations = append(resolver.declarations, map[string]expressions.Token{})
+	resolver.constants = append(resolver.constants, map[string]bool{})
+	if inspector, ok := resolver.listener.(Inspector); ok {
+		inspector.BeginScope(function)
+	}
 }
 
 func (resolver *Resolver) endScope() {
+	if inspector, ok := resolver.listener.(Inspector); ok {
+		inspector.EndScope()
+	}
 	resolver.scopes = resolver.scopes[:len(resolver.scopes)-1]
+	resolver.declarations = resolver.declarations[:len(resolver.declarations)-1]
+	resolver.constants = resolver.constants[:len(resolver.constants)-1]
 }
 func (resolver *Resolver) VisitSuper(expr expressions.Super) (interface{}, error) {
 	if resolver.curcls == classenum.NONE {
-		reporting.ReportError(expr.Keyword.Line, "Can't use 'super' outside of a class")
+		reporting.ReportToken(expr.Keyword, "", "Can't use 'super' outside of a class")
 	} else if resolver.curcls == classenum.CLASS {
-		reporting.ReportError(expr.Keyword.Line, "Can't use 'super' with no superclass")
+		reporting.ReportToken(expr.Keyword, "", "Can't use 'super' with no superclass")
+	} else if resolver.curcls == classenum.STATIC {
+		reporting.ReportToken(expr.Keyword, "", "Can't use 'super' in a static member")
 	}
 	resolver.resolveLocalVar(expr, expr.Keyword.Lexeme)
 	return nil, nil
@@ -281,21 +534,44 @@ func (resolver *Resolver) VisitSuper(expr expressions.Super) (interface{}, error
 // any outer one and so that it knows the variable exists.
 // marks it as 'not yet ready' by binding its name to false.
 // The value represents whether or not it have finished resolving that variable’s initializer.
-func (resolver *Resolver) declare(name expressions.Token) {
+func (resolver *Resolver) declare(name expressions.Token, stmt statements.Statement) {
+	if resolver.listener != nil {
+		resolver.listener.Declare(name, stmt)
+	}
 	if len(resolver.scopes) == 0 {
+		// declaring a global again rebinds it unless it's a constant
+		if resolver.globals[name.Lexeme] {
+			reporting.ReportToken(name, "", fmt.Sprintf("Can't redeclare constant '%s'", name.Lexeme))
+		}
+		resolver.globals[name.Lexeme] = constant(stmt)
 		return
 	}
+	resolver.declarations[len(resolver.declarations)-1][name.Lexeme] = name
+	resolver.constants[len(resolver.constants)-1][name.Lexeme] = constant(stmt)
 
 	scope := resolver.scopes[len(resolver.scopes)-1]
 	resolver.scopes = resolver.scopes[:len(resolver.scopes)-1]
 	_, containsVar := scope[name.Lexeme]
 	if containsVar {
-		reporting.ReportError(name.Line, fmt.Sprintf("Duplicate decleration of variable '%s' in local scope", name.Lexeme))
+		reporting.ReportToken(name, "", fmt.Sprintf("Duplicate decleration of variable '%s' in local scope", name.Lexeme))
 	}
 	scope[name.Lexeme] = false
 	resolver.scopes = append(resolver.scopes, scope)
 }
 
+// the declarations made with 'const' can't be assigned
+func constant(stmt statements.Statement) bool {
+	switch stmt := stmt.(type) {
+	case statements.VarDecStatement:
+		return stmt.Constant
+	case statements.FunctionStatement:
+		return stmt.Constant
+	case statements.ClassStatement:
+		return stmt.Constant
+	}
+	return false
+}
+
 // resolve the variable in that same scope where the variable exists but is unavailable
 func (resolver *Resolver) define(name expressions.Token) {
 	if len(resolver.scopes) == 0 {
@@ -307,7 +583,8 @@ func (resolver *Resolver) define(name expressions.Token) {
 	resolver.scopes = append(resolver.scopes, scope)
 }
 
-func (resolver *Resolver) resolveLocalVar(expr expressions.Experssion, name string) {
+// returns the index of the scope declaring the variable, -1 if it's not found
+func (resolver *Resolver) resolveLocalVar(expr expressions.Experssion, name string) int {
 	// starts at the innermost scope and work outwards,
 	// looking in each map for a matching name.
 	// If it find the variable, it resolve it, passing in the number of scopes
@@ -318,9 +595,22 @@ func (resolver *Resolver) resolveLocalVar(expr expressions.Experssion, name stri
 		flag := scope[name]
 		if flag {
 			resolver.inter.Resolve(expr, len(resolver.scopes)-1-i)
-			return
+			return i
 		}
 	}
+	return -1
+}
+
+// notifies the listener of a variable reference resolved to the given scope
+func (resolver *Resolver) reference(name expressions.Token, scope int) {
+	if resolver.listener == nil {
+		return
+	}
+	if scope == -1 {
+		resolver.listener.Reference(name, expressions.Token{}, false)
+		return
+	}
+	resolver.listener.Reference(name, resolver.declarations[scope][name.Lexeme], true)
 }
 
 // creates a new scope for the body and then binds variables for each of the parameter
@@ -329,13 +619,21 @@ func (resolver *Resolver) resolveFunction(function statements.FunctionStatement,
 	// set the current scope type and save the enclosing one
 	encloseft := resolver.curft
 	resolver.curft = ft
-	resolver.beginScope()
-	for _, arg := range function.Args {
-		resolver.declare(arg)
+	// loops don't cross function boundaries
+	encloseLoops := resolver.loops
+	resolver.loops = nil
+	resolver.openScope(true)
+	// a default value sees the parameters before it
+	for i, arg := range function.Args {
+		resolver.declare(arg, nil)
+		if def := function.Default(i); def != nil {
+			resolver.resolveExpr(def)
+		}
 		resolver.define(arg)
 	}
 	resolver.Resolve(function.Body)
 	resolver.endScope()
 	// restore the enclosing scope type
 	resolver.curft = encloseft
+	resolver.loops = encloseLoops
 }
@@ -0,0 +1,58 @@
+package resolver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/resolver"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+// scans, parses, resolves and runs the source returning the values passed to the record native
+func run(t *testing.T, source string) []interface{} {
+	t.Helper()
+	stmts := parser.New(scanner.New(source).ScanTokens()).Parse()
+	if reporting.HadError() {
+		t.Fatal("unexpected syntax error")
+	}
+	inter := interpreter.New()
+	recorded := []interface{}{}
+	inter.DefineNative("record", 1, func(inter *interpreter.Interpreter, args []interface{}) (interface{}, error) {
+		recorded = append(recorded, args[0])
+		return nil, nil
+	})
+	resolver.New(inter).Resolve(stmts)
+	if reporting.HadError() {
+		t.Fatal("unexpected resolution error")
+	}
+	if err := inter.Interpret(stmts); err != nil {
+		t.Fatalf("unexpected runtime error: %s", err)
+	}
+	return recorded
+}
+
+// assigned values holding slices used to make the resolution table panic
+func TestAssignLiteralsToLocals(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []interface{}
+	}{
+		{"list", `func f() { let xs = nil; xs = ["a"]; record(xs[0]); } f();`, []interface{}{"a"}},
+		{"map", `func f() { let m = nil; m = {"k": "a"}; record(m["k"]); } f();`, []interface{}{"a"}},
+		{"lambda", `func f() { let g = nil; g = () => "a"; record(g()); } f();`, []interface{}{"a"}},
+		{"call", `func id(a) { return a; } func f() { let y = nil; y = id("a"); record(y); } f();`, []interface{}{"a"}},
+		{"compound", `func f() { let z = 1; z += len("ab"); z++; record(z); } f();`, []interface{}{int64(4)}},
+		{"closure", `func f() { let xs = nil; func g() { xs = ["b"]; } g(); record(xs[0]); } f();`, []interface{}{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(t, tt.source); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
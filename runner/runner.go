@@ -24,7 +24,7 @@ var ErrInterrupt = errors.New("received interrupt")
 func New(d time.Duration) *Runner {
 	return &Runner{
 		interrupt: make(chan os.Signal, 1),
-		complete:  make(chan error),
+		complete:  make(chan error, 1),
 		timeout:   time.After(d),
 	}
 }
@@ -36,6 +36,8 @@ func (r *Runner) Add(tasks ...func(int)) {
 func (r *Runner) Start() error {
 
 	signal.Notify(r.interrupt, os.Interrupt)
+	// restores the default handling once the tasks are done, an interrupt stops the program again
+	defer signal.Stop(r.interrupt)
 
 	go func() {
 		r.complete <- r.run()
@@ -47,6 +49,9 @@ func (r *Runner) Start() error {
 
 	case <-r.timeout:
 		return ErrTimeout
+
+	case <-r.interrupt:
+		return ErrInterrupt
 	}
 }
 
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/Ahmed-Sermani/prolang/parser/expressions"
@@ -13,6 +14,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -20,6 +23,13 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	COLON
+	PERCENT
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
+	QUESTION
 
 	// One or two character tokens.
 	BANG
@@ -30,6 +40,21 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ARROW
+	STAR_STAR
+	LESS_LESS
+	GREATER_GREATER
+	QUESTION_QUESTION
+	QUESTION_DOT
+	ELLIPSIS
+	// compound assignments, increments and decrements
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	PLUS_PLUS
+	MINUS_MINUS
 
 	// Literals.
 	IDENTIFIER
@@ -54,29 +79,70 @@ const (
 	LET
 	WHILE
 	EXTENDS
+	BREAK
+	CONTINUE
+	TRY
+	CATCH
+	FINALLY
+	THROW
+	IMPORT
+	FROM
+	AS
+	STATIC
+	SWITCH
+	CASE
+	DEFAULT
+	CONST
+
+	// only produced when comments are kept, they are not passed to the parser
+	COMMENT
 
 	EOF
 )
 
 // reserved keywords
 var keywords = map[string]expressions.TokenType{
-	"and":     AND,
-	"class":   CLASS,
-	"else":    ELSE,
-	"false":   FALSE,
-	"for":     FOR,
-	"func":    FUNC,
-	"if":      IF,
-	"nil":     NIL,
-	"or":      OR,
-	"print":   PRINT,
-	"return":  RETURN,
-	"super":   SUPER,
-	"this":    THIS,
-	"true":    TRUE,
-	"let":     LET,
-	"while":   WHILE,
-	"extends": EXTENDS,
+	"and":      AND,
+	"class":    CLASS,
+	"else":     ELSE,
+	"false":    FALSE,
+	"for":      FOR,
+	"func":     FUNC,
+	"if":       IF,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"true":     TRUE,
+	"let":      LET,
+	"while":    WHILE,
+	"extends":  EXTENDS,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"from":     FROM,
+	"as":       AS,
+	"static":   STATIC,
+	"switch":   SWITCH,
+	"case":     CASE,
+	"default":  DEFAULT,
+	"const":    CONST,
+}
+
+// the reserved keywords in alphabetical order
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 type Scanner struct {
@@ -88,6 +154,16 @@ type Scanner struct {
 	current int
 	// line tracks what source line current is on so can produce tokens with their location.
 	line int
+	// offset of the first character of the current line
+	lineStart int
+	// line and column of the lexeme being scanned
+	startLine int
+	column    int
+	// the file the source was read from, it's recorded in the tokens
+	file string
+	// trivia mode, comments are collected instead of being discarded
+	keepComments bool
+	comments     []expressions.Token
 }
 
 func New(source string) *Scanner {
@@ -97,9 +173,29 @@ func New(source string) *Scanner {
 	}
 }
 
+// names the file the source was read from for the diagnostics.
+// the source is registered so errors pointing into it are shown with their line
+func (scanner *Scanner) SetFile(file string) {
+	scanner.file = file
+	reporting.AddSource(file, scanner.source)
+}
+
+// makes the scanner collect the comments for tools that reprint the source (e.g. the formatter).
+// the tokens are the same with or without comments
+func (scanner *Scanner) KeepComments() {
+	scanner.keepComments = true
+}
+
+// the comments in source order, only collected when KeepComments is set
+func (scanner *Scanner) Comments() []expressions.Token {
+	return scanner.comments
+}
+
 func (scanner *Scanner) ScanTokens() []expressions.Token {
 	for !scanner.isAtEnd() {
 		scanner.start = scanner.current
+		scanner.startLine = scanner.line
+		scanner.column = scanner.start - scanner.lineStart + 1
 		scanner.scanToken()
 	}
 
@@ -109,6 +205,9 @@ func (scanner *Scanner) ScanTokens() []expressions.Token {
 		Lexeme:  "",
 		Literal: expressions.Literal{Value: nil},
 		Line:    scanner.line,
+		Column:  scanner.current - scanner.lineStart + 1,
+		Offset:  scanner.current,
+		File:    scanner.file,
 	})
 	return scanner.tokens
 }
@@ -128,20 +227,72 @@ func (scanner *Scanner) scanToken() {
 		scanner.addToken(LEFT_BRACE, expressions.Literal{Value: nil})
 	case '}':
 		scanner.addToken(RIGHT_BRACE, expressions.Literal{Value: nil})
+	case '[':
+		scanner.addToken(LEFT_BRACKET, expressions.Literal{Value: nil})
+	case ']':
+		scanner.addToken(RIGHT_BRACKET, expressions.Literal{Value: nil})
+	case ':':
+		scanner.addToken(COLON, expressions.Literal{Value: nil})
 	case ',':
 		scanner.addToken(COMMA, expressions.Literal{Value: nil})
 	case '.':
-		scanner.addToken(DOT, expressions.Literal{Value: nil})
-	case '-':
-		scanner.addToken(MINUS, expressions.Literal{Value: nil})
-	case '+':
-		scanner.addToken(PLUS, expressions.Literal{Value: nil})
+		if scanner.peek() == '.' && scanner.lookahead() == '.' {
+			scanner.advance()
+			scanner.advance()
+			scanner.addToken(ELLIPSIS, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(DOT, expressions.Literal{Value: nil})
+		}
 	case ';':
 		scanner.addToken(SEMICOLON, expressions.Literal{Value: nil})
-	case '*':
-		scanner.addToken(STAR, expressions.Literal{Value: nil})
+	case '&':
+		scanner.addToken(AMPERSAND, expressions.Literal{Value: nil})
+	case '|':
+		scanner.addToken(PIPE, expressions.Literal{Value: nil})
+	case '^':
+		scanner.addToken(CARET, expressions.Literal{Value: nil})
+	case '~':
+		scanner.addToken(TILDE, expressions.Literal{Value: nil})
+	case '?':
+		if scanner.match('?') {
+			scanner.addToken(QUESTION_QUESTION, expressions.Literal{Value: nil})
+		} else if scanner.match('.') {
+			scanner.addToken(QUESTION_DOT, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(QUESTION, expressions.Literal{Value: nil})
+		}
 
 	// two stages match
+	case '-':
+		if scanner.match('=') {
+			scanner.addToken(MINUS_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('-') {
+			scanner.addToken(MINUS_MINUS, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(MINUS, expressions.Literal{Value: nil})
+		}
+	case '+':
+		if scanner.match('=') {
+			scanner.addToken(PLUS_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('+') {
+			scanner.addToken(PLUS_PLUS, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(PLUS, expressions.Literal{Value: nil})
+		}
+	case '*':
+		if scanner.match('=') {
+			scanner.addToken(STAR_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('*') {
+			scanner.addToken(STAR_STAR, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(STAR, expressions.Literal{Value: nil})
+		}
+	case '%':
+		if scanner.match('=') {
+			scanner.addToken(PERCENT_EQUAL, expressions.Literal{Value: nil})
+		} else {
+			scanner.addToken(PERCENT, expressions.Literal{Value: nil})
+		}
 	case '!':
 		if scanner.match('=') {
 			scanner.addToken(BANG_EQUAL, expressions.Literal{Value: nil})
@@ -151,18 +302,24 @@ func (scanner *Scanner) scanToken() {
 	case '=':
 		if scanner.match('=') {
 			scanner.addToken(EQUAL_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('>') {
+			scanner.addToken(ARROW, expressions.Literal{Value: nil})
 		} else {
 			scanner.addToken(EQUAL, expressions.Literal{Value: nil})
 		}
 	case '<':
 		if scanner.match('=') {
 			scanner.addToken(LESS_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('<') {
+			scanner.addToken(LESS_LESS, expressions.Literal{Value: nil})
 		} else {
 			scanner.addToken(LESS, expressions.Literal{Value: nil})
 		}
 	case '>':
 		if scanner.match('=') {
 			scanner.addToken(GREATER_EQUAL, expressions.Literal{Value: nil})
+		} else if scanner.match('>') {
+			scanner.addToken(GREATER_GREATER, expressions.Literal{Value: nil})
 		} else {
 			scanner.addToken(GREATER, expressions.Literal{Value: nil})
 		}
@@ -175,6 +332,18 @@ func (scanner *Scanner) scanToken() {
 				// consume the comment
 				scanner.advance()
 			}
+			if scanner.keepComments {
+				scanner.comments = append(scanner.comments, expressions.Token{
+					Kind:   COMMENT,
+					Lexeme: scanner.source[scanner.start:scanner.current],
+					Line:   scanner.line,
+					Column: scanner.column,
+					Offset: scanner.start,
+					File:   scanner.file,
+				})
+			}
+		} else if scanner.match('=') {
+			scanner.addToken(SLASH_EQUAL, expressions.Literal{Value: nil})
 		} else {
 			scanner.addToken(SLASH, expressions.Literal{Value: nil})
 		}
@@ -185,7 +354,7 @@ func (scanner *Scanner) scanToken() {
 	case '\r':
 
 	case '\n':
-		scanner.line++
+		scanner.newLine()
 
 	// literals
 	case '"':
@@ -198,7 +367,7 @@ func (scanner *Scanner) scanToken() {
 		} else if isAlpha(c) {
 			scanner.parseIdentifer()
 		} else {
-			reporting.ReportError(scanner.line, "Unexpected character.")
+			scanner.error("Unexpected character.", "")
 		}
 	}
 
@@ -221,13 +390,31 @@ func (scanner *Scanner) peek() byte {
 
 // grabs the text of the current lexeme and creates a new token for it.
 func (scanner *Scanner) addToken(kind expressions.TokenType, literal expressions.Literal) {
-	text := scanner.source[scanner.start:scanner.current]
-	scanner.tokens = append(scanner.tokens, expressions.Token{
+	scanner.tokens = append(scanner.tokens, scanner.lexeme(kind, literal))
+}
+
+// the token of the current lexeme, tokens spanning multiple lines (e.g. strings) are on the line they start at
+func (scanner *Scanner) lexeme(kind expressions.TokenType, literal expressions.Literal) expressions.Token {
+	return expressions.Token{
 		Kind:    kind,
-		Lexeme:  text,
+		Lexeme:  scanner.source[scanner.start:scanner.current],
 		Literal: literal,
-		Line:    scanner.line,
-	})
+		Line:    scanner.startLine,
+		Column:  scanner.column,
+		Offset:  scanner.start,
+		File:    scanner.file,
+	}
+}
+
+// reports an error spanning the current lexeme
+func (scanner *Scanner) error(msg string, hint string) {
+	reporting.ReportTokenHint(scanner.lexeme(EOF, expressions.Literal{}), "", msg, hint)
+}
+
+// called after consuming a line break
+func (scanner *Scanner) newLine() {
+	scanner.line++
+	scanner.lineStart = scanner.current
 }
 
 // matches the current character if the match succeeded the incremant current
@@ -255,14 +442,17 @@ func (scanner *Scanner) lookahead() byte {
 // parses string literal and add its token. supports multiline strings
 func (scanner *Scanner) parseStringLiteral() {
 	for scanner.peek() != '"' && !scanner.isAtEnd() {
-		if scanner.peek() == '\n' {
-			scanner.line++
-		}
 		scanner.advance()
+		if scanner.source[scanner.current-1] == '\n' {
+			scanner.newLine()
+		}
 	}
 
 	if scanner.isAtEnd() {
-		reporting.ReportError(scanner.line, "Unterminated string.")
+		// only the opening quote is underlined
+		scanner.current = scanner.start + 1
+		scanner.error("Unterminated string.", "add the closing '\"'")
+		scanner.current = len(scanner.source)
 		return
 	}
 
@@ -292,12 +482,20 @@ func (scanner *Scanner) parseNumberLiteral() {
 			// consume fractions
 			scanner.advance()
 		}
+
+		// parse the number as float64
+		value, err := strconv.ParseFloat(scanner.source[scanner.start:scanner.current], 64)
+		if err != nil {
+			scanner.error("Invalid Float Value", "")
+		}
+		scanner.addToken(NUMBER, expressions.Literal{Value: value})
+		return
 	}
 
-	// parse the number as float64
-	value, err := strconv.ParseFloat(scanner.source[scanner.start:scanner.current], 64)
+	// numbers without a fractional part are integers
+	value, err := strconv.ParseInt(scanner.source[scanner.start:scanner.current], 10, 64)
 	if err != nil {
-		reporting.ReportError(scanner.line, "Invalid Float Value")
+		scanner.error("Integer literal out of range", "integers are 64-bit, write it with a fractional part (e.g. 1.0) to make it a float")
 	}
 	scanner.addToken(NUMBER, expressions.Literal{Value: value})
 }
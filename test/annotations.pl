@@ -0,0 +1,25 @@
+// type annotations are ignored when a script runs
+class Point {
+  init(x: number, y: number) {
+    this.x = x;
+    this.y = y;
+  }
+  get norm: number { return this.x * this.x + this.y * this.y; }
+}
+
+func dot(a: Point, b: Point): number {
+  return a.x * b.x + a.y * b.y;
+}
+
+func total(...xs: int): int {
+  let sum: int = 0;
+  for (let i = 0; i < len(xs); i++) sum += xs[i];
+  return sum;
+}
+
+let count: int = 0;
+count = "none";
+print count;                          // expect: none
+print dot(Point(1, 2), Point(3, 4));  // expect: 11
+print Point(3, 4).norm;               // expect: 25
+print total(1, 2, 3);                 // expect: 6
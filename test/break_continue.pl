@@ -0,0 +1,28 @@
+// continue still runs the for loop increment
+for (let i = 0; i < 10; i = i + 1) {
+  if (i == 2) continue;
+  if (i == 4) break;
+  print i;
+}
+// expect: 0
+// expect: 1
+// expect: 3
+
+// labels target an outer loop
+outer: for (let i = 0; i < 3; i = i + 1) {
+  for (let j = 0; j < 3; j = j + 1) {
+    if (j == 1) continue outer;
+    if (i == 2) break outer;
+    print i;
+  }
+}
+// expect: 0
+// expect: 1
+
+let n = 0;
+while (true) {
+  n = n + 1;
+  if (n < 3) continue;
+  break;
+}
+print n; // expect: 3
@@ -0,0 +1,11 @@
+class Area {
+  init(name) { this.name = name; }
+  format() { return this.name + " area"; }
+}
+class City extends Area {
+  format() { return "The city " + super.format(); }
+}
+print City("Riyadh").format(); // expect: The city Riyadh area
+print City;                    // expect: <class City>
+print City("x");               // expect: <instance of City>
+City(); // expect runtime error: Function <class City> expected 1 argument but got 0
@@ -0,0 +1,30 @@
+let total = 10;
+total += 5;
+total *= 2;
+total /= 3;
+print total; // expect: 10
+total -= 14;
+print total; // expect: -4
+let n = 1;
+print n++; // expect: 1
+print ++n; // expect: 3
+print n--; // expect: 3
+print n;   // expect: 2
+
+class Box { init() { this.v = 1; } }
+let b = Box();
+b.v += 2;
+print b.v++; // expect: 3
+print b.v;   // expect: 4
+
+func local() {
+  let z = 1;
+  z += len("ab");
+  z++;
+  return z;
+}
+print local(); // expect: 4
+
+let s = "a";
+s += "b";
+print s; // expect: ab
@@ -0,0 +1,20 @@
+let age = 20;
+print age >= 18 ? "adult" : "minor"; // expect: adult
+print age < 18 ? "minor" : age < 65 ? "adult" : "senior"; // expect: adult
+
+// ?? gives the right operand only when the left one is nil
+print nil ?? "default";   // expect: default
+print false ?? "default"; // expect: false
+
+// ?. gives nil instead of failing when the object is nil, the rest of the chain is skipped
+class Node {
+  init(value, next) {
+    this.value = value;
+    this.next = next;
+  }
+}
+let list = Node(1, nil);
+print list.next?.value;          // expect: nil
+print list.next?.next.value;     // expect: nil
+print list.next?.value ?? "end"; // expect: end
+print list?.value;               // expect: 1
@@ -0,0 +1,7 @@
+const MAX = 10;
+print MAX; // expect: 10
+
+// a global assigned before its declaration fails when the assignment runs
+func reset() { LIMIT = 0; } // expect runtime error: Can't assign to constant 'LIMIT'.
+const LIMIT = 5;
+reset();
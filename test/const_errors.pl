@@ -0,0 +1,2 @@
+const MAX = 10;
+MAX = 20; // error at line 2: Can't assign to constant 'MAX'
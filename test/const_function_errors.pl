@@ -0,0 +1,14 @@
+const func twice(n) { return n * 2; }
+twice = nil; // error at line 2: Can't assign to constant 'twice'
+
+const class Shape {}
+Shape = nil; // error at line 5: Can't assign to constant 'Shape'
+
+const LIMIT = 1;
+let LIMIT = 2; // error at line 8: Can't redeclare constant 'LIMIT'
+func twice() {} // error at line 9: Can't redeclare constant 'twice'
+
+{
+  const func local() {}
+  local = 1; // error at line 13: Can't assign to constant 'local'
+}
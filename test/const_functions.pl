@@ -0,0 +1,21 @@
+// functions and classes are constant only when declared with 'const'
+func plain() { return 1; }
+plain = "reassigned";
+print plain; // expect: reassigned
+
+class Plain {}
+Plain = 2;
+print Plain; // expect: 2
+
+const func fixed() { return 3; }
+print fixed(); // expect: 3
+
+const class Point {
+  init(x) { this.x = x; }
+}
+print Point(4).x; // expect: 4
+
+// a global function declared before its assignment fails when the assignment runs
+func rename() { late = nil; } // expect runtime error: Can't assign to constant 'late'.
+const func late() {}
+rename();
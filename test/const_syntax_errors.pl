@@ -0,0 +1 @@
+const NONE; // error at line 1: at ';' Expect '=' after constant name.
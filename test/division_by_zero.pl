@@ -0,0 +1,2 @@
+print 1.0 / 0 > 0; // expect: true
+print 1 / 0; // expect runtime error: Integer division by zero.
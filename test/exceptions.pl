@@ -0,0 +1,35 @@
+// any value can be thrown
+try {
+  throw "boom";
+} catch (e) {
+  print e; // expect: boom
+} finally {
+  print "always runs"; // expect: always runs
+}
+
+// runtime errors are caught as instances with message, line and kind fields
+try {
+  print 1 + "a";
+} catch (e) {
+  print e.kind;    // expect: OperandMismatch
+  print e.message; // expect: Operands must be two numbers or two strings
+  print e.line;    // expect: 12
+}
+
+// return inside try still runs finally
+func f() {
+  try { return "from try"; } finally { print "cleanup"; }
+}
+print f();
+// expect: cleanup
+// expect: from try
+
+// errors unwind through calls
+func thrower() { throw [1, 2]; }
+try {
+  thrower();
+} catch (e) {
+  print e; // expect: [1, 2]
+}
+
+throw "uncaught"; // expect runtime error: Uncaught uncaught
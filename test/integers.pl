@@ -0,0 +1,9 @@
+// numbers without a fractional part are 64-bit integers, the rest are floats
+print 7 / 2;    // expect: 3
+print 7.0 / 2;  // expect: 3.5
+print 1 == 1.0; // expect: true
+print 2.0;      // expect: 2
+print 9223372036854775807; // expect: 9223372036854775807
+print type(2 * 3);   // expect: int
+print type(2 * 3.0); // expect: float
+print 9223372036854775807 + 1; // expect runtime error: Integer overflow.
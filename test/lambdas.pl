@@ -0,0 +1,31 @@
+let add = func (a, b) { return a + b; };
+print add(1, 2); // expect: 3
+print add;       // expect: <func anonymous>
+
+// an expression body is returned
+let square = x => x * x;
+let mul = (a, b) => a * b;
+print square(4); // expect: 16
+print mul(2, 5); // expect: 10
+
+func apply(f, v) { return f(v); }
+print apply((x) => { let y = x + 1; return y * 2; }, 3); // expect: 8
+
+// lambdas close over their scope
+func counter() {
+  let n = 0;
+  return () => {
+    n = n + 1;
+    return n;
+  };
+}
+let next = counter();
+next();
+print next(); // expect: 2
+
+func local() {
+  let g = nil;
+  g = () => "assigned";
+  return g();
+}
+print local(); // expect: assigned
@@ -0,0 +1,32 @@
+let xs = [1, 2, 3];
+print xs[0];        // expect: 1
+xs[0] = 10;
+print xs;           // expect: [10, 2, 3]
+push(xs, 4);
+print pop(xs);      // expect: 4
+print len(xs);      // expect: 3
+print xs[1:];       // expect: [2, 3]
+print xs[:1];       // expect: [10]
+print "hello"[1:3]; // expect: el
+print "hello"[1];   // expect: e
+print [1, [2]] == [1, [2]]; // expect: true
+print [];           // expect: []
+
+func f() {
+  let ys = nil;
+  ys = [1];
+  ys[0] = ys[0] + 1;
+  return ys;
+}
+print f(); // expect: [2]
+
+let cyclic = [1];
+push(cyclic, cyclic);
+print cyclic;                          // expect: [1, [...]]
+print cyclic == cyclic;                // expect: true
+let other = [1];
+push(other, other);
+print cyclic == other;                 // expect: true
+print [cyclic, cyclic];                // expect: [[1, [...]], [1, [...]]]
+
+print xs[5]; // expect runtime error: Index 5 out of range for length 3
@@ -0,0 +1,7 @@
+break;           // error at line 1: Can't use 'break' outside of a loop
+func f() {
+  continue;      // error at line 3: Can't use 'continue' outside of a loop
+}
+while (true) {
+  break missing; // error at line 6: Undefined loop label 'missing'
+}
@@ -0,0 +1,28 @@
+// entries keep their insertion order
+let m = {"name": "Ahmed", "langs": ["go"], 1: true};
+print m["name"];    // expect: Ahmed
+m["city"] = "Riyadh";
+print keys(m);      // expect: ["name", "langs", 1, "city"]
+print values(m)[0]; // expect: Ahmed
+print has(m, 1);    // expect: true
+print delete(m, 1); // expect: true
+print len(m);       // expect: 3
+print {"a": [1]} == {"a": [1]}; // expect: true
+print {};           // expect: {}
+
+func f() {
+  let local = nil;
+  local = {"a": 1};
+  return local;
+}
+print f(); // expect: {"a": 1}
+
+let self = {};
+self["self"] = self;
+print self;               // expect: {"self": {...}}
+print self == self;       // expect: true
+let wrapper = [self];
+push(wrapper, wrapper);
+print wrapper;            // expect: [{"self": {...}}, [...]]
+
+print m["zz"]; // expect runtime error: Key "zz" not found
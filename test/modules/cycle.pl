@@ -0,0 +1 @@
+import "lib/cycle_a.pl"; // expect runtime error: Circular import cycle_a.pl -> cycle_b.pl -> cycle_a.pl
@@ -0,0 +1,8 @@
+import "lib/math.pl" as math;
+from "lib/math.pl" import square, PI;
+import "lib/math.pl"; // bound to 'math' by default
+
+print math.square(3); // expect: 9
+print square(4);      // expect: 16
+print PI;             // expect: 3.14
+print math._cache;    // expect runtime error: Module 'math' has no exported member '_cache'
@@ -0,0 +1,3 @@
+// module state is shared by every importer since a module runs once
+let count = 0;
+func increment() { count++; return count; }
@@ -0,0 +1 @@
+throw "failed while loading"; // expect runtime error: Uncaught failed while loading
@@ -0,0 +1,4 @@
+// imported by the module tests, every top level name but the private ones is exported
+let PI = 3.14;
+let _cache = nil;
+func square(x) { return x * x; }
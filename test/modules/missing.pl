@@ -0,0 +1 @@
+import "lib/nope.pl"; // expect runtime error: Module 'lib/nope.pl' not found
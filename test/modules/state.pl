@@ -0,0 +1,23 @@
+import "lib/counter.pl";
+from "lib/counter.pl" import increment;
+
+increment();
+print counter.increment(); // expect: 2
+print counter.count;       // expect: 2
+print counter;             // expect: <module counter>
+print type(counter);       // expect: module
+
+// imports inside functions bind locals
+func local() {
+  from "lib/math.pl" import square;
+  import "lib/math.pl" as m;
+  return square(m.PI);
+}
+print local() > 9; // expect: true
+
+try {
+  import "lib/failing.pl";
+} catch (e) {
+  print e; // expect: failed while loading
+}
+from "lib/math.pl" import nope; // expect runtime error: Module 'math' has no exported member 'nope'
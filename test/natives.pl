@@ -0,0 +1,14 @@
+// built-in functions
+print len("hello");   // expect: 5
+print str(12) + "!";  // expect: 12!
+print num("3.5") + 1; // expect: 4.5
+print num("7");       // expect: 7
+print type("a");      // expect: string
+print type(1);        // expect: int
+print type(1.5);      // expect: float
+print type(nil);      // expect: nil
+print int(3.9);       // expect: 3
+print float(3) / 2;   // expect: 1.5
+print type(clock());  // expect: float
+print len;            // expect: <native func len>
+len(1, 2);            // expect runtime error: Function <native func len> expected 1 argument but got 2
@@ -0,0 +1,19 @@
+// % keeps the sign of the dividend, ** is right associative and binds tighter than unary minus
+print 7 % 3;       // expect: 1
+print -7 % 3;      // expect: -1
+print 2 ** 3 ** 2; // expect: 512
+print -2 ** 2;     // expect: -4
+print 2 ** -1;     // expect: 0.5
+let n = 17;
+n %= 5;
+print n;           // expect: 2
+
+// bitwise operators work on whole numbers
+print 6 & 3;      // expect: 2
+print 6 | 3;      // expect: 7
+print 6 ^ 3;      // expect: 5
+print ~5;         // expect: -6
+print 1 << 4;     // expect: 16
+print 256 >> 2;   // expect: 64
+print 5 & 1 == 1; // expect: true
+print 1.5 & 1;    // expect runtime error: Operands must be whole numbers.
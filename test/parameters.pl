@@ -0,0 +1,20 @@
+// default values are evaluated on each call and see the parameters before them
+func greet(name, greeting = "Hello", punctuation = "!") {
+  return greeting + ", " + name + punctuation;
+}
+print greet("Ann");       // expect: Hello, Ann!
+print greet("Bob", "Hi"); // expect: Hi, Bob!
+
+func pair(a, b = a * 2) { return [a, b]; }
+print pair(3); // expect: [3, 6]
+
+// the rest parameter collects the extra arguments in a list
+func sum(first, ...rest) {
+  let total = first;
+  for (let i = 0; i < len(rest); i++) total += rest[i];
+  return total;
+}
+print sum(1);       // expect: 1
+print sum(1, 2, 3); // expect: 6
+
+greet(); // expect runtime error: Function <func greet> expected 1 to 3 arguments but got 0
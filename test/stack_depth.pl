@@ -0,0 +1,12 @@
+// both backends overflow at the same depth
+let depth = 0;
+func r() {
+  depth = depth + 1;
+  r();
+}
+try {
+  r();
+} catch (e) {
+  print e.message; // expect: Stack overflow.
+  print depth;     // expect: 16384
+}
@@ -0,0 +1,2 @@
+func r(n) { return r(n + 1); } // expect runtime error: Stack overflow.
+r(0);
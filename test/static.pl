@@ -0,0 +1,27 @@
+class Geometry {
+  static let unit = 1;
+  static max(a, b) {
+    if (a > b) return a;
+    return b;
+  }
+}
+print Geometry.max(3, 9); // expect: 9
+print Geometry.unit;      // expect: 1
+Geometry.unit = 2;
+print Geometry.unit;      // expect: 2
+
+class Rect {
+  init(w, h) {
+    this.w = w;
+    this.h = h;
+  }
+  // getters run on property access
+  get area {
+    return this.w * this.h;
+  }
+  static square(side) {
+    return Rect(side, side);
+  }
+}
+print Rect(2, 3).area;     // expect: 6
+print Rect.square(4).area; // expect: 16
@@ -0,0 +1,5 @@
+class A {
+  static f() {
+    return this; // error at line 3: Can't use 'this' keyword in a static member
+  }
+}
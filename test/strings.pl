@@ -0,0 +1,11 @@
+// strings are measured, indexed and sliced by characters (runes) not bytes
+let word = "héllo";
+print len(word);   // expect: 5
+print word[1];     // expect: é
+print word[1:3];   // expect: él
+print word[4];     // expect: o
+print len("日本語"); // expect: 3
+print "日本語"[2];   // expect: 語
+print "日本語"[:2];  // expect: 日本
+print len("");     // expect: 0
+print word[5];     // expect runtime error: Index 5 out of range for length 5
@@ -0,0 +1,31 @@
+// the first case holding a value equal to the switch value runs, there's no fallthrough
+func describe(age) {
+  switch (age) {
+    case 0:
+      return "newborn";
+    case 1, 2, 3:
+      return "toddler";
+    case "unknown", nil:
+      return "not told";
+    default:
+      return "older";
+  }
+}
+print describe(2);   // expect: toddler
+print describe(nil); // expect: not told
+print describe(40);  // expect: older
+
+// each case body is a scope of its own, break targets the enclosing loop
+for (let i = 0; i < 3; i = i + 1) {
+  switch (i) {
+    case 1:
+      let name = "one";
+      print name;
+    case 2:
+      break;
+  }
+  print i;
+}
+// expect: 0
+// expect: one
+// expect: 1
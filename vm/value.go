@@ -0,0 +1,243 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+)
+
+// runtime representation of functions, the compiled function with the variables it captured
+type Closure struct {
+	Function *compiler.Function
+	upvalues []*Upvalue
+	// the globals of the closure are the ones of the module it was created in
+	module *Module
+}
+
+func (c *Closure) String() string {
+	return c.Function.String()
+}
+
+func (c *Closure) TypeName() string {
+	return "function"
+}
+
+// the runtime state owned by a single source file, every module has its own globals
+type Module struct {
+	Path    string
+	globals map[string]interface{}
+	// the globals defined by constant declarations, functions and classes
+	constants map[string]bool
+}
+
+func newModule(path string) *Module {
+	return &Module{
+		Path:      path,
+		globals:   map[string]interface{}{},
+		constants: map[string]bool{},
+	}
+}
+
+// looks up an exported global, names starting with an underscore are private to their module
+func (m *Module) get(name string) (interface{}, bool) {
+	value, ok := m.globals[name]
+	return value, ok && !strings.HasPrefix(name, "_")
+}
+
+// the file name without its extension
+func (m *Module) name() string {
+	base := filepath.Base(m.Path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func (m *Module) String() string {
+	return "<module " + m.name() + ">"
+}
+
+func (m *Module) TypeName() string {
+	return "module"
+}
+
+// a variable captured by a closure.
+// while the variable is still on the stack the upvalue refers to its slot,
+// once the variable goes out of scope the value moves into the upvalue itself.
+type Upvalue struct {
+	slot   int
+	open   bool
+	closed interface{}
+}
+
+type Class struct {
+	name       string
+	methods    map[string]*Closure
+	getters    map[string]*Closure
+	superclass *Class
+	// static methods and fields are looked up on the class itself
+	staticMethods map[string]*Closure
+	fields        map[string]interface{}
+}
+
+func newClass(name string) *Class {
+	return &Class{
+		name:          name,
+		methods:       map[string]*Closure{},
+		getters:       map[string]*Closure{},
+		staticMethods: map[string]*Closure{},
+		fields:        map[string]interface{}{},
+	}
+}
+
+func (c *Class) lookForMethod(name string) *Closure {
+	for class := c; class != nil; class = class.superclass {
+		if method, ok := class.methods[name]; ok {
+			return method
+		}
+	}
+	return nil
+}
+
+func (c *Class) lookForGetter(name string) *Closure {
+	for class := c; class != nil; class = class.superclass {
+		if getter, ok := class.getters[name]; ok {
+			return getter
+		}
+	}
+	return nil
+}
+
+// lookup a static field or a static method on the class and its superclasses
+func (c *Class) getStatic(name string) (interface{}, bool) {
+	for class := c; class != nil; class = class.superclass {
+		if field, ok := class.fields[name]; ok {
+			return field, true
+		}
+		if method, ok := class.staticMethods[name]; ok {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Class) String() string {
+	return "<class " + c.name + ">"
+}
+
+func (c *Class) TypeName() string {
+	return "class"
+}
+
+type Instance struct {
+	class  *Class
+	fields map[string]interface{}
+}
+
+func (i *Instance) String() string {
+	return "<instance of " + i.class.name + ">"
+}
+
+func (i *Instance) TypeName() string {
+	return "instance"
+}
+
+// a method accessed on an instance, calling it puts the receiver in slot zero
+type BoundMethod struct {
+	receiver *Instance
+	method   *Closure
+}
+
+func (b *BoundMethod) String() string {
+	return b.method.String()
+}
+
+func (b *BoundMethod) TypeName() string {
+	return "function"
+}
+
+// runtime errors raised by the vm itself, the shared operations (arithmetic, indexing, ...)
+// raise the error types of the interpreter package
+type RuntimeError struct {
+	kind  string
+	msg   string
+	token expressions.Token
+}
+
+func (e *RuntimeError) Error() string {
+	return e.msg + fmt.Sprintf("[line %d]", e.token.Line)
+}
+
+func (e *RuntimeError) Message() string {
+	return e.msg
+}
+
+func (e *RuntimeError) Line() int {
+	return e.token.Line
+}
+
+// the token of the instruction that raised the error
+func (e *RuntimeError) Token() expressions.Token {
+	return e.token
+}
+
+// a value raised by a throw statement
+type ErrorThrow struct {
+	value interface{}
+	token expressions.Token
+}
+
+func (e *ErrorThrow) Error() string {
+	return fmt.Sprintf("Uncaught %s[line %d]", interpreter.Stringify(e.value), e.token.Line)
+}
+
+func (e *ErrorThrow) Value() interface{} {
+	return e.value
+}
+
+func (e *ErrorThrow) Message() string {
+	return "Uncaught " + interpreter.Stringify(e.value)
+}
+
+func (e *ErrorThrow) Token() expressions.Token {
+	return e.token
+}
+
+// implemented by the runtime errors that can be surfaced to scripts
+type runtimeError interface {
+	error
+	Message() string
+	Line() int
+}
+
+// the error a finally clause runs for, it's pushed by the handler and raised again by OP_RETHROW
+type pendingError struct {
+	err error
+}
+
+// class of the instances runtime errors are converted into when they get caught
+var errorClass = newClass("Error")
+
+// the value a catch clause binds for the given error.
+// thrown values are passed as is and runtime errors become Error instances
+// with message, line and kind fields.
+func caughtValue(err error) interface{} {
+	if thrown, ok := err.(*ErrorThrow); ok {
+		return thrown.value
+	}
+	instance := &Instance{class: errorClass, fields: map[string]interface{}{}}
+	if verr, ok := err.(*RuntimeError); ok {
+		instance.fields["kind"] = verr.kind
+	} else {
+		instance.fields["kind"] = interpreter.ErrorKind(err)
+	}
+	if rerr, ok := err.(runtimeError); ok {
+		instance.fields["message"] = rerr.Message()
+		instance.fields["line"] = int64(rerr.Line())
+	} else {
+		instance.fields["message"] = err.Error()
+		instance.fields["line"] = int64(0)
+	}
+	return instance
+}
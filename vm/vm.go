@@ -0,0 +1,705 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/interpreter"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+)
+
+type frame struct {
+	closure *Closure
+	ip      int
+	// stack index of slot zero of the frame
+	base int
+	// the number of arguments the call passed
+	argc int
+}
+
+func (f *frame) readByte() byte {
+	b := f.closure.Function.Chunk.Code[f.ip]
+	f.ip++
+	return b
+}
+
+func (f *frame) readShort() int {
+	code := f.closure.Function.Chunk.Code
+	f.ip += 2
+	return int(code[f.ip-2])<<8 | int(code[f.ip-1])
+}
+
+func (f *frame) readConstant() interface{} {
+	return f.closure.Function.Chunk.Constants[f.readShort()]
+}
+
+func (f *frame) readName() string {
+	return f.readConstant().(string)
+}
+
+// installed by OP_TRY, it restores the frames and the stack to the state
+// the try statement started with and jumps to the handler code
+type errorHandler struct {
+	frames  int
+	top     int
+	ip      int
+	catches bool
+}
+
+// executes the bytecode generated by the compiler.
+// values are the same as the tree-walking interpreter's, numbers, strings, lists and maps
+// are shared and so are the operations on them so both backends behave the same.
+type VM struct {
+	stack  []interface{}
+	frames []frame
+	// the module of the script, the runtime errors of its code are reported in its file
+	main *Module
+	// upvalues referring to slots still on the stack
+	openUpvalues []*Upvalue
+	handlers     []errorHandler
+	// built-ins are native functions of the interpreter, they are called with it
+	host     *interpreter.Interpreter
+	builtins map[string]interface{}
+	importer Importer
+	// print statements write to it, stdout by default
+	stdout io.Writer
+	// the stack trace of the last uncaught error
+	unwound []string
+	// set by Cancel from another goroutine
+	canceled int32
+}
+
+// loads and runs the module for an import statement.
+// from is the path of the importing module and path is the imported path as written.
+// the vm can't depend on the loader (it compiles with the compiler) so loading is provided from outside
+type Importer interface {
+	ImportCompiled(vm *VM, from string, path string) (*Module, error)
+}
+
+func New() *VM {
+	host := interpreter.New()
+	vm := &VM{
+		main:   newModule(""),
+		host:   host,
+		stdout: os.Stdout,
+	}
+	// the host interpreter doesn't see the frames of the vm
+	vm.DefineNative("stackTrace", 0, func(*interpreter.Interpreter, []interface{}) (interface{}, error) {
+		return strings.Join(vm.stackTrace(), "\n"), nil
+	})
+	return vm
+}
+
+// names the file the script was compiled from, relative imports are resolved against it
+func (vm *VM) SetFile(file string) {
+	vm.main.Path = file
+}
+
+func (vm *VM) SetImporter(importer Importer) {
+	vm.importer = importer
+}
+
+func (vm *VM) SetOutput(out io.Writer) {
+	vm.stdout = out
+}
+
+// stops the running script before its next jump backward or call, it's safe to call from another goroutine.
+// the script stops with interpreter.ErrorAbort which can't be caught
+func (vm *VM) Cancel() {
+	atomic.StoreInt32(&vm.canceled, 1)
+}
+
+// registers a host function in the global scope under the given name
+func (vm *VM) DefineNative(name string, arity int, fn interpreter.NativeFunc) {
+	vm.host.DefineNative(name, arity, fn)
+	vm.builtins = vm.host.Builtins()
+}
+
+// runs the compiled script, uncaught errors are reported and returned
+func (vm *VM) Interpret(script *compiler.Function) error {
+	err := vm.Execute(script)
+	// an aborted program isn't a runtime error
+	if _, aborted := err.(interpreter.ErrorAbort); err != nil && !aborted {
+		reporting.ReportRuntimeErrorTrace(err, vm.Trace(err))
+	}
+	return err
+}
+
+// runs the compiled script without reporting errors
+func (vm *VM) Execute(script *compiler.Function) error {
+	closure := &Closure{Function: script, module: vm.main}
+	vm.stack = append(vm.stack[:0], closure)
+	vm.frames = append(vm.frames[:0], frame{closure: closure})
+	vm.unwound = nil
+	err := vm.run(0)
+	if err != nil {
+		// the frames are still on the stack when no handler caught the error
+		if len(vm.frames) > 1 {
+			vm.unwound = vm.stackTrace()
+		}
+		vm.stack = vm.stack[:0]
+		vm.frames = vm.frames[:0]
+		vm.openUpvalues = vm.openUpvalues[:0]
+		vm.handlers = vm.handlers[:0]
+	}
+	return err
+}
+
+// the stack trace of the uncaught error the last script stopped with, nil if it was raised outside of functions
+func (vm *VM) Trace(err error) []string {
+	return vm.unwound
+}
+
+// runs the compiled script of an imported module in a module of its own.
+// the importer calls it while the importing code waits on OP_IMPORT, the module runs on top of its frames
+func (vm *VM) RunModule(path string, script *compiler.Function) (*Module, error) {
+	m := newModule(path)
+	closure := &Closure{Function: script, module: m}
+	depth := len(vm.frames)
+	vm.push(closure)
+	if err := vm.call(closure, 0); err != nil {
+		return nil, err
+	}
+	if err := vm.run(depth); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (vm *VM) push(value interface{}) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() interface{} {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) peek(distance int) interface{} {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+// the source token of the instruction being executed, it's located in the file of its module
+func (vm *VM) location() expressions.Token {
+	f := &vm.frames[len(vm.frames)-1]
+	tok := f.closure.Function.Chunk.Tokens[f.ip-1]
+	tok.File = f.closure.module.Path
+	return tok
+}
+
+// the lines of the stack trace of the running frames, the innermost first
+func (vm *VM) stackTrace() []string {
+	trace := make([]string, 0, len(vm.frames))
+	for i := len(vm.frames) - 1; i >= 0; i-- {
+		f := &vm.frames[i]
+		function := f.closure.Function
+		name := "<script>"
+		if !function.IsScript() {
+			name = interpreter.Frame{Function: function.Name, Class: function.Class}.Name()
+		}
+		trace = append(trace, interpreter.TraceLine(name, f.closure.module.Path, function.Chunk.Tokens[f.ip-1].Line))
+	}
+	return interpreter.CollapseTrace(trace)
+}
+
+// token passed to the shared operations, they use it to pick the operation and locate errors
+func (vm *VM) token(kind expressions.TokenType) expressions.Token {
+	tok := vm.location()
+	tok.Kind = kind
+	return tok
+}
+
+func (vm *VM) error(kind string, msg string) error {
+	return &RuntimeError{kind: kind, msg: msg, token: vm.location()}
+}
+
+// executes the frames above depth, it returns once the frame at depth returned
+func (vm *VM) run(depth int) error {
+	f := &vm.frames[len(vm.frames)-1]
+	for {
+		var err error
+		switch compiler.OpCode(f.readByte()) {
+		case compiler.OP_CONSTANT:
+			vm.push(f.readConstant())
+		case compiler.OP_NIL:
+			vm.push(nil)
+		case compiler.OP_TRUE:
+			vm.push(true)
+		case compiler.OP_FALSE:
+			vm.push(false)
+		case compiler.OP_POP:
+			vm.pop()
+		case compiler.OP_DUP:
+			vm.push(vm.peek(0))
+		case compiler.OP_ROTATE:
+			top := len(vm.stack) - 1
+			vm.stack[top-2], vm.stack[top-1], vm.stack[top] = vm.stack[top], vm.stack[top-2], vm.stack[top-1]
+		case compiler.OP_GET_LOCAL:
+			vm.push(vm.stack[f.base+int(f.readByte())])
+		case compiler.OP_SET_LOCAL:
+			vm.stack[f.base+int(f.readByte())] = vm.peek(0)
+		case compiler.OP_GET_GLOBAL:
+			name := f.readName()
+			value, ok := f.closure.module.globals[name]
+			if !ok {
+				value, ok = vm.builtins[name]
+			}
+			if !ok {
+				err = vm.error("UndefinedVariable", fmt.Sprintf("Undefined Variable '%s'.", name))
+				break
+			}
+			vm.push(value)
+		case compiler.OP_DEFINE_GLOBAL:
+			name, m := f.readName(), f.closure.module
+			m.globals[name] = vm.pop()
+			delete(m.constants, name)
+		case compiler.OP_DEFINE_CONSTANT:
+			name, m := f.readName(), f.closure.module
+			m.globals[name] = vm.pop()
+			m.constants[name] = true
+		case compiler.OP_SET_GLOBAL:
+			name, m := f.readName(), f.closure.module
+			if m.constants[name] {
+				err = vm.error("ConstantAssignment", fmt.Sprintf("Can't assign to constant '%s'.", name))
+			} else if _, ok := m.globals[name]; ok {
+				m.globals[name] = vm.peek(0)
+			} else if _, ok := vm.builtins[name]; ok {
+				vm.builtins[name] = vm.peek(0)
+			} else {
+				err = vm.error("UndefinedVariable", fmt.Sprintf("Undefined Variable '%s'.", name))
+			}
+		case compiler.OP_GET_UPVALUE:
+			vm.push(vm.readUpvalue(f.closure.upvalues[f.readByte()]))
+		case compiler.OP_SET_UPVALUE:
+			vm.writeUpvalue(f.closure.upvalues[f.readByte()], vm.peek(0))
+		case compiler.OP_GET_PROPERTY:
+			err = vm.getProperty(f.readName())
+		case compiler.OP_SET_PROPERTY:
+			err = vm.setProperty(f.readName())
+		case compiler.OP_GET_SUPER:
+			err = vm.getSuper(f.readName())
+		case compiler.OP_EQUAL:
+			right := vm.pop()
+			vm.push(interpreter.IsEqual(vm.pop(), right))
+		case compiler.OP_GREATER:
+			err = vm.compare(scanner.GREATER)
+		case compiler.OP_GREATER_EQUAL:
+			err = vm.compare(scanner.GREATER_EQUAL)
+		case compiler.OP_LESS:
+			err = vm.compare(scanner.LESS)
+		case compiler.OP_LESS_EQUAL:
+			err = vm.compare(scanner.LESS_EQUAL)
+		case compiler.OP_ADD:
+			err = vm.arithmetic(scanner.PLUS)
+		case compiler.OP_SUBTRACT:
+			err = vm.arithmetic(scanner.MINUS)
+		case compiler.OP_MULTIPLY:
+			err = vm.arithmetic(scanner.STAR)
+		case compiler.OP_DIVIDE:
+			err = vm.arithmetic(scanner.SLASH)
+		case compiler.OP_MODULO:
+			err = vm.arithmetic(scanner.PERCENT)
+		case compiler.OP_POWER:
+			err = vm.arithmetic(scanner.STAR_STAR)
+		case compiler.OP_BIT_AND:
+			err = vm.arithmetic(scanner.AMPERSAND)
+		case compiler.OP_BIT_OR:
+			err = vm.arithmetic(scanner.PIPE)
+		case compiler.OP_BIT_XOR:
+			err = vm.arithmetic(scanner.CARET)
+		case compiler.OP_SHIFT_LEFT:
+			err = vm.arithmetic(scanner.LESS_LESS)
+		case compiler.OP_SHIFT_RIGHT:
+			err = vm.arithmetic(scanner.GREATER_GREATER)
+		case compiler.OP_NOT:
+			vm.push(!interpreter.IsTruthy(vm.pop()))
+		case compiler.OP_NEGATE:
+			var value interface{}
+			value, err = interpreter.Negate(vm.token(scanner.MINUS), vm.peek(0))
+			if err == nil {
+				vm.stack[len(vm.stack)-1] = value
+			}
+		case compiler.OP_BIT_NOT:
+			var value interface{}
+			value, err = interpreter.Complement(vm.token(scanner.TILDE), vm.peek(0))
+			if err == nil {
+				vm.stack[len(vm.stack)-1] = value
+			}
+		case compiler.OP_PRINT:
+			fmt.Fprintln(vm.stdout, interpreter.Stringify(vm.pop()))
+		case compiler.OP_JUMP:
+			offset := f.readShort()
+			f.ip += offset
+		case compiler.OP_JUMP_IF_FALSE:
+			offset := f.readShort()
+			if !interpreter.IsTruthy(vm.peek(0)) {
+				f.ip += offset
+			}
+		case compiler.OP_JUMP_IF_NIL:
+			offset := f.readShort()
+			if vm.peek(0) == nil {
+				f.ip += offset
+			}
+		case compiler.OP_JUMP_IF_PASSED:
+			param := int(f.readByte())
+			offset := f.readShort()
+			if param < f.argc {
+				f.ip += offset
+			}
+		case compiler.OP_LOOP:
+			offset := f.readShort()
+			f.ip -= offset
+			if atomic.LoadInt32(&vm.canceled) == 1 {
+				err = interpreter.ErrorAbort{}
+			}
+		case compiler.OP_CALL:
+			argc := int(f.readByte())
+			err = vm.callValue(vm.peek(argc), argc)
+		case compiler.OP_CLOSURE:
+			function := f.readConstant().(*compiler.Function)
+			closure := &Closure{Function: function, upvalues: make([]*Upvalue, function.UpvalueCount), module: f.closure.module}
+			for i := range closure.upvalues {
+				isLocal, index := f.readByte(), int(f.readByte())
+				if isLocal == 1 {
+					closure.upvalues[i] = vm.captureUpvalue(f.base + index)
+				} else {
+					closure.upvalues[i] = f.closure.upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case compiler.OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case compiler.OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(f.base)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			vm.stack = vm.stack[:f.base]
+			if len(vm.frames) == depth {
+				return nil
+			}
+			vm.push(result)
+		case compiler.OP_CLASS:
+			vm.push(newClass(f.readName()))
+		case compiler.OP_INHERIT:
+			class := vm.pop().(*Class)
+			superclass, ok := vm.peek(0).(*Class)
+			if !ok {
+				err = vm.error("InvalidSuperclass", "Superclass must be a class")
+				break
+			}
+			class.superclass = superclass
+		case compiler.OP_METHOD:
+			name, method := f.readName(), vm.pop().(*Closure)
+			vm.peek(0).(*Class).methods[name] = method
+		case compiler.OP_GETTER:
+			name, getter := f.readName(), vm.pop().(*Closure)
+			vm.peek(0).(*Class).getters[name] = getter
+		case compiler.OP_STATIC_METHOD:
+			name, method := f.readName(), vm.pop().(*Closure)
+			vm.peek(0).(*Class).staticMethods[name] = method
+		case compiler.OP_LIST:
+			count := f.readShort()
+			elements := make([]interface{}, count)
+			copy(elements, vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(interpreter.NewList(elements))
+		case compiler.OP_MAP:
+			count := f.readShort()
+			entries := vm.stack[len(vm.stack)-2*count:]
+			m := interpreter.NewMap()
+			for i := 0; i < len(entries) && err == nil; i += 2 {
+				err = m.Set(entries[i], entries[i+1], vm.token(scanner.LEFT_BRACE))
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case compiler.OP_GET_INDEX:
+			index := vm.pop()
+			var value interface{}
+			value, err = interpreter.GetIndex(vm.pop(), index, vm.token(scanner.LEFT_BRACKET))
+			vm.push(value)
+		case compiler.OP_SET_INDEX:
+			value, index := vm.pop(), vm.pop()
+			err = interpreter.SetIndex(vm.pop(), index, value, vm.token(scanner.LEFT_BRACKET))
+			vm.push(value)
+		case compiler.OP_SLICE:
+			end, start := vm.pop(), vm.pop()
+			var value interface{}
+			value, err = interpreter.Slice(vm.pop(), start, end, vm.token(scanner.LEFT_BRACKET))
+			vm.push(value)
+		case compiler.OP_THROW:
+			err = &ErrorThrow{value: vm.pop(), token: vm.location()}
+		case compiler.OP_RETHROW:
+			err = vm.pop().(*pendingError).err
+		case compiler.OP_TRY:
+			catches := f.readByte() == 1
+			offset := f.readShort()
+			vm.handlers = append(vm.handlers, errorHandler{
+				frames:  len(vm.frames),
+				top:     len(vm.stack),
+				ip:      f.ip + offset,
+				catches: catches,
+			})
+		case compiler.OP_END_TRY:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case compiler.OP_IMPORT:
+			err = vm.importModule(f.readConstant().(string))
+		}
+
+		if err != nil && !vm.handle(err, depth) {
+			return err
+		}
+		// calls, returns and handled errors change the current frame
+		f = &vm.frames[len(vm.frames)-1]
+	}
+}
+
+func (vm *VM) arithmetic(kind expressions.TokenType) error {
+	right, left := vm.pop(), vm.pop()
+	value, err := interpreter.Arithmetic(vm.token(kind), left, right)
+	vm.push(value)
+	return err
+}
+
+func (vm *VM) compare(kind expressions.TokenType) error {
+	right, left := vm.pop(), vm.pop()
+	value, err := interpreter.Compare(vm.token(kind), left, right)
+	vm.push(value)
+	return err
+}
+
+// unwinds to the innermost error handler installed above depth, it reports whether there was one.
+// the handlers below depth are left to the run the frame at depth was called from
+func (vm *VM) handle(err error, depth int) bool {
+	if _, aborted := err.(interpreter.ErrorAbort); aborted {
+		return false
+	}
+	if len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frames <= depth {
+		return false
+	}
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(h.top)
+	vm.frames = vm.frames[:h.frames]
+	vm.stack = vm.stack[:h.top]
+	if h.catches {
+		vm.push(caughtValue(err))
+	} else {
+		vm.push(&pendingError{err: err})
+	}
+	vm.frames[len(vm.frames)-1].ip = h.ip
+	return true
+}
+
+// the callee is below its arguments on the stack, it's replaced with the result
+// (or with the receiver for methods and classes so it becomes slot zero of the new frame)
+func (vm *VM) callValue(callee interface{}, argc int) error {
+	switch callee := callee.(type) {
+	case *Closure:
+		return vm.call(callee, argc)
+	case *BoundMethod:
+		vm.stack[len(vm.stack)-argc-1] = callee.receiver
+		return vm.call(callee.method, argc)
+	case *Class:
+		instance := &Instance{class: callee, fields: map[string]interface{}{}}
+		vm.stack[len(vm.stack)-argc-1] = instance
+		if init := callee.lookForMethod("init"); init != nil {
+			// arity errors name the class like the tree-walker does
+			if min, max := arity(init.Function); !interpreter.AcceptsArguments(min, max, argc) {
+				return vm.argsMismatch(callee, min, max, argc)
+			}
+			return vm.call(init, argc)
+		}
+		if argc != 0 {
+			return vm.argsMismatch(callee, 0, 0, argc)
+		}
+		return nil
+	case interpreter.Callable:
+		if min, max := callee.Arity(); !interpreter.AcceptsArguments(min, max, argc) {
+			return vm.argsMismatch(callee, min, max, argc)
+		}
+		args := make([]interface{}, argc)
+		copy(args, vm.stack[len(vm.stack)-argc:])
+		result, err := callee.Call(vm.host, args)
+		if err != nil {
+			return vm.locate(err)
+		}
+		vm.stack = vm.stack[:len(vm.stack)-argc-1]
+		vm.push(result)
+		return nil
+	}
+	return vm.error("NotCallable", fmt.Sprintf("Object %s is not callable", interpreter.Stringify(callee)))
+}
+
+// the parameters without an argument are left nil for their defaults, the extra arguments are collected in a list
+func (vm *VM) call(closure *Closure, argc int) error {
+	function := closure.Function
+	if min, max := arity(function); !interpreter.AcceptsArguments(min, max, argc) {
+		return vm.argsMismatch(closure, min, max, argc)
+	}
+	if atomic.LoadInt32(&vm.canceled) == 1 {
+		return interpreter.ErrorAbort{}
+	}
+	// the script's frame isn't a call, the interpreter doesn't count it either
+	if len(vm.frames)-1 == interpreter.MaxFrames {
+		return vm.error("StackOverflow", "Stack overflow.")
+	}
+	base := len(vm.stack) - argc - 1
+	for i := argc; i < function.Arity; i++ {
+		vm.push(nil)
+	}
+	if function.Variadic {
+		rest := []interface{}{}
+		if argc > function.Arity {
+			rest = append(rest, vm.stack[base+1+function.Arity:]...)
+			vm.stack = vm.stack[:base+1+function.Arity]
+		}
+		vm.push(interpreter.NewList(rest))
+	}
+	vm.frames = append(vm.frames, frame{closure: closure, base: base, argc: argc})
+	return nil
+}
+
+// the number of arguments the function accepts, max is -1 when there's no upper bound
+func arity(function *compiler.Function) (int, int) {
+	if function.Variadic {
+		return function.Required, -1
+	}
+	return function.Required, function.Arity
+}
+
+func (vm *VM) argsMismatch(callee interface{}, min int, max int, argc int) error {
+	return vm.error("ArgumentsMismatch", fmt.Sprintf("Function %s expected %s but got %d", interpreter.Stringify(callee), interpreter.DescribeArity(min, max), argc))
+}
+
+// errors raised by native functions don't know where they were called from
+func (vm *VM) locate(err error) error {
+	if rerr, ok := err.(runtimeError); ok && rerr.Line() == 0 {
+		return &RuntimeError{kind: interpreter.ErrorKind(err), msg: rerr.Message(), token: vm.location()}
+	}
+	return err
+}
+
+// pushes the module, the importer runs it the first time it's imported
+func (vm *VM) importModule(path string) error {
+	if vm.importer == nil {
+		return vm.error("RuntimeError", "Imports are not supported by this vm")
+	}
+	m, err := vm.importer.ImportCompiled(vm, vm.location().File, path)
+	if err != nil {
+		return vm.locate(err)
+	}
+	vm.push(m)
+	return nil
+}
+
+// replaces the object on top of the stack with its property.
+// fields shadow getters and getters shadow methods, getters are called right away
+func (vm *VM) getProperty(name string) error {
+	switch obj := vm.peek(0).(type) {
+	case *Instance:
+		if value, ok := obj.fields[name]; ok {
+			vm.stack[len(vm.stack)-1] = value
+			return nil
+		}
+		if getter := obj.class.lookForGetter(name); getter != nil {
+			return vm.call(getter, 0)
+		}
+		if method := obj.class.lookForMethod(name); method != nil {
+			vm.stack[len(vm.stack)-1] = &BoundMethod{receiver: obj, method: method}
+			return nil
+		}
+		return vm.error("UndefinedProperty", fmt.Sprintf("Undefined property '%s' on object of '%s'", name, obj.class.name))
+	case *Class:
+		if value, ok := obj.getStatic(name); ok {
+			vm.stack[len(vm.stack)-1] = value
+			return nil
+		}
+		return vm.error("UndefinedProperty", fmt.Sprintf("Undefined static property '%s' on class '%s'", name, obj.name))
+	case *Module:
+		if value, ok := obj.get(name); ok {
+			vm.stack[len(vm.stack)-1] = value
+			return nil
+		}
+		return vm.error("UndefinedProperty", fmt.Sprintf("Module '%s' has no exported member '%s'", obj.name(), name))
+	}
+	return vm.error("InvalidPropertyAccess", "not an instance, only instances have properties ")
+}
+
+// sets a field of an instance or a static field of a class, the value stays on the stack
+func (vm *VM) setProperty(name string) error {
+	value := vm.pop()
+	switch obj := vm.pop().(type) {
+	case *Instance:
+		obj.fields[name] = value
+	case *Class:
+		obj.fields[name] = value
+	default:
+		return vm.error("InvalidFieldAssignment", "not an instance, field assignment only allowed on instances and classes")
+	}
+	vm.push(value)
+	return nil
+}
+
+// the superclass is on top of the receiver, the receiver is replaced with the method bound to it
+func (vm *VM) getSuper(name string) error {
+	superclass := vm.pop().(*Class)
+	receiver := vm.peek(0).(*Instance)
+	if getter := superclass.lookForGetter(name); getter != nil {
+		return vm.call(getter, 0)
+	}
+	method := superclass.lookForMethod(name)
+	if method == nil {
+		return vm.error("UndefinedProperty", "Undefined property "+name)
+	}
+	vm.stack[len(vm.stack)-1] = &BoundMethod{receiver: receiver, method: method}
+	return nil
+}
+
+// reuses the upvalue of a slot if a closure already captured it so closures share the variable
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	for _, uv := range vm.openUpvalues {
+		if uv.slot == slot {
+			return uv
+		}
+	}
+	uv := &Upvalue{slot: slot, open: true}
+	vm.openUpvalues = append(vm.openUpvalues, uv)
+	return uv
+}
+
+// moves the values of the slots at or above last into their upvalues
+func (vm *VM) closeUpvalues(last int) {
+	open := vm.openUpvalues[:0]
+	for _, uv := range vm.openUpvalues {
+		if uv.slot >= last {
+			uv.closed = vm.stack[uv.slot]
+			uv.open = false
+		} else {
+			open = append(open, uv)
+		}
+	}
+	vm.openUpvalues = open
+}
+
+func (vm *VM) readUpvalue(uv *Upvalue) interface{} {
+	if uv.open {
+		return vm.stack[uv.slot]
+	}
+	return uv.closed
+}
+
+func (vm *VM) writeUpvalue(uv *Upvalue, value interface{}) {
+	if uv.open {
+		vm.stack[uv.slot] = value
+	} else {
+		uv.closed = value
+	}
+}
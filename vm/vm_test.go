@@ -0,0 +1,105 @@
+package vm_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Ahmed-Sermani/prolang/compiler"
+	"github.com/Ahmed-Sermani/prolang/parser"
+	"github.com/Ahmed-Sermani/prolang/parser/expressions"
+	"github.com/Ahmed-Sermani/prolang/reporting"
+	"github.com/Ahmed-Sermani/prolang/scanner"
+	"github.com/Ahmed-Sermani/prolang/vm"
+)
+
+// compiles and runs the source, it returns the output and the error the script stopped with
+func run(t *testing.T, source string) (*vm.VM, string, error) {
+	t.Helper()
+	var script *compiler.Function
+	diagnostics := reporting.Collect(func() {
+		s := scanner.New(source)
+		s.SetFile("main.pl")
+		stmts := parser.New(s.ScanTokens()).Parse()
+		if !reporting.HadError() {
+			script = compiler.New().Compile(stmts)
+		}
+	})
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected compile error at line %d: %s", diagnostics[0].Line, diagnostics[0].Msg)
+	}
+	var out bytes.Buffer
+	machine := vm.New()
+	machine.SetFile("main.pl")
+	machine.SetOutput(&out)
+	err := machine.Execute(script)
+	return machine, out.String(), err
+}
+
+// methods are named after their class like in the tree-walker's traces
+func TestTraceNamesMethods(t *testing.T) {
+	source := `class A {
+  m() { return nope; }
+  static s() { return A().m(); }
+  get g { return A.s(); }
+}
+A().g;`
+	machine, _, err := run(t, source)
+	if err == nil {
+		t.Fatal("expected a runtime error")
+	}
+	want := []string{
+		"at A.m (main.pl:2)",
+		"at A.s (main.pl:3)",
+		"at A.g (main.pl:4)",
+		"at <script> (main.pl:6)",
+	}
+	if got := machine.Trace(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// the arity of an initializer is reported on its class
+func TestInitializerArity(t *testing.T) {
+	_, _, err := run(t, "class K { init(a) {} }\nK();")
+	want := "Function <class K> expected 1 argument but got 0[line 2]"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestImportWithoutImporter(t *testing.T) {
+	_, _, err := run(t, `import "lib.pl";`)
+	want := "Imports are not supported by this vm[line 1]"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+// a canceled script stops even inside a try statement
+func TestCancel(t *testing.T) {
+	var script *compiler.Function
+	reporting.Collect(func() {
+		script = compiler.New().Compile(parser.New(scanner.New("try { while (true) {} } catch (e) { print e; }").ScanTokens()).Parse())
+	})
+	machine := vm.New()
+	var out bytes.Buffer
+	machine.SetOutput(&out)
+	machine.Cancel()
+	if err := machine.Execute(script); err == nil || out.Len() != 0 {
+		t.Errorf("expected the script to stop without output, got %v and %q", err, out.String())
+	}
+}
+
+// runtime errors point at the token of the failing instruction
+func TestErrorColumn(t *testing.T) {
+	_, _, err := run(t, "let a = 1;\nprint a + \"x\";")
+	located, ok := err.(interface{ Token() expressions.Token })
+	if !ok {
+		t.Fatalf("expected a located error, got %v", err)
+	}
+	tok := located.Token()
+	if tok.File != "main.pl" || tok.Line != 2 || tok.Column != 9 {
+		t.Errorf("got %s:%d:%d, want main.pl:2:9", tok.File, tok.Line, tok.Column)
+	}
+}